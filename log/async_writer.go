@@ -1,7 +1,9 @@
 package log
 
 import (
-	"context"
+	"bytes"
+	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,36 +11,126 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// 缓冲区满时的处理策略
+const (
+	// asyncPolicyBlock 阻塞等待，不丢日志
+	asyncPolicyBlock = "block"
+	// asyncPolicyDropNewest 丢弃当前写入的日志
+	asyncPolicyDropNewest = "dropNewest"
+	// asyncPolicyDropOldest 丢弃缓冲区中最旧的日志，为当前日志腾出位置
+	asyncPolicyDropOldest = "dropOldest"
+)
+
+// 单批写入的最大字节数
+const asyncMaxBatchBytes = 256 << 10
+
+// asyncOptions 异步写入选项
+type asyncOptions struct {
+	// 缓冲区可容纳的日志条数
+	bufferSize int
+	// 缓冲区满时的处理策略
+	policy string
+	// 单批最多合并的日志条数
+	batchSize int
+	// 未攒满一批时的最长等待时间
+	flushInterval time.Duration
+}
+
+// defaultAsyncOptions 默认选项：缓冲 10000 条、满时阻塞、每批 256 条、100ms 刷新
+func defaultAsyncOptions() asyncOptions {
+	return asyncOptions{
+		bufferSize:    10000,
+		policy:        asyncPolicyBlock,
+		batchSize:     256,
+		flushInterval: 100 * time.Millisecond,
+	}
+}
+
+// asyncOptionsFromConfig 从日志配置中取出异步写入选项，<=0 的值在 newAsyncWriter 中使用默认值
+func asyncOptionsFromConfig(config *LogConfig) asyncOptions {
+	return asyncOptions{
+		bufferSize:    config.AsyncBufferSize,
+		policy:        config.AsyncPolicy,
+		batchSize:     config.AsyncBatchSize,
+		flushInterval: config.AsyncFlushInterval,
+	}
+}
+
+// parseAsyncPolicy 解析缓冲区满时的策略名称（不区分大小写，可使用 drop-newest 形式）
+func parseAsyncPolicy(s string) (string, bool) {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), "-", "")) {
+	case "block":
+		return asyncPolicyBlock, true
+	case "dropnewest":
+		return asyncPolicyDropNewest, true
+	case "dropoldest":
+		return asyncPolicyDropOldest, true
+	}
+	return "", false
+}
+
+// AsyncStats 异步写入计数
+type AsyncStats struct {
+	// 进入缓冲区的日志条数
+	Enqueued uint64
+	// 成功写入底层 writer 的日志条数
+	Written uint64
+	// 因缓冲区满被丢弃的日志条数
+	Dropped uint64
+	// 底层 writer 写入失败的次数
+	WriteErrors uint64
+}
+
+func (s AsyncStats) String() string {
+	return fmt.Sprintf("enqueued=%d written=%d dropped=%d write_errors=%d",
+		s.Enqueued, s.Written, s.Dropped, s.WriteErrors)
+}
+
+// asyncWriter 异步写入器，后台 goroutine 按批合并写入底层 writer
 type asyncWriter struct {
-	writer    zapcore.WriteSyncer
-	ch        chan []byte
-	ctx       context.Context
-	cancel    context.CancelFunc
-	wg        sync.WaitGroup
-	closeOnce sync.Once
-	syncCh    chan struct{} // 用于 Sync 操作
-	mu        sync.Mutex
-	pending   int64 // 待处理的数据计数
-	closed    int32 // 原子标记，表示是否已关闭
+	writer zapcore.WriteSyncer
+	opts   asyncOptions
+
+	ch      chan []byte
+	flushCh chan chan error
+	done    chan struct{}
+
+	// mu 保护 closed，保证关闭 ch 时没有并发的发送
+	mu     sync.RWMutex
+	closed bool
+
+	enqueued    atomic.Uint64
+	written     atomic.Uint64
+	dropped     atomic.Uint64
+	writeErrors atomic.Uint64
 }
 
-func newAsyncWriter(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+func newAsyncWriter(ws zapcore.WriteSyncer, opts asyncOptions) zapcore.WriteSyncer {
 	if ws == nil {
 		// 如果 writer 为 nil，返回一个安全的空实现，避免 panic
 		return zapcore.AddSync(&nullWriter{})
 	}
+	defaults := defaultAsyncOptions()
+	if opts.bufferSize <= 0 {
+		opts.bufferSize = defaults.bufferSize
+	}
+	if opts.policy == "" {
+		opts.policy = defaults.policy
+	}
+	if opts.batchSize <= 0 {
+		opts.batchSize = defaults.batchSize
+	}
+	if opts.flushInterval <= 0 {
+		opts.flushInterval = defaults.flushInterval
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
 	aw := &asyncWriter{
-		writer: ws,
-		ch:     make(chan []byte, 10000),
-		ctx:    ctx,
-		cancel: cancel,
-		syncCh: make(chan struct{}, 1),
-		closed: 0,
+		writer:  ws,
+		opts:    opts,
+		ch:      make(chan []byte, opts.bufferSize),
+		flushCh: make(chan chan error),
+		done:    make(chan struct{}),
 	}
-
-	aw.wg.Add(1)
 	go aw.run()
 	return aw
 }
@@ -50,173 +142,160 @@ func (n *nullWriter) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
+// Write 将日志放入缓冲区，缓冲区满时按策略阻塞或丢弃
+// 关闭后直接同步写入底层 writer
 func (a *asyncWriter) Write(p []byte) (int, error) {
-	// 检查是否已关闭
-	if atomic.LoadInt32(&a.closed) == 1 {
-		// 如果已经关闭，直接写入（同步模式），避免向已关闭的 channel 写入导致 panic
-		if a.writer != nil {
-			return a.writer.Write(p)
-		}
-		return len(p), nil
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return a.writer.Write(p)
 	}
 
-	// 复制数据，避免外部修改
+	// 复制数据，zap 会复用传入的缓冲区
 	cp := make([]byte, len(p))
 	copy(cp, p)
 
-	// 尝试将数据放入 channel
-	select {
-	case a.ch <- cp:
-		// 成功放入 channel，增加 pending 计数
-		a.mu.Lock()
-		a.pending++
-		a.mu.Unlock()
-		return len(p), nil
-	case <-a.ctx.Done():
-		// 如果已经关闭，直接写入（同步模式）
-		if a.writer != nil {
-			return a.writer.Write(p)
-		}
-		return len(p), nil
-	default:
-		// channel 满了，阻塞等待（避免丢失数据）
+	switch a.opts.policy {
+	case asyncPolicyDropNewest:
 		select {
 		case a.ch <- cp:
-			// 成功放入 channel，增加 pending 计数
-			a.mu.Lock()
-			a.pending++
-			a.mu.Unlock()
+		default:
+			a.dropped.Add(1)
 			return len(p), nil
-		case <-a.ctx.Done():
-			// 如果已经关闭，直接写入（同步模式）
-			if a.writer != nil {
-				return a.writer.Write(p)
+		}
+	case asyncPolicyDropOldest:
+		for sent := false; !sent; {
+			select {
+			case a.ch <- cp:
+				sent = true
+			default:
+				select {
+				case <-a.ch:
+					a.dropped.Add(1)
+				default:
+				}
 			}
-			return len(p), nil
 		}
+	default:
+		a.ch <- cp
 	}
+	a.enqueued.Add(1)
+	return len(p), nil
 }
 
+// Sync 等待调用前进入缓冲区的日志全部写出，再同步底层 writer
 func (a *asyncWriter) Sync() error {
-	// 如果已关闭，直接同步底层 writer
-	if atomic.LoadInt32(&a.closed) == 1 {
-		if a.writer != nil {
-			return a.writer.Sync()
-		}
-		return nil
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return a.writer.Sync()
 	}
+	ack := make(chan error, 1)
+	a.flushCh <- ack
+	a.mu.RUnlock()
+	return <-ack
+}
 
-	// 发送同步信号
-	select {
-	case a.syncCh <- struct{}{}:
-	default:
-		// syncCh 已满，说明有同步操作正在进行，等待一下
-		time.Sleep(10 * time.Millisecond)
+// Stats 返回当前计数
+func (a *asyncWriter) Stats() AsyncStats {
+	return AsyncStats{
+		Enqueued:    a.enqueued.Load(),
+		Written:     a.written.Load(),
+		Dropped:     a.dropped.Load(),
+		WriteErrors: a.writeErrors.Load(),
 	}
+}
 
-	// 等待所有待处理的数据写入完成
-	// 使用超时保护，避免无限等待
-	timeout := time.NewTimer(5 * time.Second)
-	defer timeout.Stop()
+func (a *asyncWriter) run() {
+	defer close(a.done)
 
-	ticker := time.NewTicker(10 * time.Millisecond)
+	ticker := time.NewTicker(a.opts.flushInterval)
 	defer ticker.Stop()
 
-	done := false
-	for !done {
-		a.mu.Lock()
-		pending := a.pending
-		a.mu.Unlock()
-
-		if pending == 0 {
-			// 所有数据都已处理完成
-			done = true
-			break
+	var batch bytes.Buffer
+	count := 0
+	flush := func() {
+		if count == 0 {
+			return
 		}
-
-		select {
-		case <-timeout.C:
-			// 超时，不再等待
-			done = true
-		case <-ticker.C:
-			// 继续检查
-		case <-a.ctx.Done():
-			// 如果已关闭，不再等待
-			done = true
+		// 日志写入错误只计数，不影响业务逻辑
+		if _, err := a.writer.Write(batch.Bytes()); err != nil {
+			a.writeErrors.Add(1)
+		} else {
+			a.written.Add(uint64(count))
 		}
+		batch.Reset()
+		count = 0
 	}
-
-	// 调用底层 writer 的 Sync
-	if a.writer != nil {
-		return a.writer.Sync()
+	add := func(p []byte) {
+		batch.Write(p)
+		count++
+		if count >= a.opts.batchSize || batch.Len() >= asyncMaxBatchBytes {
+			flush()
+		}
 	}
-	return nil
-}
 
-func (a *asyncWriter) run() {
-	defer a.wg.Done()
 	for {
 		select {
 		case p, ok := <-a.ch:
 			if !ok {
-				// channel 已关闭，退出
+				// 已关闭，剩余数据已全部取出
+				flush()
 				return
 			}
-			// 写入数据，忽略错误（日志写入错误通常不应该影响业务逻辑）
-			// 如果 writer 为 nil，这里会 panic，但正常情况下不应该发生
-			if a.writer != nil {
-				_, _ = a.writer.Write(p)
-			}
-			// 减少待处理计数
-			a.mu.Lock()
-			if a.pending > 0 {
-				a.pending--
-			}
-			a.mu.Unlock()
-		case <-a.syncCh:
-			// 同步信号，继续处理（Sync 会通过检查 pending 来等待）
-			continue
-		case <-a.ctx.Done():
-			// 处理剩余的数据
-			for {
+			add(p)
+		case <-ticker.C:
+			flush()
+		case ack := <-a.flushCh:
+			// Sync 发起前入队的日志都已在缓冲区中，取出当前积压的数量即可
+			for n := len(a.ch); n > 0; n-- {
 				select {
-				case p, ok := <-a.ch:
-					if !ok {
-						// channel 已关闭
-						return
-					}
-					if a.writer != nil {
-						_, _ = a.writer.Write(p)
-					}
-					a.mu.Lock()
-					if a.pending > 0 {
-						a.pending--
-					}
-					a.mu.Unlock()
+				case p := <-a.ch:
+					add(p)
 				default:
-					return
+					// dropOldest 策略可能同时取走了数据
+					n = 0
 				}
 			}
+			flush()
+			ack <- a.writer.Sync()
 		}
 	}
 }
 
-// Close 优雅关闭异步写入器，等待所有数据写入完成
+// Close 停止接收新日志，等待缓冲区全部写出后同步底层 writer
 func (a *asyncWriter) Close() error {
-	var err error
-	a.closeOnce.Do(func() {
-		// 设置关闭标记，防止新的写入操作
-		atomic.StoreInt32(&a.closed, 1)
-		// 取消 context，停止接收新数据
-		a.cancel()
-		// 关闭 channel（这会触发 run() 中的 channel 关闭检查）
-		close(a.ch)
-		// 等待 goroutine 完成
-		a.wg.Wait()
-		// 同步底层 writer（如果 writer 为 nil，这里会 panic，但正常情况下不应该发生）
-		if a.writer != nil {
-			err = a.writer.Sync()
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.ch)
+	a.mu.Unlock()
+
+	<-a.done
+	return a.writer.Sync()
+}
+
+// AsyncStats 返回所有异步 writer 的计数之和，未启用异步写入时全部为 0
+func (l *Log) AsyncStats() AsyncStats {
+	if l.root != nil {
+		return l.root.AsyncStats()
+	}
+	l.mu.Lock()
+	writers := l.writers
+	l.mu.Unlock()
+
+	var total AsyncStats
+	for _, w := range writers {
+		if aw, ok := w.(*asyncWriter); ok {
+			s := aw.Stats()
+			total.Enqueued += s.Enqueued
+			total.Written += s.Written
+			total.Dropped += s.Dropped
+			total.WriteErrors += s.WriteErrors
 		}
-	})
-	return err
+	}
+	return total
 }
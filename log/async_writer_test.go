@@ -0,0 +1,174 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateWriter 在 gate 关闭前阻塞所有写入，用于在测试中填满缓冲区
+type gateWriter struct {
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+	err     error
+
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{gate: make(chan struct{}), started: make(chan struct{})}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.gate
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gateWriter) Sync() error { return nil }
+
+func (w *gateWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+// fillAsyncWriter 写入 "a" 并等待后台 goroutine 阻塞在底层写入上，再写入 "b"、"c" 填满容量为 2 的缓冲区
+func fillAsyncWriter(t *testing.T, policy string) (*asyncWriter, *gateWriter) {
+	t.Helper()
+	w := newGateWriter()
+	aw := newAsyncWriter(w, asyncOptions{bufferSize: 2, policy: policy, batchSize: 1, flushInterval: time.Hour}).(*asyncWriter)
+	_, _ = aw.Write([]byte("a\n"))
+	select {
+	case <-w.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("background goroutine did not start writing")
+	}
+	_, _ = aw.Write([]byte("b\n"))
+	_, _ = aw.Write([]byte("c\n"))
+	return aw, w
+}
+
+func TestAsyncWriter_Policies(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   string
+		stats  AsyncStats
+	}{
+		{asyncPolicyDropNewest, "a\nb\nc\n", AsyncStats{Enqueued: 3, Written: 3, Dropped: 1}},
+		{asyncPolicyDropOldest, "a\nc\nd\n", AsyncStats{Enqueued: 4, Written: 3, Dropped: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			aw, w := fillAsyncWriter(t, tt.policy)
+			// 缓冲区已满，写入立即返回
+			if n, err := aw.Write([]byte("d\n")); n != 2 || err != nil {
+				t.Fatalf("Write = %d, %v", n, err)
+			}
+			close(w.gate)
+			if err := aw.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if got := w.String(); got != tt.want {
+				t.Fatalf("written %q, want %q", got, tt.want)
+			}
+			if s := aw.Stats(); s != tt.stats {
+				t.Fatalf("stats %v, want %v", s, tt.stats)
+			}
+		})
+	}
+}
+
+func TestAsyncWriter_Block(t *testing.T) {
+	aw, w := fillAsyncWriter(t, asyncPolicyBlock)
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = aw.Write([]byte("d\n"))
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Write should block while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(w.gate)
+	<-done
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := w.String(); got != "a\nb\nc\nd\n" {
+		t.Fatalf("written %q", got)
+	}
+	if s := aw.Stats(); s != (AsyncStats{Enqueued: 4, Written: 4}) {
+		t.Fatalf("stats %v", s)
+	}
+}
+
+func TestAsyncWriter_WriteErrors(t *testing.T) {
+	w := newGateWriter()
+	w.err = errors.New("disk full")
+	close(w.gate)
+	aw := newAsyncWriter(w, asyncOptions{batchSize: 1}).(*asyncWriter)
+	for i := 0; i < 3; i++ {
+		_, _ = aw.Write([]byte("x\n"))
+	}
+	_ = aw.Close()
+	if s := aw.Stats(); s != (AsyncStats{Enqueued: 3, WriteErrors: 3}) {
+		t.Fatalf("stats %v", s)
+	}
+}
+
+func TestAsyncWriter_Flush(t *testing.T) {
+	w := newGateWriter()
+	close(w.gate)
+	// 批次和刷新间隔都足够大，日志只会在 Sync 和 Close 时写出
+	aw := newAsyncWriter(w, asyncOptions{batchSize: 1000, flushInterval: time.Hour}).(*asyncWriter)
+
+	var want strings.Builder
+	write := func(n int) {
+		for i := 0; i < n; i++ {
+			line := strings.Repeat("x", i%10) + "\n"
+			_, _ = aw.Write([]byte(line))
+			want.WriteString(line)
+		}
+	}
+
+	write(50)
+	if err := aw.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if got := w.String(); got != want.String() {
+		t.Fatalf("Sync wrote %d of %d bytes", len(got), want.Len())
+	}
+
+	write(50)
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := w.String(); got != want.String() {
+		t.Fatalf("Close wrote %d of %d bytes", len(got), want.Len())
+	}
+	if s := aw.Stats(); s != (AsyncStats{Enqueued: 100, Written: 100}) {
+		t.Fatalf("stats %v", s)
+	}
+
+	// 关闭后同步写入底层 writer，重复关闭无影响
+	_, _ = aw.Write([]byte("late\n"))
+	if err := aw.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if !strings.HasSuffix(w.String(), "late\n") {
+		t.Fatal("Write after Close was lost")
+	}
+}
@@ -0,0 +1,735 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobwong89757/gnbutils/yaml"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zapcore"
+)
+
+// 输出类型
+const (
+	TypeConsole = "console"
+	TypeFile    = "file"
+	TypeHybrid  = "hybrid"
+)
+
+// 默认日志文件目录
+const defaultLogDir = "./logs"
+
+// 文件输出模式
+const (
+	FileModeSeparate = "separate"
+	FileModeSingle   = "single"
+)
+
+// LogConfig 日志配置
+//
+// 通过 NewLogConfig 获取带默认值的配置后按需修改，再调用 InitLogWithConfig；
+// 也可以通过 LogConfigFromMap / LoadLogConfigFromViper / LoadLogConfigFromYaml 从配置文件加载
+type LogConfig struct {
+	// 全局最低级别，默认 debug
+	Level zapcore.Level
+	// 输出类型：console / file / hybrid，默认 console
+	Type string
+	// 输出格式：console / json / logfmt，默认 console
+	Format string
+	// 控制台和文件各自的格式，为空时使用 Format
+	ConsoleFormat string
+	FileFormat    string
+	// 控制台输出是否带颜色
+	Color bool
+	// 文件输出模式：separate（按级别分文件）/ single（单个文件），默认 separate
+	FileMode string
+	// 控制台输出的级别，为空表示全部
+	ConsoleLevels []zapcore.Level
+	// 文件输出的级别，为空表示全部
+	FileLevels []zapcore.Level
+	// 该级别及以上的日志附带堆栈，nil 表示不附带
+	StacktraceLevel *zapcore.Level
+
+	// 日志文件目录，默认 ./logs
+	Dir string
+	// 文件名模板，{name} 为日志文件名（子 logger 为 <日志文件名>_<模块名>），{level} 为级别；
+	// 默认 separate 模式为 {name}_{level}.log，single 模式为 {name}.log
+	FileNameTemplate string
+	// 按模块名（Named 的参数）配置子 logger 的级别和文件
+	Modules map[string]ModuleConfig
+
+	// 字段名，"-" 表示不输出该字段，为空时使用默认值（ts / level / logger / file / msg / stacktrace）
+	TimeKey       string
+	LevelKey      string
+	NameKey       string
+	CallerKey     string
+	MessageKey    string
+	StacktraceKey string
+	// 时间格式：rfc3339、rfc3339nano、iso8601、epoch、epochMillis、epochNanos 或 Go 时间格式
+	TimeFormat string
+
+	// 保留时长，默认 7 天，<0 表示不按时间清理；与 RotationCount 互斥
+	MaxAge time.Duration
+	// 切割间隔，默认 24h
+	RotationTime time.Duration
+	// 保留文件数量，<=0 表示不限制；设置时 MaxAge 必须 <0
+	RotationCount int
+	// 文件名时间格式，如 %Y%m%d，为空时根据 RotationTime 自动选择
+	RotationFormat string
+	// 单个文件最大字节数，<=0 表示不按大小切割
+	MaxSize int64
+	// 所有日志文件（含压缩包）的总字节数上限，<=0 表示不限制
+	MaxTotalSize int64
+	// 切割后文件的压缩格式：""、gzip、lz4
+	Compress string
+
+	// 是否异步写入
+	Async bool
+	// 异步缓冲区条数，默认 10000
+	AsyncBufferSize int
+	// 缓冲区满时的策略：block / dropNewest / dropOldest，默认 block
+	AsyncPolicy string
+	// 单批最多条数，默认 256
+	AsyncBatchSize int
+	// 刷新间隔，默认 100ms
+	AsyncFlushInterval time.Duration
+
+	// zap 采样器，见 zapcore.NewSamplerWithOptions
+	Sampling           bool
+	SamplingTick       time.Duration
+	SamplingFirst      int
+	SamplingThereafter int
+	// 按消息限流：每个窗口内前 RateLimitFirst 条输出，之后每 RateLimitThereafter 条输出一条（0 表示不再输出）
+	RateLimit           bool
+	RateLimitInterval   time.Duration
+	RateLimitFirst      int
+	RateLimitThereafter int
+
+	// 远程日志输出：syslog / ndjson / http
+	Sinks []string
+	// syslog / ndjson 的连接和写入超时，默认 1s
+	SinkTimeout time.Duration
+	Syslog      SyslogSinkConfig
+	NDJSON      NDJSONSinkConfig
+	HTTP        HTTPSinkConfig
+
+	// 告警 Webhook，配置 URL 后达到级别的日志会通过 Hook 发送，见 AddHook / NewWebhookNotifier
+	Alert AlertConfig
+
+	// 脱敏：写入任何输出（控制台、文件、远程输出和 Hook）之前替换消息和字段中的敏感信息
+	Redact RedactConfig
+}
+
+// ModuleConfig 子 logger 配置
+type ModuleConfig struct {
+	// 模块的最低级别，nil 表示与主 logger 共用级别
+	Level *zapcore.Level
+	// 是否输出到独立文件，为 true 时该模块的日志不再写入主日志文件
+	File bool
+}
+
+// SyslogSinkConfig RFC5424 syslog 输出配置
+type SyslogSinkConfig struct {
+	// udp / tcp / unix / unixgram，默认 udp
+	Network string
+	// 地址，如 127.0.0.1:514 或 /dev/log
+	Addr string
+	// APP-NAME，为空时使用日志文件名
+	Tag string
+	// 设施名称，默认 local0
+	Facility string
+	// 消息体格式，为空时使用 Format
+	Format string
+	// 最低级别，nil 表示跟随全局级别
+	Level *zapcore.Level
+}
+
+// NDJSONSinkConfig 每行一个 JSON 的网络输出配置
+type NDJSONSinkConfig struct {
+	// tcp / udp，默认 tcp
+	Network string
+	Addr    string
+	Level   *zapcore.Level
+}
+
+// HTTPSinkConfig HTTP 批量输出配置
+type HTTPSinkConfig struct {
+	URL string
+	// 每批最多条数，默认 100
+	BatchSize int
+	// 刷新间隔，默认 1s
+	FlushInterval time.Duration
+	// 单次请求超时，默认 5s
+	Timeout time.Duration
+	// 失败重试次数，默认 3
+	MaxRetries int
+	// 发送失败时落盘的目录，为空时不落盘
+	SpoolDir string
+	// 落盘总大小上限，默认 100MB
+	SpoolMaxSize int64
+	Level        *zapcore.Level
+}
+
+// AlertConfig 告警 Webhook 配置
+type AlertConfig struct {
+	// Webhook 地址，为空时不启用
+	WebhookURL string
+	// 触发告警的最低级别，默认 error
+	Level zapcore.Level
+	// 去重窗口，默认 1 分钟，<0 表示不去重
+	DedupeWindow time.Duration
+	// 每批最多条数，默认 20
+	BatchSize int
+	// 未攒满一批时的最长等待时间，默认 1s
+	FlushInterval time.Duration
+	// 请求超时，默认 5s
+	Timeout time.Duration
+}
+
+// RedactConfig 脱敏配置，未配置任何规则时不启用
+type RedactConfig struct {
+	// 敏感字段名（不区分大小写），如 password、token、authorization；
+	// 字段的值整体替换，消息中 name=value、name: value、"name":"value" 形式的值也会替换
+	Fields []string
+	// 需要替换的正则，不含分组时替换整个匹配，含分组时只替换分组匹配的部分
+	Patterns []string
+	// 替换消息和字符串字段中的手机号（validator.IsChineseMobile），保留前 3 位和后 4 位
+	Mobile bool
+	// 替换身份证号（validator.IsChineseIdNum），保留前 3 位和后 4 位
+	IDNumber bool
+	// 替换文本，默认 ***
+	Mask string
+}
+
+// NewLogConfig 返回带默认值的日志配置
+func NewLogConfig() *LogConfig {
+	async := defaultAsyncOptions()
+	return &LogConfig{
+		Level:               zapcore.DebugLevel,
+		Type:                TypeConsole,
+		Format:              formatConsole,
+		FileMode:            FileModeSeparate,
+		Dir:                 defaultLogDir,
+		MaxAge:              7 * 24 * time.Hour,
+		RotationTime:        24 * time.Hour,
+		AsyncBufferSize:     async.bufferSize,
+		AsyncPolicy:         async.policy,
+		AsyncBatchSize:      async.batchSize,
+		AsyncFlushInterval:  async.flushInterval,
+		SamplingTick:        time.Second,
+		SamplingFirst:       100,
+		SamplingThereafter:  100,
+		RateLimitInterval:   time.Second,
+		RateLimitFirst:      10,
+		RateLimitThereafter: 0,
+		SinkTimeout:         time.Second,
+		Syslog:              SyslogSinkConfig{Network: "udp", Facility: "local0"},
+		NDJSON:              NDJSONSinkConfig{Network: "tcp"},
+		HTTP: HTTPSinkConfig{
+			BatchSize:     100,
+			FlushInterval: time.Second,
+			Timeout:       5 * time.Second,
+			MaxRetries:    3,
+			SpoolMaxSize:  100 << 20,
+		},
+		Alert: AlertConfig{
+			Level:         zapcore.ErrorLevel,
+			DedupeWindow:  time.Minute,
+			BatchSize:     20,
+			FlushInterval: time.Second,
+			Timeout:       5 * time.Second,
+		},
+	}
+}
+
+// Validate 检查配置，返回所有问题
+func (c *LogConfig) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	oneOf := func(v string, allowed ...string) bool {
+		for _, a := range allowed {
+			if strings.EqualFold(v, a) {
+				return true
+			}
+		}
+		return false
+	}
+
+	check(oneOf(c.Type, "", TypeConsole, TypeFile, TypeHybrid), "unknown type %q", c.Type)
+	for _, f := range []struct{ name, value string }{
+		{"format", c.Format}, {"consoleFormat", c.ConsoleFormat}, {"fileFormat", c.FileFormat}, {"syslogFormat", c.Syslog.Format},
+	} {
+		check(oneOf(f.value, "", formatConsole, formatJSON, formatLogfmt), "unknown %s %q", f.name, f.value)
+	}
+	check(oneOf(c.FileMode, "", FileModeSeparate, FileModeSingle), "unknown fileMode %q", c.FileMode)
+
+	if c.hasFileOutput() {
+		check(c.RotationTime > 0, "rotationTime must be positive, got %s", c.RotationTime)
+		check(c.FileNameTemplate == "" || strings.Contains(c.FileNameTemplate, "{name}"),
+			"fileNameTemplate %q must contain {name}", c.FileNameTemplate)
+		check(c.FileNameTemplate == "" || strings.EqualFold(c.FileMode, FileModeSingle) || strings.Contains(c.FileNameTemplate, "{level}"),
+			"fileNameTemplate %q must contain {level} in separate mode", c.FileNameTemplate)
+	}
+	check(c.MaxAge < 0 || c.RotationCount <= 0,
+		"maxAge (%s) and rotationCount (%d) are mutually exclusive, set maxAge to -1 when using rotationCount", c.MaxAge, c.RotationCount)
+	check(c.MaxSize >= 0, "maxSize must not be negative")
+	check(c.MaxTotalSize >= 0, "maxTotalSize must not be negative")
+	check(oneOf(c.Compress, compressNone, compressGzip, compressLz4), "unknown compress %q", c.Compress)
+
+	if c.Async {
+		_, ok := parseAsyncPolicy(c.AsyncPolicy)
+		check(c.AsyncPolicy == "" || ok, "unknown asyncPolicy %q", c.AsyncPolicy)
+		check(c.AsyncBufferSize >= 0, "asyncBufferSize must not be negative")
+		check(c.AsyncBatchSize >= 0, "asyncBatchSize must not be negative")
+		check(c.AsyncFlushInterval >= 0, "asyncFlushInterval must not be negative")
+	}
+	if c.Sampling {
+		check(c.SamplingTick > 0, "samplingTick must be positive")
+		check(c.SamplingFirst >= 0 && c.SamplingThereafter >= 0, "samplingFirst and samplingThereafter must not be negative")
+	}
+	if c.RateLimit {
+		check(c.RateLimitInterval > 0, "rateLimitInterval must be positive")
+		check(c.RateLimitFirst >= 0 && c.RateLimitThereafter >= 0, "rateLimitFirst and rateLimitThereafter must not be negative")
+	}
+
+	if c.Alert.WebhookURL != "" {
+		check(c.Alert.BatchSize >= 0, "alertBatchSize must not be negative")
+		check(c.Alert.FlushInterval >= 0, "alertFlushInterval must not be negative")
+	}
+
+	for _, p := range c.Redact.Patterns {
+		_, err := regexp.Compile(p)
+		check(err == nil, "invalid redactPattern %q: %v", p, err)
+	}
+
+	for _, sink := range c.Sinks {
+		switch strings.ToLower(strings.TrimSpace(sink)) {
+		case sinkSyslog:
+			check(c.Syslog.Addr != "", "syslog sink requires syslogAddr")
+			check(oneOf(c.Syslog.Network, "", "udp", "tcp", "unix", "unixgram"), "unknown syslogNetwork %q", c.Syslog.Network)
+			_, ok := syslogFacilities[strings.ToLower(c.Syslog.Facility)]
+			check(c.Syslog.Facility == "" || ok, "unknown syslogFacility %q", c.Syslog.Facility)
+		case sinkNDJSON:
+			check(c.NDJSON.Addr != "", "ndjson sink requires ndjsonAddr")
+			check(oneOf(c.NDJSON.Network, "", "tcp", "udp"), "unknown ndjsonNetwork %q", c.NDJSON.Network)
+		case sinkHTTP:
+			check(c.HTTP.URL != "", "http sink requires httpURL")
+			check(c.HTTP.MaxRetries >= 0, "httpMaxRetries must not be negative")
+		case "":
+		default:
+			errs = append(errs, fmt.Errorf("unknown sink %q", sink))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// module 返回模块配置，模块名不区分大小写
+func (c *LogConfig) module(name string) (ModuleConfig, bool) {
+	if m, ok := c.Modules[name]; ok {
+		return m, true
+	}
+	for k, m := range c.Modules {
+		if strings.EqualFold(k, name) {
+			return m, true
+		}
+	}
+	return ModuleConfig{}, false
+}
+
+// filePath 按目录和文件名模板生成日志文件路径
+func (c *LogConfig) filePath(name, level string) string {
+	dir := c.Dir
+	if dir == "" {
+		dir = defaultLogDir
+	}
+	tmpl := c.FileNameTemplate
+	if tmpl == "" {
+		tmpl = "{name}_{level}.log"
+		if level == "" {
+			tmpl = "{name}.log"
+		}
+	}
+	if level == "" {
+		level = "all"
+	}
+	return filepath.Join(dir, strings.NewReplacer("{name}", name, "{level}", level).Replace(tmpl))
+}
+
+// hasFileOutput 是否输出到文件
+func (c *LogConfig) hasFileOutput() bool {
+	return strings.EqualFold(c.Type, TypeFile) || strings.EqualFold(c.Type, TypeHybrid)
+}
+
+// logConfigKeys map 配置项（不区分大小写）到 LogConfig 字段的解析函数
+var logConfigKeys = map[string]func(c *LogConfig, v string) error{
+	"level":            func(c *LogConfig, v string) error { return setLevel(&c.Level, v) },
+	"type":             func(c *LogConfig, v string) error { return setOneOf(&c.Type, v, logTypes...) },
+	"format":           func(c *LogConfig, v string) error { return setOneOf(&c.Format, v, logFormats...) },
+	"consoleformat":    func(c *LogConfig, v string) error { return setOneOf(&c.ConsoleFormat, v, logFormats...) },
+	"fileformat":       func(c *LogConfig, v string) error { return setOneOf(&c.FileFormat, v, logFormats...) },
+	"color":            func(c *LogConfig, v string) error { return setBool(&c.Color, v) },
+	"filemode":         func(c *LogConfig, v string) error { return setOneOf(&c.FileMode, v, FileModeSeparate, FileModeSingle) },
+	"consolelevels":    func(c *LogConfig, v string) (err error) { c.ConsoleLevels, err = parseLevelList(v); return },
+	"filelevels":       func(c *LogConfig, v string) (err error) { c.FileLevels, err = parseLevelList(v); return },
+	"stacktracelevel":  func(c *LogConfig, v string) error { return setOptionalLevel(&c.StacktraceLevel, v) },
+	"dir":              func(c *LogConfig, v string) error { c.Dir = v; return nil },
+	"filenametemplate": func(c *LogConfig, v string) error { c.FileNameTemplate = v; return nil },
+
+	"timekey":       func(c *LogConfig, v string) error { c.TimeKey = v; return nil },
+	"levelkey":      func(c *LogConfig, v string) error { c.LevelKey = v; return nil },
+	"namekey":       func(c *LogConfig, v string) error { c.NameKey = v; return nil },
+	"callerkey":     func(c *LogConfig, v string) error { c.CallerKey = v; return nil },
+	"messagekey":    func(c *LogConfig, v string) error { c.MessageKey = v; return nil },
+	"stacktracekey": func(c *LogConfig, v string) error { c.StacktraceKey = v; return nil },
+	"timeformat":    func(c *LogConfig, v string) error { c.TimeFormat = v; return nil },
+
+	"maxage":         setMaxAge,
+	"rotationtime":   func(c *LogConfig, v string) error { return setDuration(&c.RotationTime, v) },
+	"rotationcount":  setRotationCount,
+	"rotationformat": func(c *LogConfig, v string) error { c.RotationFormat = v; return nil },
+	"maxsize":        func(c *LogConfig, v string) error { return setSize(&c.MaxSize, v) },
+	"maxtotalsize":   func(c *LogConfig, v string) error { return setSize(&c.MaxTotalSize, v) },
+	"compress":       func(c *LogConfig, v string) error { c.Compress = strings.ToLower(v); return nil },
+
+	"async":              func(c *LogConfig, v string) error { return setBool(&c.Async, v) },
+	"asyncbuffersize":    func(c *LogConfig, v string) error { return setInt(&c.AsyncBufferSize, v) },
+	"asyncpolicy":        setAsyncPolicy,
+	"asyncbatchsize":     func(c *LogConfig, v string) error { return setInt(&c.AsyncBatchSize, v) },
+	"asyncflushinterval": func(c *LogConfig, v string) error { return setDuration(&c.AsyncFlushInterval, v) },
+
+	"sampling":            func(c *LogConfig, v string) error { return setBool(&c.Sampling, v) },
+	"samplingtick":        func(c *LogConfig, v string) error { return setDuration(&c.SamplingTick, v) },
+	"samplingfirst":       func(c *LogConfig, v string) error { return setInt(&c.SamplingFirst, v) },
+	"samplingthereafter":  func(c *LogConfig, v string) error { return setInt(&c.SamplingThereafter, v) },
+	"ratelimit":           func(c *LogConfig, v string) error { return setBool(&c.RateLimit, v) },
+	"ratelimitinterval":   func(c *LogConfig, v string) error { return setDuration(&c.RateLimitInterval, v) },
+	"ratelimitfirst":      func(c *LogConfig, v string) error { return setInt(&c.RateLimitFirst, v) },
+	"ratelimitthereafter": func(c *LogConfig, v string) error { return setInt(&c.RateLimitThereafter, v) },
+
+	"sinks":          func(c *LogConfig, v string) error { c.Sinks = splitList(v); return nil },
+	"sinktimeout":    func(c *LogConfig, v string) error { return setDuration(&c.SinkTimeout, v) },
+	"syslognetwork":  func(c *LogConfig, v string) error { c.Syslog.Network = strings.ToLower(v); return nil },
+	"syslogaddr":     func(c *LogConfig, v string) error { c.Syslog.Addr = v; return nil },
+	"syslogtag":      func(c *LogConfig, v string) error { c.Syslog.Tag = v; return nil },
+	"syslogfacility": func(c *LogConfig, v string) error { c.Syslog.Facility = strings.ToLower(v); return nil },
+	"syslogformat":   func(c *LogConfig, v string) error { return setOneOf(&c.Syslog.Format, v, logFormats...) },
+	"sysloglevel":    func(c *LogConfig, v string) error { return setOptionalLevel(&c.Syslog.Level, v) },
+	"ndjsonnetwork":  func(c *LogConfig, v string) error { c.NDJSON.Network = strings.ToLower(v); return nil },
+	"ndjsonaddr":     func(c *LogConfig, v string) error { c.NDJSON.Addr = v; return nil },
+	"ndjsonlevel":    func(c *LogConfig, v string) error { return setOptionalLevel(&c.NDJSON.Level, v) },
+	"httpurl":        func(c *LogConfig, v string) error { c.HTTP.URL = v; return nil },
+	"httpbatchsize":  func(c *LogConfig, v string) error { return setInt(&c.HTTP.BatchSize, v) },
+	"httpflushinterval": func(c *LogConfig, v string) error {
+		return setDuration(&c.HTTP.FlushInterval, v)
+	},
+	"httptimeout":      func(c *LogConfig, v string) error { return setDuration(&c.HTTP.Timeout, v) },
+	"httpmaxretries":   func(c *LogConfig, v string) error { return setInt(&c.HTTP.MaxRetries, v) },
+	"httpspooldir":     func(c *LogConfig, v string) error { c.HTTP.SpoolDir = v; return nil },
+	"httpspoolmaxsize": func(c *LogConfig, v string) error { return setSize(&c.HTTP.SpoolMaxSize, v) },
+	"httplevel":        func(c *LogConfig, v string) error { return setOptionalLevel(&c.HTTP.Level, v) },
+
+	"alertwebhookurl":    func(c *LogConfig, v string) error { c.Alert.WebhookURL = v; return nil },
+	"alertlevel":         func(c *LogConfig, v string) error { return setLevel(&c.Alert.Level, v) },
+	"alertdedupewindow":  func(c *LogConfig, v string) error { return setDuration(&c.Alert.DedupeWindow, v) },
+	"alertbatchsize":     func(c *LogConfig, v string) error { return setInt(&c.Alert.BatchSize, v) },
+	"alertflushinterval": func(c *LogConfig, v string) error { return setDuration(&c.Alert.FlushInterval, v) },
+	"alerttimeout":       func(c *LogConfig, v string) error { return setDuration(&c.Alert.Timeout, v) },
+
+	"redactfields":   func(c *LogConfig, v string) error { c.Redact.Fields = splitList(v); return nil },
+	"redactpatterns": func(c *LogConfig, v string) error { c.Redact.Patterns = append(c.Redact.Patterns, v); return nil },
+	"redactmobile":   func(c *LogConfig, v string) error { return setBool(&c.Redact.Mobile, v) },
+	"redactidnumber": func(c *LogConfig, v string) error { return setBool(&c.Redact.IDNumber, v) },
+	"redactmask":     func(c *LogConfig, v string) error { c.Redact.Mask = v; return nil },
+}
+
+// LogConfigFromMap 从 map 解析日志配置，key 不区分大小写（兼容 Viper 转小写后的 key）
+// 未知的 key、无法解析的值和 Validate 发现的问题都会返回错误；
+// 出错时仍返回配置，出错的项保持默认值
+//
+// 支持的 key 与 LogConfig 字段一一对应（首字母小写），另外：
+//   - maxAge: 保留天数（如 7），也可以使用时长格式（如 72h）；-1 表示不按时间清理
+//   - rotationCount: 设置且未设置 maxAge 时自动禁用按时间清理，-1 表示不限制
+//   - consoleLevels / fileLevels / sinks: 逗号分隔的列表，levels 可为 all
+//   - syslogXxx / ndjsonXxx / httpXxx / alertXxx: 对应 Syslog / NDJSON / HTTP / Alert 的字段
+//   - modules.<模块名>.level / modules.<模块名>.file: 对应 Modules 中的配置
+//   - redactPatterns: 单个正则（正则中可能有逗号，不按逗号分隔）；多个正则使用 redactPatterns.<名称>
+func LogConfigFromMap(m map[string]string) (*LogConfig, error) {
+	c := NewLogConfig()
+	var errs []error
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	maxAgeSet := false
+	for _, key := range keys {
+		lower := strings.ToLower(strings.TrimSpace(key))
+		set, ok := logConfigKeys[lower]
+		if !ok && strings.HasPrefix(lower, "modules.") {
+			set, ok = moduleConfigKey(key)
+		}
+		if !ok && strings.HasPrefix(lower, "redactpatterns.") {
+			set, ok = logConfigKeys["redactpatterns"]
+		}
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown log config key %q", key))
+			continue
+		}
+		value := strings.TrimSpace(m[key])
+		if value == "" {
+			continue
+		}
+		if err := set(c, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s %q: %w", key, value, err))
+			continue
+		}
+		if lower == "maxage" {
+			maxAgeSet = true
+		}
+	}
+	// 兼容旧行为：只设置 rotationCount 时按数量保留
+	if c.RotationCount > 0 && !maxAgeSet {
+		c.MaxAge = -1
+	}
+
+	if err := c.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return c, errors.Join(errs...)
+}
+
+// LoadLogConfigFromViper 从 Viper 的 configKey 节点加载日志配置
+//
+// 配置示例（YAML）:
+//
+//	log:
+//	  level: info
+//	  type: hybrid
+//	  format: json
+//	  rotationTime: 1h
+//	  maxAge: 7
+//	  sinks: [syslog]
+//	  syslogAddr: 127.0.0.1:514
+func LoadLogConfigFromViper(v *viper.Viper, configKey string) (*LogConfig, error) {
+	if v == nil {
+		return nil, errors.New("viper instance is nil")
+	}
+	if !v.IsSet(configKey) {
+		return nil, fmt.Errorf("log config %q not found", configKey)
+	}
+	m := make(map[string]string)
+	flattenSettings(m, "", v.GetStringMap(configKey))
+	return LogConfigFromMap(m)
+}
+
+// flattenSettings 将嵌套的配置展开为以 . 连接的 key，列表以逗号连接
+// （redactPatterns 的正则可能包含逗号，展开为 redactPatterns.<序号>）
+func flattenSettings(dst map[string]string, prefix string, settings map[string]interface{}) {
+	for k, val := range settings {
+		key := prefix + k
+		switch val := val.(type) {
+		case map[string]interface{}:
+			flattenSettings(dst, key+".", val)
+		case []interface{}:
+			if strings.EqualFold(key, "redactpatterns") {
+				for i, item := range val {
+					dst[fmt.Sprintf("%s.%03d", key, i)] = fmt.Sprint(item)
+				}
+				continue
+			}
+			items := make([]string, 0, len(val))
+			for _, item := range val {
+				items = append(items, fmt.Sprint(item))
+			}
+			dst[key] = strings.Join(items, ",")
+		case nil:
+			dst[key] = ""
+		default:
+			dst[key] = fmt.Sprint(val)
+		}
+	}
+}
+
+// LoadLogConfigFromYaml 从 yaml.YamlUtil 的 configKey 节点加载日志配置
+func LoadLogConfigFromYaml(y *yaml.YamlUtil, configKey string) (*LogConfig, error) {
+	if y == nil {
+		return nil, errors.New("yaml util is nil")
+	}
+	return LoadLogConfigFromViper(y.GetViper(), configKey)
+}
+
+// moduleConfigKey 解析 modules.<模块名>.level / modules.<模块名>.file，模块名保留原始大小写
+func moduleConfigKey(key string) (func(c *LogConfig, v string) error, bool) {
+	rest := strings.TrimSpace(key)[len("modules."):]
+	i := strings.LastIndex(rest, ".")
+	if i <= 0 {
+		return nil, false
+	}
+	name, field := rest[:i], strings.ToLower(rest[i+1:])
+	update := func(c *LogConfig, fn func(m *ModuleConfig) error) error {
+		if c.Modules == nil {
+			c.Modules = make(map[string]ModuleConfig)
+		}
+		m := c.Modules[name]
+		if err := fn(&m); err != nil {
+			return err
+		}
+		c.Modules[name] = m
+		return nil
+	}
+	switch field {
+	case "level":
+		return func(c *LogConfig, v string) error {
+			return update(c, func(m *ModuleConfig) error { return setOptionalLevel(&m.Level, v) })
+		}, true
+	case "file":
+		return func(c *LogConfig, v string) error {
+			return update(c, func(m *ModuleConfig) error { return setBool(&m.File, v) })
+		}, true
+	}
+	return nil, false
+}
+
+func setBool(dst *bool, v string) error {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return err
+	}
+	*dst = b
+	return nil
+}
+
+func setInt(dst *int, v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return err
+	}
+	*dst = n
+	return nil
+}
+
+func setDuration(dst *time.Duration, v string) error {
+	d, err := parseDuration(v)
+	if err != nil {
+		return err
+	}
+	*dst = d
+	return nil
+}
+
+func setSize(dst *int64, v string) error {
+	n, err := parseSize(v)
+	if err != nil {
+		return err
+	}
+	*dst = n
+	return nil
+}
+
+// 可选的输出类型和日志格式
+var (
+	logTypes   = []string{TypeConsole, TypeFile, TypeHybrid}
+	logFormats = []string{formatConsole, formatJSON, formatLogfmt}
+)
+
+// setOneOf 将 v 转为小写后设置到 dst，不是 allowed 之一时返回错误，dst 保持原值（默认值）
+func setOneOf(dst *string, v string, allowed ...string) error {
+	v = strings.ToLower(v)
+	for _, a := range allowed {
+		if v == a {
+			*dst = v
+			return nil
+		}
+	}
+	return fmt.Errorf("expected one of %s", strings.Join(allowed, ", "))
+}
+
+func setLevel(dst *zapcore.Level, v string) error {
+	level, ok := parseLevel(v)
+	if !ok {
+		return errors.New("unknown level")
+	}
+	*dst = level
+	return nil
+}
+
+func setOptionalLevel(dst **zapcore.Level, v string) error {
+	level, ok := parseLevel(v)
+	if !ok {
+		return errors.New("unknown level")
+	}
+	*dst = &level
+	return nil
+}
+
+// setMaxAge 解析保留时长：纯数字按天，否则按时长格式，负数表示不按时间清理
+func setMaxAge(c *LogConfig, v string) error {
+	if days, err := strconv.Atoi(v); err == nil {
+		c.MaxAge = time.Duration(days) * 24 * time.Hour
+		if days < 0 {
+			c.MaxAge = -1
+		}
+		return nil
+	}
+	return setDuration(&c.MaxAge, v)
+}
+
+// setRotationCount 解析保留文件数量，负数表示不限制
+func setRotationCount(c *LogConfig, v string) error {
+	if err := setInt(&c.RotationCount, v); err != nil {
+		return err
+	}
+	if c.RotationCount < 0 {
+		c.RotationCount = 0
+	}
+	return nil
+}
+
+func setAsyncPolicy(c *LogConfig, v string) error {
+	policy, ok := parseAsyncPolicy(v)
+	if !ok {
+		return errors.New("expected block, dropNewest or dropOldest")
+	}
+	c.AsyncPolicy = policy
+	return nil
+}
+
+// parseLevelList 解析逗号分隔的级别列表，all 或空表示全部（返回 nil）
+func parseLevelList(v string) ([]zapcore.Level, error) {
+	if strings.EqualFold(strings.TrimSpace(v), "all") {
+		return nil, nil
+	}
+	var levels []zapcore.Level
+	for _, name := range splitList(v) {
+		level, ok := parseLevel(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown level %q", name)
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// splitList 拆分逗号分隔的列表，去掉空项
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,166 @@
+package log
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogConfigFromMap(t *testing.T) {
+	// Viper 读出的 key 是小写的
+	config, err := LogConfigFromMap(map[string]string{
+		"level":           "info",
+		"type":            "hybrid",
+		"rotationtime":    "1h",
+		"rotationCount":   "24",
+		"maxSize":         "100MB",
+		"fileLevels":      "warn,error",
+		"async":           "1",
+		"asyncPolicy":     "drop-oldest",
+		"sinks":           "syslog, http",
+		"syslogAddr":      "127.0.0.1:514",
+		"httpURL":         "http://127.0.0.1/logs",
+		"httpLevel":       "error",
+		"stacktraceLevel": "error",
+	})
+	if err != nil {
+		t.Fatalf("LogConfigFromMap: %v", err)
+	}
+	if config.Level != zapcore.InfoLevel || config.RotationTime != time.Hour || config.MaxSize != 100<<20 {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+	// 只设置 rotationCount 时自动禁用按时间清理
+	if config.RotationCount != 24 || config.MaxAge >= 0 {
+		t.Fatalf("rotationCount=%d maxAge=%s", config.RotationCount, config.MaxAge)
+	}
+	if len(config.FileLevels) != 2 || config.FileLevels[0] != zapcore.WarnLevel {
+		t.Fatalf("fileLevels = %v", config.FileLevels)
+	}
+	if !config.Async || config.AsyncPolicy != asyncPolicyDropOldest {
+		t.Fatalf("async=%v policy=%s", config.Async, config.AsyncPolicy)
+	}
+	if len(config.Sinks) != 2 || config.HTTP.Level == nil || *config.HTTP.Level != zapcore.ErrorLevel {
+		t.Fatalf("sinks=%v httpLevel=%v", config.Sinks, config.HTTP.Level)
+	}
+	if config.StacktraceLevel == nil || *config.StacktraceLevel != zapcore.ErrorLevel {
+		t.Fatalf("stacktraceLevel = %v", config.StacktraceLevel)
+	}
+}
+
+func TestLogConfigFromMap_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]string
+		want   string
+	}{
+		{"typo", map[string]string{"rotationTim": "1h"}, `unknown log config key "rotationTim"`},
+		{"level", map[string]string{"level": "verbose"}, "invalid level"},
+		{"duration", map[string]string{"rotationTime": "1 hour"}, "invalid rotationTime"},
+		{"number", map[string]string{"rotationCount": "ten"}, "invalid rotationCount"},
+		{"conflict", map[string]string{"maxAge": "7", "rotationCount": "10"}, "mutually exclusive"},
+		{"levels", map[string]string{"fileLevels": "info,trace"}, `unknown level "trace"`},
+		{"sink", map[string]string{"sinks": "kafka"}, `unknown sink "kafka"`},
+		{"sink addr", map[string]string{"sinks": "syslog"}, "requires syslogAddr"},
+		{"compress", map[string]string{"compress": "zstd"}, `unknown compress "zstd"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := LogConfigFromMap(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("want error containing %q, got %v", tt.want, err)
+			}
+			if config == nil {
+				t.Fatal("config should be returned along with the error")
+			}
+		})
+	}
+}
+
+func TestInitLogWithConfig_Invalid(t *testing.T) {
+	config := NewLogConfig()
+	config.RotationCount = 10 // MaxAge 仍为默认的 7 天
+
+	var l Log
+	if err := l.InitLogWithConfig(config, "app"); err == nil {
+		t.Fatal("want error for conflicting maxAge and rotationCount")
+	}
+	if l.GetLog() != nil {
+		t.Fatal("logger should not be initialized when config is invalid")
+	}
+
+	config.MaxAge = -1
+	if err := l.InitLogWithConfig(config, "app"); err != nil {
+		t.Fatalf("InitLogWithConfig: %v", err)
+	}
+	if l.GetLog() == nil {
+		t.Fatal("logger not initialized")
+	}
+}
+
+func TestInitLog_InvalidFieldsUseDefaults(t *testing.T) {
+	config, err := LogConfigFromMap(map[string]string{"type": "stdout", "format": "XML", "fileMode": "both", "level": "warn"})
+	for _, want := range []string{`invalid type "stdout"`, `invalid format "XML"`, `invalid fileMode "both"`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("want error containing %q, got %v", want, err)
+		}
+	}
+	if config.Type != TypeConsole || config.Format != formatConsole || config.FileMode != FileModeSeparate || config.Level != zapcore.WarnLevel {
+		t.Fatalf("invalid fields should keep their defaults: type=%q format=%q fileMode=%q level=%v",
+			config.Type, config.Format, config.FileMode, config.Level)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("config with defaults should be valid: %v", err)
+	}
+
+	// 无效的文件格式使用全局格式，日志仍然输出到文件
+	dir := t.TempDir()
+	var l Log
+	l.InitLog(map[string]string{"type": "FILE", "dir": dir, "fileMode": "single", "format": "json", "fileFormat": "yaml"}, "app")
+	l.GetLog().Info("still logged")
+	_ = l.Close(context.Background())
+	if out := readFile(t, filepath.Join(dir, "app.log")); !strings.HasPrefix(out, "{") || !strings.Contains(out, "still logged") {
+		t.Fatalf("file output: %q", out)
+	}
+}
+
+func TestLoadLogConfigFromViper(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err := v.ReadConfig(strings.NewReader(`
+log:
+  level: warn
+  format: json
+  maxAge: 3
+  sinks: [ndjson]
+  ndjsonAddr: 127.0.0.1:5170
+  redactPatterns: ['\d{3,4}', 'sk-\w+']
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadLogConfigFromViper(v, "log")
+	if err != nil {
+		t.Fatalf("LoadLogConfigFromViper: %v", err)
+	}
+	if config.Level != zapcore.WarnLevel || config.Format != formatJSON || config.MaxAge != 3*24*time.Hour {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+	if len(config.Sinks) != 1 || config.NDJSON.Addr != "127.0.0.1:5170" {
+		t.Fatalf("sinks=%v ndjson=%+v", config.Sinks, config.NDJSON)
+	}
+
+	// 正则中的逗号不作为列表分隔符
+	if p := config.Redact.Patterns; len(p) != 2 || p[0] != `\d{3,4}` || p[1] != `sk-\w+` {
+		t.Fatalf("redactPatterns = %q", p)
+	}
+
+	if _, err := LoadLogConfigFromViper(v, "missing"); err == nil {
+		t.Fatal("want error for missing key")
+	}
+}
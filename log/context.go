@@ -0,0 +1,141 @@
+package log
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// 上下文中常用字段的名称
+const (
+	TraceIDKey    = "trace_id"
+	RequestIDKey  = "request_id"
+	UserIDKey     = "user_id"
+	ShardDBKey    = "shard_db"
+	ShardTableKey = "shard_table"
+)
+
+// ctxFieldsKey 上下文中存放日志字段的 key
+type ctxFieldsKey struct{}
+
+// ctxFields 上下文中的日志字段，按写入顺序保存；同名字段以后写入的为准
+type ctxFields struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// ContextWithFields 返回附加了日志字段的 context，keysAndValues 为 key、value 交替的列表
+// 通过 WithContext 取得的 logger 会自动带上这些字段
+//
+// 使用示例:
+//
+//	ctx = log.ContextWithFields(ctx, "order_id", orderID, "channel", "ios")
+//	logger.WithContext(ctx).Info("order paid")
+func ContextWithFields(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent, _ := ctx.Value(ctxFieldsKey{}).(*ctxFields)
+	fields := &ctxFields{values: make(map[string]interface{})}
+	if parent != nil {
+		fields.keys = append(fields.keys, parent.keys...)
+		for k, v := range parent.values {
+			fields.values[k] = v
+		}
+	}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			continue
+		}
+		if _, exists := fields.values[key]; !exists {
+			fields.keys = append(fields.keys, key)
+		}
+		fields.values[key] = keysAndValues[i+1]
+	}
+	return context.WithValue(ctx, ctxFieldsKey{}, fields)
+}
+
+// FieldsFromContext 返回 context 中的日志字段（key、value 交替），没有时返回 nil
+func FieldsFromContext(ctx context.Context) []interface{} {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(ctxFieldsKey{}).(*ctxFields)
+	if fields == nil {
+		return nil
+	}
+	result := make([]interface{}, 0, len(fields.keys)*2)
+	for _, k := range fields.keys {
+		result = append(result, k, fields.values[k])
+	}
+	return result
+}
+
+// fieldFromContext 返回 context 中指定字段的值
+func fieldFromContext(ctx context.Context, key string) (interface{}, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	fields, _ := ctx.Value(ctxFieldsKey{}).(*ctxFields)
+	if fields == nil {
+		return nil, false
+	}
+	v, ok := fields.values[key]
+	return v, ok
+}
+
+// ContextWithTraceID 在 context 中设置链路追踪 ID
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return ContextWithFields(ctx, TraceIDKey, traceID)
+}
+
+// ContextWithRequestID 在 context 中设置请求 ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return ContextWithFields(ctx, RequestIDKey, requestID)
+}
+
+// ContextWithUserID 在 context 中设置用户 ID
+func ContextWithUserID(ctx context.Context, userID interface{}) context.Context {
+	return ContextWithFields(ctx, UserIDKey, userID)
+}
+
+// ContextWithShard 在 context 中设置分库分表信息，tableName 为空时只记录库索引
+func ContextWithShard(ctx context.Context, dbIndex int, tableName string) context.Context {
+	if tableName == "" {
+		return ContextWithFields(ctx, ShardDBKey, dbIndex)
+	}
+	return ContextWithFields(ctx, ShardDBKey, dbIndex, ShardTableKey, tableName)
+}
+
+// TraceIDFromContext 返回 context 中的链路追踪 ID，没有时返回空字符串
+func TraceIDFromContext(ctx context.Context) string {
+	v, _ := fieldFromContext(ctx, TraceIDKey)
+	s, _ := v.(string)
+	return s
+}
+
+// RequestIDFromContext 返回 context 中的请求 ID，没有时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	v, _ := fieldFromContext(ctx, RequestIDKey)
+	s, _ := v.(string)
+	return s
+}
+
+// UserIDFromContext 返回 context 中的用户 ID
+func UserIDFromContext(ctx context.Context) (interface{}, bool) {
+	return fieldFromContext(ctx, UserIDKey)
+}
+
+// WithContext 返回带有 context 中日志字段（trace_id、request_id、user_id、分片信息等）的 logger
+// 未初始化时返回不输出任何日志的 logger
+func (l *Log) WithContext(ctx context.Context) *zap.SugaredLogger {
+	logger := l.logger
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	if fields := FieldsFromContext(ctx); len(fields) > 0 {
+		return logger.With(fields...)
+	}
+	return logger
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 日志输出格式
+const (
+	formatConsole = "console"
+	formatJSON    = "json"
+	formatLogfmt  = "logfmt"
+)
+
+// defaultTimeLayout 默认时间格式
+const defaultTimeLayout = "2006-01-02 15:04:05"
+
+// buildEncoderConfig 根据配置构建 EncoderConfig：
+//   - TimeKey / LevelKey / CallerKey / MessageKey / NameKey / StacktraceKey: 字段名，设置为 "-" 表示不输出该字段
+//   - TimeFormat: rfc3339、rfc3339nano、iso8601、epoch（秒）、epochMillis、epochNanos 或 Go 时间格式（默认 2006-01-02 15:04:05）
+func buildEncoderConfig(config *LogConfig) zapcore.EncoderConfig {
+	key := func(v, def string) string {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return def
+		}
+		if v == "-" {
+			return zapcore.OmitKey
+		}
+		return v
+	}
+
+	return zapcore.EncoderConfig{
+		MessageKey:    key(config.MessageKey, "msg"),
+		LevelKey:      key(config.LevelKey, "level"),
+		TimeKey:       key(config.TimeKey, "ts"),
+		NameKey:       key(config.NameKey, "logger"),
+		CallerKey:     key(config.CallerKey, "file"),
+		StacktraceKey: key(config.StacktraceKey, "stacktrace"),
+		LineEnding:    zapcore.DefaultLineEnding,
+		EncodeLevel:   zapcore.CapitalLevelEncoder,
+		EncodeTime:    parseTimeEncoder(config.TimeFormat),
+		EncodeCaller:  zapcore.ShortCallerEncoder,
+		EncodeDuration: func(d time.Duration, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendInt64(int64(d) / 1000000)
+		},
+	}
+}
+
+// parseTimeEncoder 解析时间格式配置
+func parseTimeEncoder(format string) zapcore.TimeEncoder {
+	format = strings.TrimSpace(format)
+	switch strings.ToLower(format) {
+	case "":
+		return zapcore.TimeEncoderOfLayout(defaultTimeLayout)
+	case "rfc3339":
+		return zapcore.RFC3339TimeEncoder
+	case "rfc3339nano":
+		return zapcore.RFC3339NanoTimeEncoder
+	case "iso8601":
+		return zapcore.ISO8601TimeEncoder
+	case "epoch":
+		return zapcore.EpochTimeEncoder
+	case "epochmillis":
+		return zapcore.EpochMillisTimeEncoder
+	case "epochnanos":
+		return zapcore.EpochNanosTimeEncoder
+	default:
+		return zapcore.TimeEncoderOfLayout(format)
+	}
+}
+
+// parseFormat 解析输出格式，未知格式返回 def
+func parseFormat(format, def string) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case formatJSON:
+		return formatJSON
+	case formatLogfmt:
+		return formatLogfmt
+	case formatConsole:
+		return formatConsole
+	default:
+		return def
+	}
+}
+
+// buildEncoder 按格式创建 Encoder，color 仅对 console 格式生效
+func buildEncoder(format string, cfg zapcore.EncoderConfig, color bool) zapcore.Encoder {
+	switch format {
+	case formatJSON:
+		return zapcore.NewJSONEncoder(cfg)
+	case formatLogfmt:
+		return newLogfmtEncoder(cfg)
+	default:
+		if color {
+			cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.NewConsoleEncoder(cfg)
+	}
+}
@@ -0,0 +1,138 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// sqlTablePattern 从 SQL 中提取第一个表名（FROM / INTO / UPDATE / JOIN 之后）
+var sqlTablePattern = regexp.MustCompile("(?i)\\b(?:FROM|INTO|UPDATE|JOIN)\\s+`?([\\w.]+)`?")
+
+// GormLoggerConfig GORM 日志桥接配置
+type GormLoggerConfig struct {
+	// 慢查询阈值，超过该耗时的 SQL 以 warn 级别输出，默认 200ms，<0 表示禁用
+	SlowThreshold time.Duration
+	// GORM 日志级别，默认 Warn（只输出慢查询和错误）
+	LogLevel gormlogger.LogLevel
+	// 忽略 ErrRecordNotFound 错误
+	IgnoreRecordNotFoundError bool
+	// 隐藏 SQL 参数，输出带 ? 占位符的 SQL
+	RedactParams bool
+}
+
+// GormLogger 将 GORM 日志输出到 zap，实现 gorm logger.Interface
+//
+// 日志级别映射：普通 SQL -> debug，慢查询 -> warn，执行错误 -> error，
+// Info / Warn / Error 方法分别对应 info / warn / error。
+type GormLogger struct {
+	logger *zap.SugaredLogger
+	config GormLoggerConfig
+}
+
+// NewGormLogger 基于 Log 创建 GORM 日志桥接，l 未初始化时不输出任何日志
+func NewGormLogger(l *Log, config GormLoggerConfig) *GormLogger {
+	var sugar *zap.SugaredLogger
+	if l != nil {
+		sugar = l.GetLog()
+	}
+	if sugar == nil {
+		sugar = zap.NewNop().Sugar()
+	}
+	if config.SlowThreshold == 0 {
+		config.SlowThreshold = 200 * time.Millisecond
+	}
+	if config.LogLevel == 0 {
+		config.LogLevel = gormlogger.Warn
+	}
+
+	// 调用位置由 GORM 计算（业务代码行），关闭 zap 自身的 caller
+	sugar = sugar.Desugar().WithOptions(zap.WithCaller(false)).Sugar().With("component", "gorm")
+	return &GormLogger{logger: sugar, config: config}
+}
+
+// WithFields 返回附加了固定字段的副本，如分库索引、数据库名
+func (g *GormLogger) WithFields(keysAndValues ...interface{}) *GormLogger {
+	return &GormLogger{logger: g.logger.With(keysAndValues...), config: g.config}
+}
+
+// LogMode 实现 logger.Interface
+func (g *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	config := g.config
+	config.LogLevel = level
+	return &GormLogger{logger: g.logger, config: config}
+}
+
+// Info 实现 logger.Interface
+func (g *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if g.config.LogLevel >= gormlogger.Info {
+		g.logger.Infow(fmt.Sprintf(msg, data...), "source", utils.FileWithLineNum())
+	}
+}
+
+// Warn 实现 logger.Interface
+func (g *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if g.config.LogLevel >= gormlogger.Warn {
+		g.logger.Warnw(fmt.Sprintf(msg, data...), "source", utils.FileWithLineNum())
+	}
+}
+
+// Error 实现 logger.Interface
+func (g *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if g.config.LogLevel >= gormlogger.Error {
+		g.logger.Errorw(fmt.Sprintf(msg, data...), "source", utils.FileWithLineNum())
+	}
+}
+
+// Trace 实现 logger.Interface，记录每条 SQL 的耗时、影响行数和表名，并附带 ctx 中的日志字段
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if g.config.LogLevel <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	isErr := err != nil && g.config.LogLevel >= gormlogger.Error &&
+		!(g.config.IgnoreRecordNotFoundError && errors.Is(err, gorm.ErrRecordNotFound))
+	isSlow := g.config.SlowThreshold > 0 && elapsed > g.config.SlowThreshold && g.config.LogLevel >= gormlogger.Warn
+	if !isErr && !isSlow && g.config.LogLevel < gormlogger.Info {
+		return
+	}
+
+	sql, rows := fc()
+	fields := []interface{}{
+		"elapsed", elapsed,
+		"rows", rows,
+		"source", utils.FileWithLineNum(),
+	}
+	if m := sqlTablePattern.FindStringSubmatch(sql); m != nil {
+		fields = append(fields, "table", m[1])
+	}
+
+	logger := g.logger
+	if ctxFields := FieldsFromContext(ctx); len(ctxFields) > 0 {
+		logger = logger.With(ctxFields...)
+	}
+	switch {
+	case isErr:
+		logger.Errorw(sql, append(fields, "error", err)...)
+	case isSlow:
+		logger.Warnw(sql, append(fields, "slow_threshold", g.config.SlowThreshold)...)
+	default:
+		logger.Debugw(sql, fields...)
+	}
+}
+
+// ParamsFilter 实现 gorm.ParamsFilter，开启 RedactParams 时丢弃 SQL 参数
+func (g *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if g.config.RedactParams {
+		return sql, nil
+	}
+	return sql, params
+}
@@ -0,0 +1,139 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newObservedLog 返回输出到内存的 Log，用于断言日志内容
+func newObservedLog() (*Log, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return &Log{logger: zap.New(core).Sugar(), level: zap.NewAtomicLevel()}, logs
+}
+
+func TestGormLogger_Trace(t *testing.T) {
+	const sql = "SELECT * FROM `users` WHERE id = 1"
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name    string
+		config  GormLoggerConfig
+		elapsed time.Duration
+		err     error
+		want    zapcore.Level // 期望的日志级别，-2 表示不输出
+	}{
+		{"fast query at warn level", GormLoggerConfig{}, time.Millisecond, nil, -2},
+		{"slow query", GormLoggerConfig{}, 300 * time.Millisecond, nil, zapcore.WarnLevel},
+		{"custom threshold", GormLoggerConfig{SlowThreshold: 10 * time.Millisecond}, 20 * time.Millisecond, nil, zapcore.WarnLevel},
+		{"slow log disabled", GormLoggerConfig{SlowThreshold: -1}, time.Second, nil, -2},
+		{"error", GormLoggerConfig{}, time.Millisecond, errBoom, zapcore.ErrorLevel},
+		{"record not found", GormLoggerConfig{}, time.Millisecond, gorm.ErrRecordNotFound, zapcore.ErrorLevel},
+		{"record not found ignored", GormLoggerConfig{IgnoreRecordNotFoundError: true}, time.Millisecond, gorm.ErrRecordNotFound, -2},
+		{"record not found ignored but slow", GormLoggerConfig{IgnoreRecordNotFoundError: true}, time.Second, gorm.ErrRecordNotFound, zapcore.WarnLevel},
+		{"info level logs every query", GormLoggerConfig{LogLevel: gormlogger.Info}, time.Millisecond, nil, zapcore.DebugLevel},
+		{"error level skips slow queries", GormLoggerConfig{LogLevel: gormlogger.Error}, time.Second, nil, -2},
+		{"silent", GormLoggerConfig{LogLevel: gormlogger.Silent}, time.Second, errBoom, -2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := newObservedLog()
+			g := NewGormLogger(l, tt.config)
+			g.Trace(context.Background(), time.Now().Add(-tt.elapsed), func() (string, int64) {
+				return sql, 1
+			}, tt.err)
+
+			entries := logs.All()
+			if tt.want == -2 {
+				if len(entries) != 0 {
+					t.Fatalf("want no log, got %v %q", entries[0].Level, entries[0].Message)
+				}
+				return
+			}
+			if len(entries) != 1 {
+				t.Fatalf("want 1 log, got %d", len(entries))
+			}
+			e := entries[0]
+			if e.Level != tt.want || e.Message != sql {
+				t.Fatalf("got %v %q, want %v", e.Level, e.Message, tt.want)
+			}
+			fields := e.ContextMap()
+			if fields["table"] != "users" || fields["rows"] != int64(1) || fields["component"] != "gorm" {
+				t.Fatalf("fields: %v", fields)
+			}
+			if _, ok := fields["slow_threshold"]; ok != (tt.want == zapcore.WarnLevel) {
+				t.Fatalf("slow_threshold present = %v, level %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestGormLogger_TraceContextFields(t *testing.T) {
+	l, logs := newObservedLog()
+	g := NewGormLogger(l, GormLoggerConfig{LogLevel: gormlogger.Info}).WithFields("db_index", 2)
+
+	ctx := ContextWithTraceID(context.Background(), "trace-1")
+	g.Trace(ctx, time.Now(), func() (string, int64) {
+		return "INSERT INTO orders_3 (id) VALUES (1)", 1
+	}, nil)
+
+	fields := logs.All()[0].ContextMap()
+	if fields["trace_id"] != "trace-1" || fields["db_index"] != int64(2) || fields["table"] != "orders_3" {
+		t.Fatalf("fields: %v", fields)
+	}
+}
+
+func TestGormLogger_ParamsFilter(t *testing.T) {
+	const sql = "SELECT * FROM users WHERE password = ?"
+	params := []interface{}{"s3cret"}
+
+	tests := []struct {
+		name   string
+		redact bool
+		want   int
+	}{
+		{"params kept", false, 1},
+		{"params redacted", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGormLogger(nil, GormLoggerConfig{RedactParams: tt.redact})
+			gotSQL, gotParams := g.ParamsFilter(context.Background(), sql, params...)
+			if gotSQL != sql || len(gotParams) != tt.want {
+				t.Fatalf("got %q %v", gotSQL, gotParams)
+			}
+		})
+	}
+
+	// LogMode 保留脱敏配置
+	g := NewGormLogger(nil, GormLoggerConfig{RedactParams: true}).LogMode(gormlogger.Info)
+	if _, gotParams := g.(gorm.ParamsFilter).ParamsFilter(context.Background(), sql, params...); gotParams != nil {
+		t.Fatalf("LogMode lost RedactParams: %v", gotParams)
+	}
+}
+
+func TestGormLogger_LogLevels(t *testing.T) {
+	l, logs := newObservedLog()
+	g := NewGormLogger(l, GormLoggerConfig{})
+	ctx := context.Background()
+
+	g.Info(ctx, "info %d", 1)
+	g.Warn(ctx, "warn %d", 2)
+	g.Error(ctx, "error %d", 3)
+	if logs.Len() != 2 || logs.All()[0].Message != "warn 2" || logs.All()[1].Level != zapcore.ErrorLevel {
+		t.Fatalf("default Warn level: got %v", logs.All())
+	}
+
+	logs.TakeAll()
+	g.LogMode(gormlogger.Info).Info(ctx, "info %d", 1)
+	if logs.Len() != 1 || logs.All()[0].Level != zapcore.InfoLevel {
+		t.Fatalf("Info level: got %v", logs.All())
+	}
+}
@@ -0,0 +1,418 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// HookEntry 交给 Hook 的日志条目
+type HookEntry struct {
+	Level      string                 `json:"level"`
+	Time       time.Time              `json:"time"`
+	LoggerName string                 `json:"logger,omitempty"`
+	Message    string                 `json:"msg"`
+	Caller     string                 `json:"caller,omitempty"`
+	Stack      string                 `json:"stacktrace,omitempty"`
+	Fields     map[string]interface{} `json:"fields,omitempty"`
+	// 该条目代表的日志条数：首次出现为 1；去重窗口结束时如有重复，
+	// 会再发送一条 Count 为窗口内重复次数的汇总
+	Count int `json:"count"`
+}
+
+// Hook 日志回调，每次收到一批条目，返回的错误输出到标准错误
+// Hook 在独立的 goroutine 中执行，不阻塞写日志；不要在 Hook 中通过同一个 logger 写达到阈值的日志
+type Hook func(entries []HookEntry) error
+
+// HookOptions Hook 选项，零值使用默认值
+type HookOptions struct {
+	// 触发的最低级别，nil 表示默认的 error
+	Level *zapcore.Level
+	// 去重窗口：窗口内 logger 名称、级别和消息都相同的条目只回调一次，默认 1 分钟，<0 表示不去重
+	DedupeWindow time.Duration
+	// 每批最多条数，默认 20
+	BatchSize int
+	// 未攒满一批时的最长等待时间，默认 1s
+	FlushInterval time.Duration
+	// 缓冲区条数，满时丢弃新条目，默认 1000
+	BufferSize int
+}
+
+// NewHookOptions 返回带默认值的 Hook 选项
+func NewHookOptions() *HookOptions {
+	level := zapcore.ErrorLevel
+	return &HookOptions{
+		Level:         &level,
+		DedupeWindow:  time.Minute,
+		BatchSize:     20,
+		FlushInterval: time.Second,
+		BufferSize:    1000,
+	}
+}
+
+// AddHook 注册 Hook，达到 opts.Level 的日志（包括子 logger 的日志）会按批回调 hook
+// 返回注销函数，注销时发送剩余的条目；opts 为 nil 时使用 NewHookOptions
+// 需在 InitLog 之后调用，Log.Sync / Log.Close 会同时刷新和关闭 Hook
+//
+// 使用示例:
+//
+//	remove := logger.AddHook(log.NewWebhookNotifier(url, nil), nil)
+//	defer remove()
+func (l *Log) AddHook(hook Hook, opts *HookOptions) (remove func()) {
+	if l.root != nil {
+		return l.root.AddHook(hook, opts)
+	}
+	l.mu.Lock()
+	hooks := l.hooks
+	l.mu.Unlock()
+	if hook == nil || hooks == nil {
+		return func() {}
+	}
+
+	r := newHookRunner(hook, hookOptionsWithDefaults(opts))
+	hooks.add(r)
+
+	l.mu.Lock()
+	l.writers = append(l.writers, r)
+	l.closers = append([]io.Closer{r}, l.closers...)
+	l.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			hooks.remove(r)
+			_ = r.Close()
+		})
+	}
+}
+
+// hookOptionsWithDefaults 将无效的选项替换为默认值
+func hookOptionsWithDefaults(opts *HookOptions) HookOptions {
+	def := NewHookOptions()
+	if opts == nil {
+		return *def
+	}
+	o := *opts
+	if o.Level == nil {
+		o.Level = def.Level
+	}
+	if o.DedupeWindow == 0 {
+		o.DedupeWindow = def.DedupeWindow
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = def.BatchSize
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = def.FlushInterval
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = def.BufferSize
+	}
+	return o
+}
+
+// hookSet 已注册的 Hook，InitLog 创建的 core 通过它分发日志
+type hookSet struct {
+	mu      sync.RWMutex
+	runners []*hookRunner
+	// 所有 Hook 中最低的触发级别，没有 Hook 时为 InvalidLevel（不启用）
+	minLevel atomic.Int32
+}
+
+func newHookSet() *hookSet {
+	s := &hookSet{}
+	s.minLevel.Store(int32(zapcore.InvalidLevel))
+	return s
+}
+
+func (s *hookSet) add(r *hookRunner) {
+	s.mu.Lock()
+	s.runners = append(s.runners, r)
+	s.updateLocked()
+	s.mu.Unlock()
+}
+
+func (s *hookSet) remove(r *hookRunner) {
+	s.mu.Lock()
+	for i, v := range s.runners {
+		if v == r {
+			s.runners = append(s.runners[:i:i], s.runners[i+1:]...)
+			break
+		}
+	}
+	s.updateLocked()
+	s.mu.Unlock()
+}
+
+func (s *hookSet) updateLocked() {
+	min := zapcore.InvalidLevel
+	for _, r := range s.runners {
+		if min == zapcore.InvalidLevel || *r.opts.Level < min {
+			min = *r.opts.Level
+		}
+	}
+	s.minLevel.Store(int32(min))
+}
+
+func (s *hookSet) enabled(lvl zapcore.Level) bool {
+	min := zapcore.Level(s.minLevel.Load())
+	return min != zapcore.InvalidLevel && lvl >= min
+}
+
+func (s *hookSet) dispatch(entry HookEntry, lvl zapcore.Level) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.runners {
+		if lvl >= *r.opts.Level {
+			r.enqueue(entry)
+		}
+	}
+}
+
+// newHookOutput 创建分发到 Hook 的输出，与控制台和文件输出并列
+func newHookOutput(s *hookSet) outputFactory {
+	return func(level zapcore.LevelEnabler) zapcore.Core {
+		return &hookCore{
+			LevelEnabler: zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+				return s.enabled(lvl) && level.Enabled(lvl)
+			}),
+			hooks: s,
+		}
+	}
+}
+
+// hookCore 将日志条目转换为 HookEntry 并分发给 Hook
+type hookCore struct {
+	zapcore.LevelEnabler
+	hooks  *hookSet
+	fields []zapcore.Field
+}
+
+func (c *hookCore) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(append(all, c.fields...), fields...)
+	return &hookCore{LevelEnabler: c.LevelEnabler, hooks: c.hooks, fields: all}
+}
+
+func (c *hookCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *hookCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	entry := HookEntry{
+		Level:      ent.Level.String(),
+		Time:       ent.Time,
+		LoggerName: ent.LoggerName,
+		Message:    ent.Message,
+		Stack:      ent.Stack,
+		Count:      1,
+	}
+	if ent.Caller.Defined {
+		entry.Caller = ent.Caller.TrimmedPath()
+	}
+	if len(enc.Fields) > 0 {
+		entry.Fields = enc.Fields
+	}
+	c.hooks.dispatch(entry, ent.Level)
+	return nil
+}
+
+func (c *hookCore) Sync() error {
+	return nil
+}
+
+// hookKey 去重的维度：logger 名称、级别和消息
+type hookKey struct {
+	name    string
+	level   string
+	message string
+}
+
+// hookDedupe 去重窗口内的状态
+type hookDedupe struct {
+	start time.Time
+	last  HookEntry
+	// 窗口内被去重的条数
+	repeated int
+}
+
+// hookRunner 在独立的 goroutine 中对一个 Hook 做去重、攒批和回调
+type hookRunner struct {
+	hook Hook
+	opts HookOptions
+
+	ch      chan HookEntry
+	flushCh chan chan error
+	done    chan struct{}
+
+	// mu 保护 closed，保证关闭 ch 时没有并发的发送
+	mu     sync.RWMutex
+	closed bool
+
+	dropped atomic.Uint64
+}
+
+func newHookRunner(hook Hook, opts HookOptions) *hookRunner {
+	r := &hookRunner{
+		hook:    hook,
+		opts:    opts,
+		ch:      make(chan HookEntry, opts.BufferSize),
+		flushCh: make(chan chan error),
+		done:    make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// enqueue 放入缓冲区，缓冲区满或已关闭时丢弃
+func (r *hookRunner) enqueue(entry HookEntry) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.ch <- entry:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Sync 立即回调缓冲区中的条目（不包括去重窗口尚未结束的汇总）
+func (r *hookRunner) Sync() error {
+	r.mu.RLock()
+	if r.closed {
+		r.mu.RUnlock()
+		return nil
+	}
+	ack := make(chan error, 1)
+	r.flushCh <- ack
+	r.mu.RUnlock()
+	return <-ack
+}
+
+// Close 回调剩余的条目和所有重复汇总后停止
+func (r *hookRunner) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.ch)
+	r.mu.Unlock()
+
+	<-r.done
+	return nil
+}
+
+func (r *hookRunner) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []HookEntry
+	dedupe := make(map[hookKey]*hookDedupe)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		entries := batch
+		batch = nil
+		if dropped := r.dropped.Swap(0); dropped > 0 {
+			fmt.Fprintf(os.Stderr, "log: hook buffer full, dropped %d entries\n", dropped)
+		}
+		return r.call(entries)
+	}
+	add := func(entry HookEntry) {
+		batch = append(batch, entry)
+		if len(batch) >= r.opts.BatchSize {
+			_ = flush()
+		}
+	}
+	// expire 结束已过期（force 时为全部）的去重窗口，有重复的输出汇总
+	expire := func(now time.Time, force bool) {
+		for k, d := range dedupe {
+			if !force && now.Sub(d.start) < r.opts.DedupeWindow {
+				continue
+			}
+			delete(dedupe, k)
+			if d.repeated > 0 {
+				summary := d.last
+				summary.Count = d.repeated
+				add(summary)
+			}
+		}
+	}
+	receive := func(entry HookEntry) {
+		if r.opts.DedupeWindow < 0 {
+			add(entry)
+			return
+		}
+		key := hookKey{name: entry.LoggerName, level: entry.Level, message: entry.Message}
+		now := time.Now()
+		if d, ok := dedupe[key]; ok && now.Sub(d.start) < r.opts.DedupeWindow {
+			d.repeated++
+			d.last = entry
+			return
+		}
+		expire(now, false)
+		dedupe[key] = &hookDedupe{start: now, last: entry}
+		add(entry)
+	}
+
+	for {
+		select {
+		case entry, ok := <-r.ch:
+			if !ok {
+				expire(time.Now(), true)
+				_ = flush()
+				return
+			}
+			receive(entry)
+		case <-ticker.C:
+			expire(time.Now(), false)
+			_ = flush()
+		case ack := <-r.flushCh:
+			for n := len(r.ch); n > 0; n-- {
+				select {
+				case entry := <-r.ch:
+					receive(entry)
+				default:
+				}
+			}
+			ack <- flush()
+		}
+	}
+}
+
+// call 执行 Hook，panic 和错误都输出到标准错误，不影响后续回调
+func (r *hookRunner) call(entries []HookEntry) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("log hook panic: %v", p)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "log: hook failed: %v\n", err)
+		}
+	}()
+	return r.hook(entries)
+}
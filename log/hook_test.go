@@ -0,0 +1,158 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWebhookAlert(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests [][]HookEntry
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		var payload webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		if payload.Count != len(payload.Entries) {
+			t.Errorf("count = %d, entries = %d", payload.Count, len(payload.Entries))
+		}
+		mu.Lock()
+		requests = append(requests, payload.Entries)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	var l Log
+	err := l.InitLogWithConfig(mustLogConfig(t, map[string]string{
+		"level":              "debug",
+		"consoleLevels":      "fatal",
+		"alertWebhookURL":    server.URL,
+		"alertFlushInterval": "1h",
+	}), "app")
+	if err != nil {
+		t.Fatalf("InitLogWithConfig: %v", err)
+	}
+
+	logger := l.GetLog()
+	logger.Info("not an alert")
+	for i := 0; i < 3; i++ {
+		logger.Errorw("db down", "db", i)
+	}
+	l.Named("sharding").GetLog().Errorw("shard lost", "table", "user_3")
+	if err := l.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	mu.Lock()
+	if len(requests) != 1 || len(requests[0]) != 2 {
+		mu.Unlock()
+		t.Fatalf("requests = %+v, want one batch of 2", requests)
+	}
+	first, second := requests[0][0], requests[0][1]
+	mu.Unlock()
+	if first.Message != "db down" || first.Level != "error" || first.Count != 1 || first.Fields["db"] != float64(0) {
+		t.Fatalf("unexpected first entry: %+v", first)
+	}
+	if second.LoggerName != "sharding" || second.Fields["table"] != "user_3" || second.Caller == "" {
+		t.Fatalf("unexpected second entry: %+v", second)
+	}
+
+	// 关闭时发送去重窗口内的重复汇总
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 2 || len(requests[1]) != 1 {
+		t.Fatalf("requests = %+v, want summary batch", requests)
+	}
+	if summary := requests[1][0]; summary.Message != "db down" || summary.Count != 2 || summary.Fields["db"] != float64(2) {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestAddHook_BatchAndRemove(t *testing.T) {
+	var l Log
+	if err := l.InitLogWithConfig(mustLogConfig(t, map[string]string{"consoleLevels": "fatal"}), "app"); err != nil {
+		t.Fatalf("InitLogWithConfig: %v", err)
+	}
+	defer l.Close(context.Background())
+
+	batches := make(chan []HookEntry, 10)
+	level := zapcore.WarnLevel
+	remove := l.AddHook(func(entries []HookEntry) error {
+		batches <- entries
+		return nil
+	}, &HookOptions{Level: &level, DedupeWindow: -1, BatchSize: 2, FlushInterval: time.Hour})
+
+	logger := l.GetLog()
+	logger.Info("skip")
+	logger.Warn("a")
+	logger.Warn("a")
+	logger.Error("b")
+
+	// 攒满一批立即回调，不需要等待刷新
+	select {
+	case batch := <-batches:
+		if len(batch) != 2 || batch[0].Message != "a" || batch[1].Message != "a" {
+			t.Fatalf("unexpected batch: %+v", batch)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("batch not delivered")
+	}
+
+	// 注销时发送剩余的条目，之后不再回调
+	remove()
+	if batch := <-batches; len(batch) != 1 || batch[0].Message != "b" {
+		t.Fatalf("unexpected batch: %+v", batch)
+	}
+	logger.Error("c")
+	_ = l.Sync()
+	select {
+	case batch := <-batches:
+		t.Fatalf("hook called after remove: %+v", batch)
+	default:
+	}
+}
+
+func mustLogConfig(t *testing.T, m map[string]string) *LogConfig {
+	t.Helper()
+	config, err := LogConfigFromMap(m)
+	if err != nil {
+		t.Fatalf("LogConfigFromMap: %v", err)
+	}
+	return config
+}
+
+func TestHookOptions_DefaultLevel(t *testing.T) {
+	// 未设置 Level 时默认 error，而不是 zapcore.Level 的零值 info
+	for _, opts := range []*HookOptions{nil, {}, {BatchSize: 5}} {
+		if o := hookOptionsWithDefaults(opts); o.Level == nil || *o.Level != zapcore.ErrorLevel {
+			t.Fatalf("%+v: level = %v, want error", opts, o.Level)
+		}
+	}
+	debug := zapcore.DebugLevel
+	if o := hookOptionsWithDefaults(&HookOptions{Level: &debug}); *o.Level != zapcore.DebugLevel {
+		t.Fatalf("explicit level = %v, want debug", *o.Level)
+	}
+
+	// newHookedLog 未设置 Level，warn 日志不触发 Hook
+	l, entries := newHookedLog(t)
+	l.GetLog().Warn("warn")
+	l.GetLog().Error("error")
+	if e := receiveEntry(t, entries); e.Message != "error" {
+		t.Fatalf("first entry %q, want error", e.Message)
+	}
+}
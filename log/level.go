@@ -0,0 +1,116 @@
+package log
+
+import (
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelState 在锁内读取级别过滤器和 logger（并发的 InitLog 会替换它们），未初始化时 logger 为 nil
+func (l *Log) levelState() (zap.AtomicLevel, *zap.SugaredLogger) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.level, l.logger
+}
+
+// Level 返回当前的全局最低级别，未初始化时返回 debug
+func (l *Log) Level() zapcore.Level {
+	level, logger := l.levelState()
+	if logger == nil {
+		return zapcore.DebugLevel
+	}
+	return level.Level()
+}
+
+// SetLevel 在运行时调整全局最低级别，对控制台和文件输出立即生效；未初始化时忽略
+func (l *Log) SetLevel(level zapcore.Level) {
+	current, logger := l.levelState()
+	if logger == nil {
+		return
+	}
+	current.SetLevel(level)
+}
+
+// SetLevelString 按名称调整全局最低级别（debug/info/warn/error/fatal/panic），名称无效时返回 false
+func (l *Log) SetLevelString(levelStr string) bool {
+	level, ok := parseLevel(levelStr)
+	if !ok {
+		return false
+	}
+	l.SetLevel(level)
+	return true
+}
+
+// AtomicLevel 返回底层的 zap.AtomicLevel
+// 未初始化时返回独立的 debug 级别；重新 InitLog 后之前返回的 AtomicLevel 不再生效
+func (l *Log) AtomicLevel() zap.AtomicLevel {
+	level, logger := l.levelState()
+	if logger == nil {
+		return zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	}
+	return level
+}
+
+// LevelHandler 返回查看和修改日志级别的 http.Handler
+// 每次请求时使用当前的级别（重新 InitLog 后仍然生效），未初始化时返回 503
+//
+//	GET  返回 {"level":"info"}
+//	PUT  请求体 {"level":"debug"} 或表单 level=debug
+//
+// 使用示例:
+//
+//	http.Handle("/debug/log/level", logger.LevelHandler())
+func (l *Log) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		level, logger := l.levelState()
+		if logger == nil {
+			http.Error(w, "log is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+		level.ServeHTTP(w, r)
+	})
+}
+
+// WatchLevelSignal 监听信号，每次收到 sig 时在当前级别和 toggleLevel 之间切换
+// 返回停止监听的函数，需在 InitLog 之后调用
+//
+// 使用示例（Linux）:
+//
+//	stop := logger.WatchLevelSignal(syscall.SIGUSR1, zapcore.DebugLevel)
+//	defer stop()
+func (l *Log) WatchLevelSignal(sig os.Signal, toggleLevel zapcore.Level) func() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig)
+	done := make(chan struct{})
+
+	go func() {
+		previous := l.Level()
+		for {
+			select {
+			case <-ch:
+				current := l.Level()
+				if current == toggleLevel {
+					l.SetLevel(previous)
+				} else {
+					previous = current
+					l.SetLevel(toggleLevel)
+				}
+				if _, logger := l.levelState(); logger != nil {
+					logger.Infof("log level changed from %s to %s by signal %s", current, l.Level(), sig)
+				}
+			case <-done:
+				signal.Stop(ch)
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
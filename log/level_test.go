@@ -0,0 +1,159 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newFileLog 创建只输出到 dir/app.log 的 Log
+func newFileLog(t *testing.T, dir string, logConfig map[string]string) *Log {
+	t.Helper()
+	config, err := LogConfigFromMap(logConfig)
+	if err != nil {
+		t.Fatalf("LogConfigFromMap: %v", err)
+	}
+	config.Type = TypeFile
+	config.Dir = dir
+	var l Log
+	if err := l.InitLogWithConfig(config, "app"); err != nil {
+		t.Fatalf("InitLogWithConfig: %v", err)
+	}
+	return &l
+}
+
+func TestLog_SetLevel(t *testing.T) {
+	var uninitialized Log
+	uninitialized.SetLevel(zapcore.ErrorLevel)
+	if uninitialized.Level() != zapcore.DebugLevel {
+		t.Fatal("SetLevel before InitLog should be ignored")
+	}
+
+	dir := t.TempDir()
+	l := newFileLog(t, dir, map[string]string{"level": "info", "fileMode": "single"})
+	if l.Level() != zapcore.InfoLevel {
+		t.Fatalf("Level = %v, want info", l.Level())
+	}
+
+	l.GetLog().Debug("debug before")
+	l.SetLevel(zapcore.DebugLevel)
+	l.GetLog().Debug("debug after")
+	if !l.SetLevelString("WARN") || l.Level() != zapcore.WarnLevel {
+		t.Fatalf("SetLevelString: level = %v", l.Level())
+	}
+	l.GetLog().Info("info after warn")
+	l.GetLog().Warn("warn after warn")
+	if l.SetLevelString("verbose") || l.Level() != zapcore.WarnLevel {
+		t.Fatal("SetLevelString should reject an unknown level")
+	}
+	if l.AtomicLevel().Level() != zapcore.WarnLevel {
+		t.Fatal("AtomicLevel should share the level")
+	}
+	_ = l.Close(context.Background())
+
+	out := readFile(t, filepath.Join(dir, "app.log"))
+	for want, present := range map[string]bool{
+		"debug before": false, "debug after": true, "info after warn": false, "warn after warn": true,
+	} {
+		if strings.Contains(out, want) != present {
+			t.Errorf("%q present = %v:\n%s", want, !present, out)
+		}
+	}
+}
+
+func TestLog_LevelHandler(t *testing.T) {
+	l := newFileLog(t, t.TempDir(), map[string]string{"level": "info"})
+	defer l.Close(context.Background())
+	handler := l.LevelHandler()
+
+	serve := func(method, body, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/debug/log/level", strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "", "")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"level":"info"}` {
+		t.Fatalf("GET: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serve(http.MethodPut, `{"level":"debug"}`, "application/json")
+	if rec.Code != http.StatusOK || l.Level() != zapcore.DebugLevel {
+		t.Fatalf("PUT json: %d %s, level %v", rec.Code, rec.Body.String(), l.Level())
+	}
+
+	rec = serve(http.MethodPut, "level=error", "application/x-www-form-urlencoded")
+	if rec.Code != http.StatusOK || l.Level() != zapcore.ErrorLevel {
+		t.Fatalf("PUT form: %d %s, level %v", rec.Code, rec.Body.String(), l.Level())
+	}
+
+	// 无效级别返回 400，级别不变
+	for _, body := range []string{`{"level":"verbose"}`, `{"level":`, `{}`} {
+		rec = serve(http.MethodPut, body, "application/json")
+		if rec.Code != http.StatusBadRequest || l.Level() != zapcore.ErrorLevel {
+			t.Fatalf("PUT %s: %d, level %v", body, rec.Code, l.Level())
+		}
+	}
+
+	if rec = serve(http.MethodPost, `{"level":"info"}`, "application/json"); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST: %d", rec.Code)
+	}
+
+	// 重新初始化后 handler 使用新的级别
+	_ = l.Close(context.Background())
+	if err := l.InitLogWithConfig(mustLogConfig(t, map[string]string{"level": "warn", "type": "file", "dir": t.TempDir()}), "app"); err != nil {
+		t.Fatalf("InitLogWithConfig: %v", err)
+	}
+	if rec = serve(http.MethodGet, "", ""); strings.TrimSpace(rec.Body.String()) != `{"level":"warn"}` {
+		t.Fatalf("GET after re-init: %s", rec.Body.String())
+	}
+	if rec = serve(http.MethodPut, `{"level":"info"}`, "application/json"); rec.Code != http.StatusOK || l.Level() != zapcore.InfoLevel {
+		t.Fatalf("PUT after re-init: %d, level %v", rec.Code, l.Level())
+	}
+}
+
+func TestLog_LevelUninitialized(t *testing.T) {
+	var l Log
+	rec := httptest.NewRecorder()
+	l.LevelHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET before InitLog: %d", rec.Code)
+	}
+
+	// 未初始化时返回可用的独立级别
+	level := l.AtomicLevel()
+	level.SetLevel(zapcore.ErrorLevel)
+	if level.Level() != zapcore.ErrorLevel || l.Level() != zapcore.DebugLevel {
+		t.Fatalf("detached level %v, log level %v", level.Level(), l.Level())
+	}
+}
+
+func TestLog_LevelConcurrentInit(t *testing.T) {
+	var l Log
+	dir := t.TempDir()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			_ = l.InitLogWithConfig(mustLogConfig(t, map[string]string{"type": "file", "dir": dir}), "app")
+		}
+	}()
+	handler := l.LevelHandler()
+	for i := 0; i < 200; i++ {
+		l.SetLevel(zapcore.InfoLevel)
+		_ = l.Level()
+		_ = l.AtomicLevel()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	<-done
+	_ = l.Close(context.Background())
+}
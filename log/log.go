@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
@@ -13,174 +14,245 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// syncer 可刷新的输出
+type syncer interface {
+	Sync() error
+}
+
 type Log struct {
 	logger *zap.SugaredLogger
+	// 全局最低级别，可在运行时通过 SetLevel 调整
+	level zap.AtomicLevel
+
+	// 由 InitLog 创建的 writer，Sync / Close 时依次刷新和关闭
+	mu      sync.Mutex
+	writers []syncer
+	closers []io.Closer
+	// 按消息限流器，未启用时为 nil
+	limiter *rateLimiter
+	// AddHook 注册的 Hook
+	hooks *hookSet
+	// 脱敏器，未配置时为 nil
+	redact *redactor
+
+	// 以下字段供 Named 创建子 logger 使用
+	config      *LogConfig
+	fileName    string
+	fileEncoder zapcore.Encoder
+	sampling    samplingOptions
+	options     []zap.Option
+	// 控制台和远程输出，所有子 logger 共用
+	shared []outputFactory
+	// 文件输出，未配置独立文件的子 logger 沿用上一级 logger 的文件输出
+	files    []outputFactory
+	children map[string]*Log
+
+	// 子 logger 的模块名和所属的主 logger
+	name string
+	root *Log
+}
+
+// outputFactory 按级别过滤器创建 core，同一个输出（writer）可供多个 logger 共用
+type outputFactory func(level zapcore.LevelEnabler) zapcore.Core
+
+// newOutput 创建写入 ws 的输出，levels 为输出自身的级别筛选（nil 表示全部），与传入的级别过滤器同时生效
+func newOutput(enc zapcore.Encoder, ws zapcore.WriteSyncer, levels func(zapcore.Level) bool) outputFactory {
+	return func(level zapcore.LevelEnabler) zapcore.Core {
+		return zapcore.NewCore(enc, ws, zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+			return level.Enabled(lvl) && (levels == nil || levels(lvl))
+		}))
+	}
 }
 
+// InitLog 按 map 配置初始化日志，配置项见 LogConfigFromMap
+// 配置有误（未知的 key、无法解析的值等）时在标准错误输出警告，出错的项使用默认值
 func (l *Log) InitLog(logConfig map[string]string, logFileName string) {
-	// 解析日志级别（默认 debug）
-	minLevel := zapcore.DebugLevel
-	if levelStr, ok := logConfig["level"]; ok && levelStr != "" {
-		levelStr = strings.ToLower(strings.TrimSpace(levelStr))
-		switch levelStr {
-		case "debug":
-			minLevel = zapcore.DebugLevel
-		case "info":
-			minLevel = zapcore.InfoLevel
-		case "warn", "warning":
-			minLevel = zapcore.WarnLevel
-		case "error":
-			minLevel = zapcore.ErrorLevel
-		case "fatal":
-			minLevel = zapcore.FatalLevel
-		case "panic":
-			minLevel = zapcore.PanicLevel
-		}
+	config, err := LogConfigFromMap(logConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log: invalid config: %v\n", err)
 	}
+	l.init(config, logFileName)
+}
 
-	levelEncoder := zapcore.CapitalLevelEncoder
-	useColor, ok := logConfig["color"]
-	if ok {
-		if strings.EqualFold(useColor, "true") {
-			levelEncoder = zapcore.CapitalColorLevelEncoder
-		}
+// InitLogWithConfig 按 LogConfig 初始化日志，配置校验失败时返回错误且不做任何修改
+func (l *Log) InitLogWithConfig(config *LogConfig, logFileName string) error {
+	if config == nil {
+		config = NewLogConfig()
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid log config: %w", err)
 	}
+	l.init(config, logFileName)
+	return nil
+}
 
-	logType := logConfig["type"]
-
-	// 设置一些基本日志格式 具体含义还比较好理解，直接看zap源码也不难懂
-	encoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
-		MessageKey:  "msg",
-		LevelKey:    "level",
-		EncodeLevel: levelEncoder,
-		TimeKey:     "ts",
-		EncodeTime: func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-			enc.AppendString(t.Format("2006-01-02 15:04:05"))
-		},
-		CallerKey:    "file",
-		EncodeCaller: zapcore.ShortCallerEncoder,
-		EncodeDuration: func(d time.Duration, enc zapcore.PrimitiveArrayEncoder) {
-			enc.AppendInt64(int64(d) / 1000000)
-		},
+// init 按配置创建 cores 和 logger
+func (l *Log) init(config *LogConfig, logFileName string) {
+	// 全局级别过滤器（用于控制整体日志输出），运行时可通过 SetLevel 调整
+	globalLevel := zap.NewAtomicLevelAt(config.Level)
+
+	// 设置日志格式：Format 为全局格式（json / console / logfmt，默认 console），
+	// ConsoleFormat / FileFormat 可分别覆盖控制台和文件的格式
+	encoderConfig := buildEncoderConfig(config)
+	format := parseFormat(config.Format, formatConsole)
+	consoleEncoder := buildEncoder(parseFormat(config.ConsoleFormat, format), encoderConfig, config.Color)
+	fileEncoder := buildEncoder(parseFormat(config.FileFormat, format), encoderConfig, false)
+
+	// 记录创建的 writer，供 Sync / Close 使用
+	var writers []syncer
+	var asyncClosers, fileClosers []io.Closer
+	wrapWriter := newWriterWrapper(config, func(ws syncer, async, file io.Closer) {
+		writers = append(writers, ws)
+		if async != nil {
+			asyncClosers = append(asyncClosers, async)
+		}
+		if file != nil {
+			fileClosers = append(fileClosers, file)
+		}
 	})
 
-	// 检查是否启用异步写入
-	useAsync := false
-	if asyncStr, ok := logConfig["async"]; ok {
-		useAsync = strings.EqualFold(asyncStr, "true") || asyncStr == "1"
+	// 根据 Type 创建不同的输出
+	var shared, files []outputFactory
+
+	// 判断是否需要控制台输出（与其他输出一样受全局级别过滤，ConsoleLevels 在此基础上再筛选）
+	logType := strings.ToLower(config.Type)
+	needConsole := logType == TypeConsole || logType == TypeHybrid || logType == ""
+	if needConsole {
+		shared = append(shared, newOutput(consoleEncoder, wrapWriter(consoleWriter{os.Stdout}), levelFilter(config.ConsoleLevels)))
 	}
 
-	// 解析文件输出模式：separate（分别输出到不同级别文件）或 single（统一输出到一个文件）
-	fileMode := "separate"
-	if mode, ok := logConfig["fileMode"]; ok {
-		fileMode = strings.ToLower(strings.TrimSpace(mode))
+	// 判断是否需要文件输出
+	if config.hasFileOutput() {
+		files = buildFileOutputs(fileEncoder, wrapWriter, logFileName, config)
 	}
 
-	// 解析控制台输出级别（可选，格式：debug,info,warn,error 或 all）
-	consoleLevels := parseOutputLevels(logConfig["consoleLevels"], minLevel)
-	// 解析文件输出级别（可选，格式：debug,info,warn,error 或 all）
-	fileLevels := parseOutputLevels(logConfig["fileLevels"], minLevel)
+	// 远程日志输出（syslog / ndjson / http），与控制台和文件输出并列
+	sinkOutputs, sinkWriters := buildSinkOutputs(config, logFileName, format, encoderConfig)
+	shared = append(shared, sinkOutputs...)
+	var sinkClosers []io.Closer
+	for _, w := range sinkWriters {
+		writers = append(writers, w)
+		sinkClosers = append(sinkClosers, w)
+	}
 
-	// 创建全局级别过滤器（用于控制整体日志输出）
-	globalLevel := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= minLevel
-	})
+	// 检查是否有有效的输出
+	if len(shared)+len(files) == 0 {
+		// 如果没有有效的输出，至少创建一个控制台输出，避免完全没有日志输出
+		shared = append(shared, newOutput(consoleEncoder, wrapWriter(consoleWriter{os.Stdout}), nil))
+	}
 
-	// 辅助函数：根据配置决定是否使用异步写入包装 writer
-	wrapWriter := func(w io.Writer) zapcore.WriteSyncer {
-		ws := zapcore.AddSync(w)
-		if useAsync {
-			return newAsyncWriter(ws)
-		}
-		return ws
+	// Hook 输出：没有注册 Hook 时不启用
+	hooks := newHookSet()
+	shared = append(shared, newHookOutput(hooks))
+
+	// 需要传入 zap.AddCaller() 才会显示打日志点的文件名和行数
+	options := []zap.Option{zap.AddCaller()}
+	// StacktraceLevel: 该级别及以上的日志附带堆栈（默认不附带）
+	if config.StacktraceLevel != nil {
+		options = append(options, zap.AddStacktrace(*config.StacktraceLevel))
 	}
 
-	// 根据 logType 创建不同的 core
-	var cores []zapcore.Core
+	// 所有输出在写入前脱敏
+	redact := newRedactor(config.Redact)
+	shared = redact.wrap(shared)
+	files = redact.wrap(files)
 
-	// 判断是否需要控制台输出
-	needConsole := strings.EqualFold(logType, "console") || strings.EqualFold(logType, "hybrid") || logType == ""
-	if needConsole {
-		consoleLevel := globalLevel
-		if len(consoleLevels) > 0 {
-			// 使用配置的控制台级别
-			consoleLevel = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-				return lvl >= minLevel && containsLevel(consoleLevels, lvl)
-			})
+	// 创建 Tee core，按配置包装采样和限流（控制台和文件共用同一份计数）
+	sampling := samplingOptionsFromConfig(config)
+	limiter := newSamplingLimiter(sampling)
+	core := wrapSampling(buildTee(globalLevel, shared, files), sampling, limiter)
+	log := zap.New(core, options...)
+
+	l.mu.Lock()
+	l.level = globalLevel
+	l.logger = log.Sugar()
+	l.writers = writers
+	l.limiter = limiter
+	l.hooks = hooks
+	l.redact = redact
+	// 先关闭异步 writer 把缓冲写入文件，再关闭文件
+	l.closers = append(append(asyncClosers, fileClosers...), sinkClosers...)
+	l.config = config
+	l.fileName = logFileName
+	l.fileEncoder = fileEncoder
+	l.sampling = sampling
+	l.options = options
+	l.shared = shared
+	l.files = files
+	l.children = make(map[string]*Log)
+	l.mu.Unlock()
+
+	// 配置了告警 Webhook 时注册对应的 Hook
+	if alert := config.Alert; alert.WebhookURL != "" {
+		l.AddHook(NewWebhookNotifier(alert.WebhookURL, &WebhookOptions{Timeout: alert.Timeout}), &HookOptions{
+			Level:         &alert.Level,
+			DedupeWindow:  alert.DedupeWindow,
+			BatchSize:     alert.BatchSize,
+			FlushInterval: alert.FlushInterval,
+		})
+	}
+}
+
+// buildTee 用同一个级别过滤器创建所有输出的 core
+func buildTee(level zapcore.LevelEnabler, outputs ...[]outputFactory) zapcore.Core {
+	var cores []zapcore.Core
+	for _, group := range outputs {
+		for _, output := range group {
+			cores = append(cores, output(level))
 		}
-		cores = append(cores, zapcore.NewCore(encoder, wrapWriter(os.Stdout), consoleLevel))
 	}
+	return zapcore.NewTee(cores...)
+}
 
-	// 判断是否需要文件输出
-	needFile := strings.EqualFold(logType, "file") || strings.EqualFold(logType, "hybrid")
-	if needFile {
-		if fileMode == "single" {
-			// 统一文件输出：所有级别输出到一个文件
-			fileLevel := globalLevel
-			if len(fileLevels) > 0 {
-				fileLevel = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-					return lvl >= minLevel && containsLevel(fileLevels, lvl)
-				})
+// newWriterWrapper 返回包装 writer 的函数：根据配置决定是否使用异步写入，并通过 track 记录需要刷新和关闭的对象
+func newWriterWrapper(config *LogConfig, track func(ws syncer, async, file io.Closer)) func(io.Writer) zapcore.WriteSyncer {
+	asyncOpts := asyncOptionsFromConfig(config)
+	return func(w io.Writer) zapcore.WriteSyncer {
+		var async, file io.Closer
+		if c, ok := w.(io.Closer); ok {
+			file = c
+		}
+		ws := zapcore.AddSync(w)
+		if config.Async {
+			ws = newAsyncWriter(ws, asyncOpts)
+			if c, ok := ws.(io.Closer); ok {
+				async = c
 			}
-			allWriter := getWriter(fmt.Sprintf("./logs/%s.log", logFileName), logConfig)
-			cores = append(cores, zapcore.NewCore(encoder, wrapWriter(allWriter), fileLevel))
-		} else {
-			// 分别输出到不同级别的文件（使用精确匹配，避免创建不需要的文件）
-			cores = append(cores, buildFileCores(encoder, wrapWriter, logFileName, logConfig, minLevel, fileLevels)...)
 		}
+		track(ws, async, file)
+		return ws
 	}
+}
 
-	// 检查是否有有效的 cores
-	if len(cores) == 0 {
-		// 如果没有有效的 cores，至少创建一个控制台输出，避免完全没有日志输出
-		cores = append(cores, zapcore.NewCore(encoder, wrapWriter(os.Stdout), globalLevel))
+// levelFilter 返回按级别列表筛选的函数，列表为空时返回 nil（不筛选）
+func levelFilter(levels []zapcore.Level) func(zapcore.Level) bool {
+	if len(levels) == 0 {
+		return nil
+	}
+	return func(lvl zapcore.Level) bool {
+		return containsLevel(levels, lvl)
 	}
-
-	// 创建 Tee core
-	core := zapcore.NewTee(cores...)
-
-	// 需要传入 zap.AddCaller() 才会显示打日志点的文件名和行数
-	log := zap.New(core, zap.AddCaller())
-	l.logger = log.Sugar()
 }
 
 func (l *Log) GetLog() *zap.SugaredLogger {
 	return l.logger
 }
 
-// getWriter 创建日志文件 Writer，支持通过配置设置切割参数
-// filename: 日志文件路径
-// logConfig: 日志配置 map，支持以下配置项：
-//   - maxAge: 保留天数（默认7天）。如果设置为 -1，则禁用基于时间的清理
-//   - rotationTime: 分割时间间隔，支持格式：1h, 30m, 24h, 1d（默认1d，即24小时）
-//   - rotationCount: 保留的文件数量（默认-1，表示不限制）。如果设置了此选项，需要将 maxAge 设置为 -1
-//   - rotationFormat: 文件名格式，如 "%Y%m%d"（默认根据 rotationTime 自动选择）
+// getWriter 创建日志文件 Writer，切割参数见 LogConfig 的 MaxAge / RotationTime / RotationCount /
+// RotationFormat / MaxSize / MaxTotalSize / Compress
 //
-// 注意：file-rotatelogs 不支持按大小分割，只支持按时间分割
-// 如果需要按大小分割，请考虑使用其他日志库（如 lumberjack）
-func getWriter(filename string, logConfig map[string]string) io.Writer {
-	// 解析保留天数（默认7天）
-	maxAgeDays := 7
-	if maxAgeStr, ok := logConfig["maxAge"]; ok && maxAgeStr != "" {
-		if days, err := strconv.Atoi(maxAgeStr); err == nil {
-			maxAgeDays = days
-		}
+// 未配置 MaxSize / MaxTotalSize / Compress 时使用 file-rotatelogs 按时间分割，
+// 否则使用 rotateWriter 同时按时间和大小分割
+func getWriter(filename string, config *LogConfig) io.Writer {
+	// 分割时间间隔（默认1天）
+	rotationTime := config.RotationTime
+	if rotationTime <= 0 {
+		rotationTime = 24 * time.Hour
 	}
 
-	// 解析分割时间间隔（默认1天）
-	rotationTime := 24 * time.Hour
-	if rotationTimeStr, ok := logConfig["rotationTime"]; ok && rotationTimeStr != "" {
-		if duration, err := parseDuration(rotationTimeStr); err == nil && duration > 0 {
-			rotationTime = duration
-		}
-	}
-
-	// 解析文件名格式（默认根据 rotationTime 自动选择）
-	rotationFormat := ""
-	if format, ok := logConfig["rotationFormat"]; ok && format != "" {
-		rotationFormat = format
-	} else {
-		// 根据 rotationTime 自动选择合适的格式
+	// 文件名格式（默认根据 rotationTime 自动选择）
+	rotationFormat := config.RotationFormat
+	if rotationFormat == "" {
 		if rotationTime >= 24*time.Hour {
 			rotationFormat = "%Y%m%d" // 按天
 		} else if rotationTime >= time.Hour {
@@ -194,34 +266,43 @@ func getWriter(filename string, logConfig map[string]string) io.Writer {
 	baseName := strings.Replace(filename, ".log", "", -1)
 	pattern := fmt.Sprintf("%s-%s.log", baseName, rotationFormat)
 
-	// 解析保留文件数量
-	rotationCount := -1
-	if rotationCountStr, ok := logConfig["rotationCount"]; ok && rotationCountStr != "" {
-		if count, err := strconv.Atoi(rotationCountStr); err == nil && count > 0 {
-			rotationCount = count
+	// 设置了 rotationCount 时按数量保留，不再按时间清理
+	rotationCount := config.RotationCount
+	maxAge := config.MaxAge
+	if rotationCount > 0 || maxAge < 0 {
+		maxAge = -1
+	}
+
+	compress := strings.ToLower(config.Compress)
+	if compress != compressGzip && compress != compressLz4 {
+		compress = compressNone
+	}
+
+	if config.MaxSize > 0 || config.MaxTotalSize > 0 || compress != compressNone {
+		writer, err := newRotateWriter(filename, rotationFormat, rotateOptions{
+			rotationTime:  rotationTime,
+			maxAge:        maxAge,
+			rotationCount: rotationCount,
+			maxSize:       config.MaxSize,
+			maxTotalSize:  config.MaxTotalSize,
+			compress:      compress,
+		})
+		if err != nil {
+			panic(fmt.Errorf("failed to create log writer: %w", err))
 		}
+		return writer
 	}
 
 	// 创建 rotatelogs 选项
 	options := []rotatelogs.Option{
 		rotatelogs.WithLinkName(filename),
 		rotatelogs.WithRotationTime(rotationTime),
+		// maxAge = -1 表示禁用基于时间的清理
+		rotatelogs.WithMaxAge(maxAge),
 	}
-
-	// 设置 MaxAge 或 RotationCount
-	// 如果设置了 rotationCount，必须将 maxAge 设置为 -1
 	if rotationCount > 0 {
 		// 使用 rotationCount 模式：保留固定数量的文件
-		options = append(options, rotatelogs.WithMaxAge(-1))
 		options = append(options, rotatelogs.WithRotationCount(uint(rotationCount)))
-	} else {
-		// 使用 maxAge 模式：基于时间清理
-		if maxAgeDays >= 0 {
-			options = append(options, rotatelogs.WithMaxAge(time.Duration(maxAgeDays)*24*time.Hour))
-		} else {
-			// maxAge = -1 表示禁用基于时间的清理
-			options = append(options, rotatelogs.WithMaxAge(-1))
-		}
 	}
 
 	// 创建 rotatelogs Logger
@@ -233,6 +314,25 @@ func getWriter(filename string, logConfig map[string]string) io.Writer {
 	return hook
 }
 
+// parseLevel 解析日志级别名称，空字符串或未知名称返回 false
+func parseLevel(levelStr string) (zapcore.Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(levelStr)) {
+	case "debug":
+		return zapcore.DebugLevel, true
+	case "info":
+		return zapcore.InfoLevel, true
+	case "warn", "warning":
+		return zapcore.WarnLevel, true
+	case "error":
+		return zapcore.ErrorLevel, true
+	case "fatal":
+		return zapcore.FatalLevel, true
+	case "panic":
+		return zapcore.PanicLevel, true
+	}
+	return zapcore.DebugLevel, false
+}
+
 // parseDuration 解析时间字符串，支持格式：1h, 30m, 24h, 1d, 7d 等
 func parseDuration(s string) (time.Duration, error) {
 	s = strings.TrimSpace(s)
@@ -250,52 +350,6 @@ func parseDuration(s string) (time.Duration, error) {
 	return time.ParseDuration(s)
 }
 
-// parseOutputLevels 解析输出级别配置，支持格式：debug,info,warn,error 或 all
-// 返回需要输出的级别列表，如果为空表示输出所有级别
-func parseOutputLevels(levelsStr string, minLevel zapcore.Level) []zapcore.Level {
-	if levelsStr == "" {
-		return nil // 空表示输出所有级别
-	}
-
-	levelsStr = strings.ToLower(strings.TrimSpace(levelsStr))
-	if levelsStr == "all" {
-		return nil // all 表示输出所有级别
-	}
-
-	var levels []zapcore.Level
-	parts := strings.Split(levelsStr, ",")
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		switch part {
-		case "debug":
-			if minLevel <= zapcore.DebugLevel {
-				levels = append(levels, zapcore.DebugLevel)
-			}
-		case "info":
-			if minLevel <= zapcore.InfoLevel {
-				levels = append(levels, zapcore.InfoLevel)
-			}
-		case "warn", "warning":
-			if minLevel <= zapcore.WarnLevel {
-				levels = append(levels, zapcore.WarnLevel)
-			}
-		case "error":
-			if minLevel <= zapcore.ErrorLevel {
-				levels = append(levels, zapcore.ErrorLevel)
-			}
-		case "fatal":
-			if minLevel <= zapcore.FatalLevel {
-				levels = append(levels, zapcore.FatalLevel)
-			}
-		case "panic":
-			if minLevel <= zapcore.PanicLevel {
-				levels = append(levels, zapcore.PanicLevel)
-			}
-		}
-	}
-	return levels
-}
-
 // containsLevel 检查级别是否在列表中，如果列表为空表示包含所有级别
 // 对于日志级别，如果配置了某个级别，则包含该级别及以上的所有级别
 // 例如：配置了 error(2)，则 error(2)、fatal(3)、panic(4) 都包含
@@ -328,68 +382,38 @@ func containsExactLevel(levels []zapcore.Level, lvl zapcore.Level) bool {
 	return false
 }
 
-// buildFileCores 构建文件输出的 cores，支持分别输出到不同级别的文件
-func buildFileCores(encoder zapcore.Encoder, wrapWriter func(io.Writer) zapcore.WriteSyncer,
-	logFileName string, logConfig map[string]string, minLevel zapcore.Level, fileLevels []zapcore.Level) []zapcore.Core {
+// buildFileOutputs 构建文件输出，文件路径由 Dir 和 FileNameTemplate 决定
+// single 模式所有级别写入一个文件；separate 模式按级别分别写入（使用精确匹配，避免创建不需要的文件）。
+// 各级别的输出都会创建（文件在首次写入时才生成），以便运行时调低级别后立即生效
+func buildFileOutputs(encoder zapcore.Encoder, wrapWriter func(io.Writer) zapcore.WriteSyncer,
+	name string, config *LogConfig) []outputFactory {
 
-	var cores []zapcore.Core
+	if strings.EqualFold(config.FileMode, FileModeSingle) {
+		writer := getWriter(config.filePath(name, ""), config)
+		return []outputFactory{newOutput(encoder, wrapWriter(writer), levelFilter(config.FileLevels))}
+	}
 
-	// 定义级别配置
+	// 每个文件输出的级别范围，error 文件包含 error 及以上
 	levelConfigs := []struct {
 		level     zapcore.Level
 		levelName string
-		enabler   zap.LevelEnablerFunc
-		shouldAdd bool
+		match     func(zapcore.Level) bool
 	}{
-		{
-			level:     zapcore.DebugLevel,
-			levelName: "debug",
-			enabler: zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-				return lvl >= minLevel && lvl >= zapcore.DebugLevel && lvl < zapcore.InfoLevel
-			}),
-			shouldAdd: minLevel <= zapcore.DebugLevel,
-		},
-		{
-			level:     zapcore.InfoLevel,
-			levelName: "info",
-			enabler: zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-				return lvl >= minLevel && lvl >= zapcore.InfoLevel && lvl < zapcore.WarnLevel
-			}),
-			shouldAdd: minLevel <= zapcore.InfoLevel,
-		},
-		{
-			level:     zapcore.WarnLevel,
-			levelName: "warn",
-			enabler: zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-				return lvl >= minLevel && lvl >= zapcore.WarnLevel && lvl < zapcore.ErrorLevel
-			}),
-			shouldAdd: minLevel <= zapcore.WarnLevel,
-		},
-		{
-			level:     zapcore.ErrorLevel,
-			levelName: "error",
-			enabler: zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-				return lvl >= minLevel && lvl >= zapcore.ErrorLevel
-			}),
-			shouldAdd: minLevel <= zapcore.ErrorLevel,
-		},
+		{zapcore.DebugLevel, "debug", func(lvl zapcore.Level) bool { return lvl < zapcore.InfoLevel }},
+		{zapcore.InfoLevel, "info", func(lvl zapcore.Level) bool { return lvl >= zapcore.InfoLevel && lvl < zapcore.WarnLevel }},
+		{zapcore.WarnLevel, "warn", func(lvl zapcore.Level) bool { return lvl >= zapcore.WarnLevel && lvl < zapcore.ErrorLevel }},
+		{zapcore.ErrorLevel, "error", func(lvl zapcore.Level) bool { return lvl >= zapcore.ErrorLevel }},
 	}
 
-	// 为每个级别创建 core
+	var outputs []outputFactory
 	for _, cfg := range levelConfigs {
-		if !cfg.shouldAdd {
+		// 如果配置了 FileLevels，只创建用户明确配置的级别文件
+		if len(config.FileLevels) > 0 && !containsExactLevel(config.FileLevels, cfg.level) {
 			continue
 		}
 
-		// 如果配置了 fileLevels，检查该级别是否需要输出
-		// 在分别输出模式下，使用精确匹配，只创建用户明确配置的级别文件
-		if len(fileLevels) > 0 && !containsExactLevel(fileLevels, cfg.level) {
-			continue
-		}
-
-		writer := getWriter(fmt.Sprintf("./logs/%s_%s.log", logFileName, cfg.levelName), logConfig)
-		cores = append(cores, zapcore.NewCore(encoder, wrapWriter(writer), cfg.enabler))
+		writer := getWriter(config.filePath(name, cfg.levelName), config)
+		outputs = append(outputs, newOutput(encoder, wrapWriter(writer), cfg.match))
 	}
-
-	return cores
+	return outputs
 }
@@ -0,0 +1,312 @@
+package log
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtPool = buffer.NewPool()
+
+// logfmtEncoder 输出 logfmt 格式（key=value 以空格分隔）的 zapcore.Encoder
+// 数组、对象和反射类型的值会先序列化为 JSON 再作为字符串输出
+type logfmtEncoder struct {
+	cfg       *zapcore.EncoderConfig
+	buf       *buffer.Buffer
+	namespace string
+}
+
+// newLogfmtEncoder 创建 logfmt 编码器
+func newLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{cfg: &cfg, buf: logfmtPool.Get()}
+}
+
+func (enc *logfmtEncoder) addKey(key string) {
+	if enc.buf.Len() > 0 {
+		enc.buf.AppendByte(' ')
+	}
+	if enc.namespace != "" {
+		key = enc.namespace + "." + key
+	}
+	enc.buf.AppendString(sanitizeKey(key))
+	enc.buf.AppendByte('=')
+}
+
+func (enc *logfmtEncoder) appendValue(s string) {
+	if needsQuote(s) {
+		enc.buf.AppendString(strconv.Quote(s))
+		return
+	}
+	enc.buf.AppendString(s)
+}
+
+// sanitizeKey 将键名中的空白、等号和引号替换为下划线
+func sanitizeKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// needsQuote 值为空或包含空白、等号、引号、控制字符时需要加引号
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+func (enc *logfmtEncoder) addJSON(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	enc.addKey(key)
+	enc.appendValue(string(data))
+	return nil
+}
+
+func (enc *logfmtEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := m.AddArray(key, arr); err != nil {
+		return err
+	}
+	return enc.addJSON(key, m.Fields[key])
+}
+
+func (enc *logfmtEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := m.AddObject(key, obj); err != nil {
+		return err
+	}
+	return enc.addJSON(key, m.Fields[key])
+}
+
+func (enc *logfmtEncoder) AddReflected(key string, v interface{}) error {
+	return enc.addJSON(key, v)
+}
+
+func (enc *logfmtEncoder) AddBinary(key string, v []byte) {
+	enc.AddString(key, base64.StdEncoding.EncodeToString(v))
+}
+
+func (enc *logfmtEncoder) AddByteString(key string, v []byte) {
+	enc.AddString(key, string(v))
+}
+
+func (enc *logfmtEncoder) AddBool(key string, v bool) {
+	enc.addKey(key)
+	enc.buf.AppendBool(v)
+}
+
+func (enc *logfmtEncoder) AddComplex128(key string, v complex128) {
+	enc.AddString(key, strconv.FormatComplex(v, 'g', -1, 128))
+}
+
+func (enc *logfmtEncoder) AddComplex64(key string, v complex64) {
+	enc.AddString(key, strconv.FormatComplex(complex128(v), 'g', -1, 64))
+}
+
+func (enc *logfmtEncoder) AddDuration(key string, v time.Duration) {
+	if enc.cfg.EncodeDuration == nil {
+		enc.AddString(key, v.String())
+		return
+	}
+	enc.addKey(key)
+	enc.appendValue(encodePrimitive(func(pe zapcore.PrimitiveArrayEncoder) { enc.cfg.EncodeDuration(v, pe) }))
+}
+
+func (enc *logfmtEncoder) AddFloat64(key string, v float64) {
+	enc.addKey(key)
+	enc.appendFloat(v, 64)
+}
+
+func (enc *logfmtEncoder) AddFloat32(key string, v float32) {
+	enc.addKey(key)
+	enc.appendFloat(float64(v), 32)
+}
+
+func (enc *logfmtEncoder) appendFloat(v float64, bitSize int) {
+	switch {
+	case math.IsNaN(v):
+		enc.buf.AppendString("NaN")
+	case math.IsInf(v, 1):
+		enc.buf.AppendString("+Inf")
+	case math.IsInf(v, -1):
+		enc.buf.AppendString("-Inf")
+	default:
+		enc.buf.AppendFloat(v, bitSize)
+	}
+}
+
+func (enc *logfmtEncoder) AddInt(key string, v int)     { enc.AddInt64(key, int64(v)) }
+func (enc *logfmtEncoder) AddInt32(key string, v int32) { enc.AddInt64(key, int64(v)) }
+func (enc *logfmtEncoder) AddInt16(key string, v int16) { enc.AddInt64(key, int64(v)) }
+func (enc *logfmtEncoder) AddInt8(key string, v int8)   { enc.AddInt64(key, int64(v)) }
+
+func (enc *logfmtEncoder) AddInt64(key string, v int64) {
+	enc.addKey(key)
+	enc.buf.AppendInt(v)
+}
+
+func (enc *logfmtEncoder) AddString(key, v string) {
+	enc.addKey(key)
+	enc.appendValue(v)
+}
+
+func (enc *logfmtEncoder) AddTime(key string, v time.Time) {
+	if enc.cfg.EncodeTime == nil {
+		enc.AddString(key, v.Format(time.RFC3339Nano))
+		return
+	}
+	enc.addKey(key)
+	enc.appendValue(encodePrimitive(func(pe zapcore.PrimitiveArrayEncoder) { enc.cfg.EncodeTime(v, pe) }))
+}
+
+func (enc *logfmtEncoder) AddUint(key string, v uint)       { enc.AddUint64(key, uint64(v)) }
+func (enc *logfmtEncoder) AddUint32(key string, v uint32)   { enc.AddUint64(key, uint64(v)) }
+func (enc *logfmtEncoder) AddUint16(key string, v uint16)   { enc.AddUint64(key, uint64(v)) }
+func (enc *logfmtEncoder) AddUint8(key string, v uint8)     { enc.AddUint64(key, uint64(v)) }
+func (enc *logfmtEncoder) AddUintptr(key string, v uintptr) { enc.AddUint64(key, uint64(v)) }
+
+func (enc *logfmtEncoder) AddUint64(key string, v uint64) {
+	enc.addKey(key)
+	enc.buf.AppendUint(v)
+}
+
+func (enc *logfmtEncoder) OpenNamespace(key string) {
+	if enc.namespace == "" {
+		enc.namespace = key
+		return
+	}
+	enc.namespace = enc.namespace + "." + key
+}
+
+// Clone 实现 zapcore.Encoder，复制已累积的上下文字段
+func (enc *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder{cfg: enc.cfg, buf: logfmtPool.Get(), namespace: enc.namespace}
+	clone.buf.Write(enc.buf.Bytes())
+	return clone
+}
+
+// EncodeEntry 实现 zapcore.Encoder
+// 输出顺序：时间、级别、名称、调用位置、函数、消息、上下文字段、日志字段、堆栈
+func (enc *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := &logfmtEncoder{cfg: enc.cfg, buf: logfmtPool.Get()}
+	cfg := enc.cfg
+
+	if cfg.TimeKey != zapcore.OmitKey {
+		final.AddTime(cfg.TimeKey, ent.Time)
+	}
+	if cfg.LevelKey != zapcore.OmitKey {
+		final.addKey(cfg.LevelKey)
+		if cfg.EncodeLevel != nil {
+			final.appendValue(encodePrimitive(func(pe zapcore.PrimitiveArrayEncoder) { cfg.EncodeLevel(ent.Level, pe) }))
+		} else {
+			final.appendValue(ent.Level.String())
+		}
+	}
+	if ent.LoggerName != "" && cfg.NameKey != zapcore.OmitKey {
+		final.AddString(cfg.NameKey, ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		if cfg.CallerKey != zapcore.OmitKey {
+			final.addKey(cfg.CallerKey)
+			if cfg.EncodeCaller != nil {
+				final.appendValue(encodePrimitive(func(pe zapcore.PrimitiveArrayEncoder) { cfg.EncodeCaller(ent.Caller, pe) }))
+			} else {
+				final.appendValue(ent.Caller.TrimmedPath())
+			}
+		}
+		if cfg.FunctionKey != zapcore.OmitKey {
+			final.AddString(cfg.FunctionKey, ent.Caller.Function)
+		}
+	}
+	if cfg.MessageKey != zapcore.OmitKey {
+		final.AddString(cfg.MessageKey, ent.Message)
+	}
+
+	if enc.buf.Len() > 0 {
+		if final.buf.Len() > 0 {
+			final.buf.AppendByte(' ')
+		}
+		final.buf.Write(enc.buf.Bytes())
+	}
+	final.namespace = enc.namespace
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+	final.namespace = ""
+
+	if ent.Stack != "" && cfg.StacktraceKey != zapcore.OmitKey {
+		final.AddString(cfg.StacktraceKey, ent.Stack)
+	}
+
+	if cfg.SkipLineEnding {
+		return final.buf, nil
+	}
+	if cfg.LineEnding != "" {
+		final.buf.AppendString(cfg.LineEnding)
+	} else {
+		final.buf.AppendString(zapcore.DefaultLineEnding)
+	}
+	return final.buf, nil
+}
+
+// encodePrimitive 调用 zap 的 Encode* 回调并将结果拼接为字符串
+func encodePrimitive(fn func(zapcore.PrimitiveArrayEncoder)) string {
+	var pe primitiveCollector
+	fn(&pe)
+	return strings.Join(pe.values, " ")
+}
+
+// primitiveCollector 收集 PrimitiveArrayEncoder 追加的值
+type primitiveCollector struct {
+	values []string
+}
+
+func (p *primitiveCollector) add(v string)              { p.values = append(p.values, v) }
+func (p *primitiveCollector) AppendBool(v bool)         { p.add(strconv.FormatBool(v)) }
+func (p *primitiveCollector) AppendByteString(v []byte) { p.add(string(v)) }
+func (p *primitiveCollector) AppendComplex128(v complex128) {
+	p.add(strconv.FormatComplex(v, 'g', -1, 128))
+}
+func (p *primitiveCollector) AppendComplex64(v complex64) {
+	p.add(strconv.FormatComplex(complex128(v), 'g', -1, 64))
+}
+func (p *primitiveCollector) AppendFloat64(v float64) { p.add(strconv.FormatFloat(v, 'f', -1, 64)) }
+func (p *primitiveCollector) AppendFloat32(v float32) {
+	p.add(strconv.FormatFloat(float64(v), 'f', -1, 32))
+}
+func (p *primitiveCollector) AppendInt(v int)         { p.add(strconv.FormatInt(int64(v), 10)) }
+func (p *primitiveCollector) AppendInt64(v int64)     { p.add(strconv.FormatInt(v, 10)) }
+func (p *primitiveCollector) AppendInt32(v int32)     { p.add(strconv.FormatInt(int64(v), 10)) }
+func (p *primitiveCollector) AppendInt16(v int16)     { p.add(strconv.FormatInt(int64(v), 10)) }
+func (p *primitiveCollector) AppendInt8(v int8)       { p.add(strconv.FormatInt(int64(v), 10)) }
+func (p *primitiveCollector) AppendString(v string)   { p.add(v) }
+func (p *primitiveCollector) AppendUint(v uint)       { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveCollector) AppendUint64(v uint64)   { p.add(strconv.FormatUint(v, 10)) }
+func (p *primitiveCollector) AppendUint32(v uint32)   { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveCollector) AppendUint16(v uint16)   { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveCollector) AppendUint8(v uint8)     { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveCollector) AppendUintptr(v uintptr) { p.add(strconv.FormatUint(uint64(v), 10)) }
+
+// 确保实现了接口
+var _ zapcore.Encoder = (*logfmtEncoder)(nil)
@@ -0,0 +1,141 @@
+package log
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// user 用于测试嵌套对象的编码
+type user struct {
+	Name string
+	Tags []string
+}
+
+func (u user) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.Name)
+	return enc.AddArray("tags", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		for _, tag := range u.Tags {
+			arr.AppendString(tag)
+		}
+		return nil
+	}))
+}
+
+func TestLogfmtEncoder_Golden(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	caller := zapcore.NewEntryCaller(0, "/src/app/order/service.go", 42, true)
+
+	tests := []struct {
+		name   string
+		config map[string]string
+		entry  zapcore.Entry
+		fields []zapcore.Field
+		want   string
+	}{
+		{
+			name:  "default layout",
+			entry: zapcore.Entry{Level: zapcore.InfoLevel, Time: ts, Message: "order created", Caller: caller},
+			fields: []zapcore.Field{
+				zap.Int("id", 7), zap.Bool("paid", true), zap.Float64("amount", 9.5),
+			},
+			want: `ts="2024-01-02 03:04:05" level=INFO file=order/service.go:42 msg="order created" id=7 paid=true amount=9.5`,
+		},
+		{
+			name:  "quoting",
+			entry: zapcore.Entry{Level: zapcore.WarnLevel, Time: ts, Message: "line1\nline2"},
+			fields: []zapcore.Field{
+				zap.String("path", "/a b"),
+				zap.String("query", "a=b"),
+				zap.String("quote", `say "hi"`),
+				zap.String("empty", ""),
+				zap.String("plain", "ok"),
+				zap.String("unicode", "订单"),
+				zap.String("bad key=", "v"),
+				zap.Error(errors.New("connection refused")),
+			},
+			want: `ts="2024-01-02 03:04:05" level=WARN msg="line1\nline2" path="/a b" query="a=b" quote="say \"hi\"" ` +
+				`empty="" plain=ok unicode=订单 bad_key_=v error="connection refused"`,
+		},
+		{
+			name:  "nested objects and arrays",
+			entry: zapcore.Entry{Level: zapcore.InfoLevel, Time: ts, Message: "login"},
+			fields: []zapcore.Field{
+				zap.Object("user", user{Name: "bob", Tags: []string{"vip", "new user"}}),
+				zap.Ints("ids", []int{1, 2, 3}),
+				zap.Any("meta", map[string]interface{}{"region": "cn", "shards": []int{0, 1}}),
+				zap.Namespace("req"),
+				zap.String("method", "GET"),
+			},
+			want: `ts="2024-01-02 03:04:05" level=INFO msg=login user="{\"name\":\"bob\",\"tags\":[\"vip\",\"new user\"]}" ` +
+				`ids=[1,2,3] meta="{\"region\":\"cn\",\"shards\":[0,1]}" req.method=GET`,
+		},
+		{
+			name:   "time and duration",
+			config: map[string]string{"timeFormat": "rfc3339nano"},
+			entry:  zapcore.Entry{Level: zapcore.DebugLevel, Time: ts, Message: "slow"},
+			fields: []zapcore.Field{
+				zap.Duration("elapsed", 1500*time.Millisecond),
+				zap.Time("deadline", ts.Add(time.Hour)),
+				zap.Float64("ratio", math.Inf(1)),
+			},
+			want: `ts=2024-01-02T03:04:05.6Z level=DEBUG msg=slow elapsed=1500 deadline=2024-01-02T04:04:05.6Z ratio=+Inf`,
+		},
+		{
+			name:   "epoch time and custom keys",
+			config: map[string]string{"timeFormat": "epochMillis", "timeKey": "time", "levelKey": "-", "messageKey": "message"},
+			entry:  zapcore.Entry{Level: zapcore.ErrorLevel, Time: ts, Message: "failed", LoggerName: "sharding"},
+			want:   `time=1704164645600 logger=sharding message=failed`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := LogConfigFromMap(tt.config)
+			if err != nil {
+				t.Fatalf("LogConfigFromMap: %v", err)
+			}
+			enc := buildEncoder(formatLogfmt, buildEncoderConfig(config), false)
+			buf, err := enc.EncodeEntry(tt.entry, tt.fields)
+			if err != nil {
+				t.Fatalf("EncodeEntry: %v", err)
+			}
+			if got := buf.String(); got != tt.want+"\n" {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogfmtEncoder_Context(t *testing.T) {
+	config := NewLogConfig()
+	config.TimeKey = "-"
+	enc := buildEncoder(formatLogfmt, buildEncoderConfig(config), false)
+
+	// With 添加的上下文字段在日志字段之前，Clone 后互不影响
+	enc.AddString("request_id", "r 1")
+	enc.OpenNamespace("http")
+	clone := enc.Clone()
+	clone.AddInt("status", 200)
+	enc.AddInt("status", 500)
+
+	entry := zapcore.Entry{Level: zapcore.InfoLevel, Message: "done"}
+	for _, tc := range []struct {
+		enc  zapcore.Encoder
+		want string
+	}{
+		{clone, `level=INFO msg=done request_id="r 1" http.status=200 http.path=/orders` + "\n"},
+		{enc, `level=INFO msg=done request_id="r 1" http.status=500 http.path=/orders` + "\n"},
+	} {
+		buf, err := tc.enc.EncodeEntry(entry, []zapcore.Field{zap.String("path", "/orders")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.want {
+			t.Fatalf("got %q, want %q", buf.String(), tc.want)
+		}
+	}
+}
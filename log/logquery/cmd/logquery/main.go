@@ -0,0 +1,116 @@
+// logquery 查询 log 包写入的日志文件
+//
+// 使用示例:
+//
+//	logquery -dir ./logs -name app -since 1h -level warn -grep 'shard \d+'
+//	logquery -name app -since "2024-01-02 15:00:00" -until "2024-01-02 16:00:00" -caller sharding/ -json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bobwong89757/gnbutils/log/logquery"
+)
+
+func main() {
+	var (
+		dir        = flag.String("dir", "./logs", "日志目录")
+		name       = flag.String("name", "", "日志文件名（InitLog 的 logFileName）")
+		since      = flag.String("since", "", "开始时间，如 2006-01-02 15:04:05、RFC3339 或相对时长 1h")
+		until      = flag.String("until", "", "结束时间，格式同 -since")
+		level      = flag.String("level", "", "最低级别，如 warn")
+		levels     = flag.String("levels", "", "只显示这些级别，逗号分隔")
+		caller     = flag.String("caller", "", "调用位置包含的字符串")
+		logger     = flag.String("logger", "", "logger 名称（包括其子 logger）")
+		grep       = flag.String("grep", "", "消息匹配的正则")
+		timeLayout = flag.String("time-layout", "", "自定义的时间格式（Go 时间格式）")
+		limit      = flag.Int("n", 0, "最多输出条数，0 表示不限制")
+		asJSON     = flag.Bool("json", false, "以 JSON 输出解析后的日志")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: logquery -name <name> [options] [files...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	q := &logquery.Query{
+		Dir:        *dir,
+		Name:       *name,
+		Files:      flag.Args(),
+		MinLevel:   *level,
+		Caller:     *caller,
+		Logger:     *logger,
+		TimeLayout: *timeLayout,
+	}
+	if q.Name == "" && len(q.Files) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *levels != "" {
+		q.Levels = strings.Split(*levels, ",")
+	}
+	var err error
+	if q.Since, err = parseTime(*since); err != nil {
+		fatal("invalid -since: %v", err)
+	}
+	if q.Until, err = parseTime(*until); err != nil {
+		fatal("invalid -until: %v", err)
+	}
+	if *grep != "" {
+		if q.Message, err = regexp.Compile(*grep); err != nil {
+			fatal("invalid -grep: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	enc := json.NewEncoder(os.Stdout)
+	count := 0
+	err = logquery.Search(ctx, q, func(r *logquery.Record) error {
+		if *asJSON {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		} else if _, err := fmt.Println(r.Raw); err != nil {
+			return err
+		}
+		if count++; *limit > 0 && count >= *limit {
+			return logquery.ErrStop
+		}
+		return nil
+	})
+	if err != nil && err != context.Canceled {
+		fatal("%v", err)
+	}
+}
+
+// parseTime 解析绝对时间或相对当前时间的时长，空字符串返回零值
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+func fatal(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "logquery: "+format+"\n", args...)
+	os.Exit(1)
+}
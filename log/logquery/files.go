@@ -0,0 +1,144 @@
+package logquery
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bobwong89757/gnbutils/lz4"
+)
+
+// File 日志文件
+type File struct {
+	Path string
+	// 文件名去掉时间和扩展名后的部分，如 app_error；同一 Base 的文件按时间先后排列
+	Base string
+	// separate 模式的级别（debug / info / warn / error），single 模式为空
+	Level string
+	// 文件名中的时间部分，未切割的文件为空
+	TimeName string
+	// 按大小切割的序号
+	Index int
+}
+
+// 文件名格式：<base>.log、<base>-<时间>.log、<base>-<时间>.<序号>.log，可带 .gz / .lz4 后缀
+var fileNamePattern = regexp.MustCompile(`^(.+?)(?:-(\d+)(?:\.(\d+))?)?\.log(?:\.(gz|lz4))?$`)
+
+// FindFiles 查找 dir 中名为 name 的 logger 写入的日志文件（包括切割和压缩后的文件），
+// 同时匹配 single 模式的 <name>-*.log 和 separate 模式的 <name>_<level>-*.log
+// 指向当前文件的软链接会被跳过；结果按 Base 分组，组内按时间和序号排序
+func FindFiles(dir, name string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Type()&os.ModeSymlink != 0 {
+			continue
+		}
+		f, ok := parseFileName(entry.Name(), name)
+		if !ok {
+			continue
+		}
+		f.Path = filepath.Join(dir, entry.Name())
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.Base != b.Base {
+			return a.Base < b.Base
+		}
+		// 未切割的文件（rotatelogs 之外的普通文件）排在最后
+		if (a.TimeName == "") != (b.TimeName == "") {
+			return b.TimeName == ""
+		}
+		if a.TimeName != b.TimeName {
+			return a.TimeName < b.TimeName
+		}
+		return a.Index < b.Index
+	})
+	return files, nil
+}
+
+// parseFileName 解析文件名，不属于 name 的文件返回 false
+func parseFileName(fileName, name string) (File, bool) {
+	m := fileNamePattern.FindStringSubmatch(fileName)
+	if m == nil {
+		return File{}, false
+	}
+	f := File{Base: m[1], TimeName: m[2]}
+	if m[3] != "" {
+		f.Index, _ = strconv.Atoi(m[3])
+	}
+
+	switch {
+	case f.Base == name:
+	case strings.HasPrefix(f.Base, name+"_"):
+		level := strings.TrimPrefix(f.Base, name+"_")
+		if !isFileLevel(level) {
+			return File{}, false
+		}
+		f.Level = level
+	default:
+		return File{}, false
+	}
+	return f, true
+}
+
+// isFileLevel separate 模式下文件名中的级别
+func isFileLevel(level string) bool {
+	switch level {
+	case "debug", "info", "warn", "error":
+		return true
+	}
+	return false
+}
+
+// openFile 打开日志文件，.gz / .lz4 文件透明解压
+func openFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		zr, err := gzip.NewReader(bufio.NewReader(f))
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return &multiCloser{Reader: zr, closers: []io.Closer{zr, f}}, nil
+	case strings.HasSuffix(path, ".lz4"):
+		// 标准 LZ4 帧格式，流式解压
+		return &multiCloser{Reader: lz4.NewReader(bufio.NewReader(f)), closers: []io.Closer{f}}, nil
+	default:
+		return f, nil
+	}
+}
+
+// multiCloser 关闭时依次关闭多个对象
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	var first error
+	for _, c := range m.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
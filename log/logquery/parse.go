@@ -0,0 +1,265 @@
+package logquery
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record 解析后的一条日志
+type Record struct {
+	Time time.Time `json:"time"`
+	// 小写的级别名称，如 info、error
+	Level   string                 `json:"level"`
+	Logger  string                 `json:"logger,omitempty"`
+	Caller  string                 `json:"caller,omitempty"`
+	Message string                 `json:"msg"`
+	Stack   string                 `json:"stacktrace,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+	// 来源文件和起始行号
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// 原始文本，多行日志（带堆栈的 console 格式）包含所有行
+	Raw string `json:"-"`
+}
+
+// Keys JSON / logfmt 格式的字段名，与 log.LogConfig 的 TimeKey 等配置对应，为空时使用默认值
+type Keys struct {
+	Time       string
+	Level      string
+	Name       string
+	Caller     string
+	Message    string
+	Stacktrace string
+}
+
+// withDefaults 返回填充默认值后的字段名
+func (k Keys) withDefaults() Keys {
+	def := func(v, d string) string {
+		if v == "" {
+			return d
+		}
+		return v
+	}
+	return Keys{
+		Time:       def(k.Time, "ts"),
+		Level:      def(k.Level, "level"),
+		Name:       def(k.Name, "logger"),
+		Caller:     def(k.Caller, "file"),
+		Message:    def(k.Message, "msg"),
+		Stacktrace: def(k.Stacktrace, "stacktrace"),
+	}
+}
+
+// 依次尝试的时间格式，对应 log 包 TimeFormat 的默认值、rfc3339(nano) 和 iso8601
+var defaultTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000Z0700",
+}
+
+var (
+	// ShortCallerEncoder 输出的调用位置，如 sharding/manager.go:150
+	callerPattern = regexp.MustCompile(`^\S+\.go:\d+$`)
+	// 控制台带颜色输出时的转义序列
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	// logfmt 行以 key= 开头
+	logfmtPattern = regexp.MustCompile(`^[A-Za-z_][\w.\-]*=`)
+)
+
+// parser 解析 console / JSON / logfmt 格式的日志行，每行自动识别格式
+type parser struct {
+	keys    Keys
+	layouts []string
+}
+
+func newParser(keys Keys, timeLayout string) *parser {
+	p := &parser{keys: keys.withDefaults()}
+	if timeLayout != "" {
+		p.layouts = append(p.layouts, timeLayout)
+	}
+	p.layouts = append(p.layouts, defaultTimeLayouts...)
+	return p
+}
+
+// parse 解析一行日志，不是一条日志的开头（如 console 格式的堆栈行）时返回 false
+func (p *parser) parse(line string) (*Record, bool) {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "":
+		return nil, false
+	case trimmed[0] == '{':
+		if r, ok := p.parseJSON(trimmed); ok {
+			return r, true
+		}
+	case logfmtPattern.MatchString(trimmed):
+		if r, ok := p.parseLogfmt(trimmed); ok {
+			return r, true
+		}
+	}
+	return p.parseConsole(line)
+}
+
+// parseConsole 解析 zap console 格式：时间\t级别\t[logger\t][调用位置\t]消息[\t{字段}]
+func (p *parser) parseConsole(line string) (*Record, bool) {
+	parts := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+	if len(parts) < 3 {
+		return nil, false
+	}
+	t, ok := p.parseTime(parts[0])
+	if !ok {
+		return nil, false
+	}
+	r := &Record{Time: t, Level: normalizeLevel(parts[1])}
+	rest := parts[2:]
+
+	switch {
+	case len(rest) >= 2 && !callerPattern.MatchString(rest[0]) && callerPattern.MatchString(rest[1]):
+		r.Logger, r.Caller, rest = rest[0], rest[1], rest[2:]
+	case len(rest) >= 1 && callerPattern.MatchString(rest[0]):
+		r.Caller, rest = rest[0], rest[1:]
+	}
+
+	// 最后一段是 JSON 对象时为字段
+	if n := len(rest); n >= 2 && strings.HasPrefix(rest[n-1], "{") {
+		var fields map[string]interface{}
+		if json.Unmarshal([]byte(rest[n-1]), &fields) == nil {
+			r.Fields = fields
+			rest = rest[:n-1]
+		}
+	}
+	r.Message = strings.Join(rest, "\t")
+	return r, true
+}
+
+// parseJSON 解析 JSON 格式
+func (p *parser) parseJSON(line string) (*Record, bool) {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		return nil, false
+	}
+	return p.fromMap(m)
+}
+
+// parseLogfmt 解析 logfmt 格式，值为 JSON 的字段（数组、对象）保持字符串
+func (p *parser) parseLogfmt(line string) (*Record, bool) {
+	m := make(map[string]interface{})
+	for s := line; ; {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			break
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, false
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, false
+			}
+			value, _ = strconv.Unquote(quoted)
+			s = s[len(quoted):]
+		} else {
+			end := strings.IndexByte(s, ' ')
+			if end < 0 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+		}
+		m[key] = logfmtValue(value)
+	}
+	return p.fromMap(m)
+}
+
+// logfmtValue 将数字和布尔值还原为对应类型，便于与 JSON 格式统一处理
+func logfmtValue(v string) interface{} {
+	if f, err := strconv.ParseFloat(v, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f
+	}
+	if b, err := strconv.ParseBool(v); err == nil && (v == "true" || v == "false") {
+		return b
+	}
+	return v
+}
+
+// fromMap 从解析出的键值中取出时间、级别等字段，其余作为 Fields
+func (p *parser) fromMap(m map[string]interface{}) (*Record, bool) {
+	t, ok := p.timeValue(m[p.keys.Time])
+	if !ok {
+		return nil, false
+	}
+	r := &Record{Time: t}
+	take := func(key string) string {
+		v, ok := m[key]
+		if !ok {
+			return ""
+		}
+		delete(m, key)
+		if s, ok := v.(string); ok {
+			return s
+		}
+		return ""
+	}
+	delete(m, p.keys.Time)
+	r.Level = normalizeLevel(take(p.keys.Level))
+	r.Logger = take(p.keys.Name)
+	r.Caller = take(p.keys.Caller)
+	r.Message = take(p.keys.Message)
+	r.Stack = take(p.keys.Stacktrace)
+	if len(m) > 0 {
+		r.Fields = m
+	}
+	return r, true
+}
+
+// timeValue 解析时间字段：字符串按时间格式解析，数字按 epoch（秒、毫秒或纳秒）解析
+func (p *parser) timeValue(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case string:
+		return p.parseTime(t)
+	case float64:
+		return epochTime(t), true
+	}
+	return time.Time{}, false
+}
+
+// parseTime 按配置的时间格式和默认格式依次尝试解析，不带时区的格式按本地时区解析
+func (p *parser) parseTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range p.layouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return epochTime(f), true
+	}
+	return time.Time{}, false
+}
+
+// epochTime 按数值大小判断单位：秒（带小数）、毫秒或纳秒
+func epochTime(v float64) time.Time {
+	switch {
+	case v > 1e17:
+		return time.Unix(0, int64(v))
+	case v > 1e11:
+		return time.UnixMilli(int64(v))
+	default:
+		sec, frac := math.Modf(v)
+		return time.Unix(int64(sec), int64(frac*1e9))
+	}
+}
+
+// normalizeLevel 去掉颜色转义并转为小写
+func normalizeLevel(level string) string {
+	return strings.ToLower(strings.TrimSpace(ansiPattern.ReplaceAllString(level, "")))
+}
@@ -0,0 +1,332 @@
+// Package logquery 查询 log 包写入的日志文件
+//
+// 按 logger 名称查找目录中的日志文件（包括按时间、大小切割和 gzip / lz4 压缩后的文件），
+// 解析 console / JSON / logfmt 格式，按时间范围、级别、调用位置和消息正则过滤，逐条返回结果。
+//
+// 使用示例:
+//
+//	err := logquery.Search(ctx, &logquery.Query{
+//		Dir:      "./logs",
+//		Name:     "app",
+//		Since:    time.Now().Add(-time.Hour),
+//		MinLevel: "warn",
+//		Message:  regexp.MustCompile(`shard \d+`),
+//	}, func(r *logquery.Record) error {
+//		fmt.Println(r.Raw)
+//		return nil
+//	})
+package logquery
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrStop 由 Search 的回调返回时停止查询，Search 返回 nil
+var ErrStop = errors.New("logquery: stop")
+
+// 单行最大长度，超过时返回错误
+const maxLineSize = 16 << 20
+
+// Query 查询条件，零值字段表示不过滤
+type Query struct {
+	// 日志目录，默认 ./logs
+	Dir string
+	// 日志文件名（InitLog 的 logFileName），与 Files 二选一
+	Name string
+	// 直接指定要查询的文件，设置后不再按 Dir / Name 查找
+	Files []string
+
+	// 时间范围 [Since, Until)
+	Since time.Time
+	Until time.Time
+	// 只返回这些级别（不区分大小写）
+	Levels []string
+	// 只返回该级别及以上的日志
+	MinLevel string
+	// 调用位置包含该字符串，如 "sharding/manager.go" 或 "manager.go:150"
+	Caller string
+	// logger 名称等于该值或以 "该值." 开头
+	Logger string
+	// 消息匹配的正则
+	Message *regexp.Regexp
+
+	// JSON / logfmt 格式的字段名，与写入时的配置一致
+	Keys Keys
+	// 自定义的时间格式（log.LogConfig.TimeFormat 为 Go 时间格式时设置）
+	TimeLayout string
+}
+
+// matcher 编译后的查询条件
+type matcher struct {
+	q        *Query
+	levels   map[string]bool
+	minLevel zapcore.Level
+	hasMin   bool
+}
+
+func newMatcher(q *Query) (*matcher, error) {
+	m := &matcher{q: q}
+	if len(q.Levels) > 0 {
+		m.levels = make(map[string]bool, len(q.Levels))
+		for _, l := range q.Levels {
+			m.levels[strings.ToLower(strings.TrimSpace(l))] = true
+		}
+	}
+	if q.MinLevel != "" {
+		if err := m.minLevel.UnmarshalText([]byte(q.MinLevel)); err != nil {
+			return nil, fmt.Errorf("invalid MinLevel %q: %w", q.MinLevel, err)
+		}
+		m.hasMin = true
+	}
+	return m, nil
+}
+
+func (m *matcher) match(r *Record) bool {
+	q := m.q
+	if !q.Since.IsZero() && r.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !r.Time.Before(q.Until) {
+		return false
+	}
+	if m.levels != nil && !m.levels[r.Level] {
+		return false
+	}
+	if m.hasMin {
+		var lvl zapcore.Level
+		if lvl.UnmarshalText([]byte(r.Level)) != nil || lvl < m.minLevel {
+			return false
+		}
+	}
+	if q.Caller != "" && !strings.Contains(r.Caller, q.Caller) {
+		return false
+	}
+	if q.Logger != "" && r.Logger != q.Logger && !strings.HasPrefix(r.Logger, q.Logger+".") {
+		return false
+	}
+	if q.Message != nil && !q.Message.MatchString(r.Message) {
+		return false
+	}
+	return true
+}
+
+// skipFile 根据文件名中的级别判断文件内不可能有匹配的日志
+func (m *matcher) skipFile(f File) bool {
+	if f.Level == "" {
+		return false
+	}
+	var lvl zapcore.Level
+	if lvl.UnmarshalText([]byte(f.Level)) != nil {
+		return false
+	}
+	// error 文件包含 error 及以上的级别
+	if m.hasMin && f.Level != "error" && lvl < m.minLevel {
+		return true
+	}
+	if m.levels != nil && f.Level != "error" && !m.levels[f.Level] {
+		return true
+	}
+	return false
+}
+
+// Search 按条件查询日志，匹配的日志按时间顺序逐条交给 fn
+// 不同级别的文件（separate 模式）按时间归并；fn 返回 ErrStop 时停止查询并返回 nil，返回其他错误时停止并返回该错误
+func Search(ctx context.Context, q *Query, fn func(*Record) error) error {
+	m, err := newMatcher(q)
+	if err != nil {
+		return err
+	}
+	groups, err := q.fileGroups(m)
+	if err != nil {
+		return err
+	}
+
+	p := newParser(q.Keys, q.TimeLayout)
+	streams := make([]*stream, 0, len(groups))
+	defer func() {
+		for _, s := range streams {
+			s.close()
+		}
+	}()
+	for _, files := range groups {
+		s := &stream{files: files, parser: p, matcher: m}
+		if err := s.advance(); err != nil {
+			return err
+		}
+		streams = append(streams, s)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// 取当前时间最早的一条
+		var next *stream
+		for _, s := range streams {
+			if s.current != nil && (next == nil || s.current.Time.Before(next.current.Time)) {
+				next = s
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		r := next.current
+		if err := fn(r); err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+			return err
+		}
+		if err := next.advance(); err != nil {
+			return err
+		}
+	}
+}
+
+// fileGroups 返回要查询的文件，按 Base 分组
+func (q *Query) fileGroups(m *matcher) ([][]File, error) {
+	if len(q.Files) > 0 {
+		groups := make([][]File, 0, len(q.Files))
+		for _, path := range q.Files {
+			groups = append(groups, []File{{Path: path}})
+		}
+		return groups, nil
+	}
+	if q.Name == "" {
+		return nil, errors.New("logquery: Name or Files is required")
+	}
+
+	dir := q.Dir
+	if dir == "" {
+		dir = "./logs"
+	}
+	files, err := FindFiles(dir, q.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups [][]File
+	for _, f := range files {
+		if m.skipFile(f) {
+			continue
+		}
+		if n := len(groups); n > 0 && groups[n-1][0].Base == f.Base {
+			groups[n-1] = append(groups[n-1], f)
+		} else {
+			groups = append(groups, []File{f})
+		}
+	}
+	return groups, nil
+}
+
+// stream 依次读取同一组文件中匹配的日志
+type stream struct {
+	files   []File
+	parser  *parser
+	matcher *matcher
+
+	rc      io.ReadCloser
+	scanner *bufio.Scanner
+	file    string
+	line    int
+	// 已读取但尚未确定是否结束（可能还有堆栈行）的日志
+	pending *Record
+	current *Record
+}
+
+// advance 读取下一条匹配的日志到 current，读完时 current 为 nil
+func (s *stream) advance() error {
+	s.current = nil
+	for {
+		r, err := s.next()
+		if err != nil || r == nil {
+			return err
+		}
+		if s.matcher.match(r) {
+			s.current = r
+			return nil
+		}
+	}
+}
+
+// next 读取下一条日志（不过滤），后续不是日志开头的行归入上一条日志的堆栈
+func (s *stream) next() (*Record, error) {
+	for {
+		if s.scanner == nil {
+			if len(s.files) == 0 {
+				r := s.pending
+				s.pending = nil
+				return r, nil
+			}
+			if err := s.open(s.files[0].Path); err != nil {
+				return nil, err
+			}
+			s.files = s.files[1:]
+		}
+
+		if !s.scanner.Scan() {
+			err := s.scanner.Err()
+			s.close()
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", s.file, s.line, err)
+			}
+			// 文件结束，返回未完成的日志
+			if r := s.pending; r != nil {
+				s.pending = nil
+				return r, nil
+			}
+			continue
+		}
+		s.line++
+		line := s.scanner.Text()
+
+		r, ok := s.parser.parse(line)
+		if !ok {
+			if s.pending != nil {
+				if s.pending.Stack != "" {
+					s.pending.Stack += "\n"
+				}
+				s.pending.Stack += line
+				s.pending.Raw += "\n" + line
+			}
+			continue
+		}
+		r.File, r.Line, r.Raw = s.file, s.line, line
+
+		prev := s.pending
+		s.pending = r
+		if prev != nil {
+			return prev, nil
+		}
+	}
+}
+
+func (s *stream) open(path string) error {
+	rc, err := openFile(path)
+	if err != nil {
+		return err
+	}
+	s.rc = rc
+	s.scanner = bufio.NewScanner(rc)
+	s.scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	s.file = path
+	s.line = 0
+	return nil
+}
+
+func (s *stream) close() {
+	if s.rc != nil {
+		_ = s.rc.Close()
+		s.rc = nil
+	}
+	s.scanner = nil
+}
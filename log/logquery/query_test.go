@@ -0,0 +1,180 @@
+package logquery
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobwong89757/gnbutils/log"
+	"github.com/bobwong89757/gnbutils/lz4"
+)
+
+// writeLogs 通过 log 包向 dir 写入名为 app 的日志
+func writeLogs(t *testing.T, dir string, config map[string]string, write func(l *log.Log)) {
+	t.Helper()
+	config["dir"] = dir
+	config["type"] = "file"
+	logConfig, err := log.LogConfigFromMap(config)
+	if err != nil {
+		t.Fatalf("LogConfigFromMap: %v", err)
+	}
+	var l log.Log
+	if err := l.InitLogWithConfig(logConfig, "app"); err != nil {
+		t.Fatalf("InitLogWithConfig: %v", err)
+	}
+	write(&l)
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func search(t *testing.T, q *Query) []*Record {
+	t.Helper()
+	var records []*Record
+	if err := Search(context.Background(), q, func(r *Record) error {
+		records = append(records, r)
+		return nil
+	}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	return records
+}
+
+func messages(records []*Record) string {
+	var msgs []string
+	for _, r := range records {
+		msgs = append(msgs, r.Message)
+	}
+	return strings.Join(msgs, ",")
+}
+
+func TestSearch_ConsoleSeparate(t *testing.T) {
+	dir := t.TempDir()
+	const layout = "2006-01-02 15:04:05.000"
+	writeLogs(t, dir, map[string]string{"stacktraceLevel": "error", "timeFormat": layout}, func(l *log.Log) {
+		logger := l.GetLog()
+		for _, write := range []func(){
+			func() { logger.Debug("boot") },
+			func() { logger.Infow("shard 1 ready", "db", 1) },
+			func() { logger.Errorw("shard 2 down", "db", 2) },
+			func() { l.Named("sharding").GetLog().Warn("shard 3 slow") },
+		} {
+			write()
+			time.Sleep(2 * time.Millisecond)
+		}
+	})
+
+	// 不同级别的文件按时间归并
+	all := search(t, &Query{Dir: dir, Name: "app", TimeLayout: layout})
+	if got := messages(all); got != "boot,shard 1 ready,shard 2 down,shard 3 slow" {
+		t.Fatalf("messages = %s", got)
+	}
+
+	errs := search(t, &Query{Dir: dir, Name: "app", MinLevel: "error"})
+	if len(errs) != 1 {
+		t.Fatalf("errors = %+v", errs)
+	}
+	e := errs[0]
+	if e.Level != "error" || e.Fields["db"] != float64(2) || !strings.Contains(e.Caller, "query_test.go:") {
+		t.Fatalf("unexpected record: %+v", e)
+	}
+	if !strings.Contains(e.Stack, "TestSearch_ConsoleSeparate") || !strings.HasSuffix(filepath.Base(e.File), ".log") {
+		t.Fatalf("stack = %q file = %s", e.Stack, e.File)
+	}
+
+	got := search(t, &Query{Dir: dir, Name: "app", Logger: "sharding", Message: regexp.MustCompile(`shard \d`)})
+	if messages(got) != "shard 3 slow" || got[0].Logger != "sharding" {
+		t.Fatalf("logger filter: %+v", got)
+	}
+	if got := search(t, &Query{Dir: dir, Name: "app", Caller: "no_such_file.go"}); len(got) != 0 {
+		t.Fatalf("caller filter: %+v", got)
+	}
+}
+
+func TestSearch_JSONAndLogfmtTimeRange(t *testing.T) {
+	for _, format := range []string{"json", "logfmt"} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			var mid time.Time
+			writeLogs(t, dir, map[string]string{"format": format, "fileMode": "single", "timeFormat": "rfc3339nano"}, func(l *log.Log) {
+				l.GetLog().Info("before")
+				time.Sleep(5 * time.Millisecond)
+				mid = time.Now()
+				l.GetLog().Infow("after", "user", "u1", "ok", true)
+			})
+
+			got := search(t, &Query{Dir: dir, Name: "app", Since: mid})
+			if messages(got) != "after" {
+				t.Fatalf("since: %+v", got)
+			}
+			if got[0].Fields["user"] != "u1" || got[0].Fields["ok"] != true || got[0].Level != "info" {
+				t.Fatalf("unexpected record: %+v", got[0])
+			}
+			if got := search(t, &Query{Dir: dir, Name: "app", Until: mid}); messages(got) != "before" {
+				t.Fatalf("until: %+v", got)
+			}
+		})
+	}
+}
+
+func TestSearch_CompressedArchives(t *testing.T) {
+	dir := t.TempDir()
+	lines := map[string]string{
+		"app-20240101.log.gz":  "2024-01-01 10:00:00\tINFO\tmain.go:10\tday one\n",
+		"app-20240102.log.lz4": "2024-01-02 10:00:00\tINFO\tmain.go:10\tday two\n",
+		"app-20240104.log.lz4": "2024-01-04 10:00:00\tINFO\tmain.go:10\tday four\n",
+		"app-20240103.log":     "2024-01-03 10:00:00\tINFO\tmain.go:10\tday three\n",
+		"other-20240101.log":   "2024-01-01 09:00:00\tINFO\tmain.go:10\tother\n",
+	}
+	for name, content := range lines {
+		path := filepath.Join(dir, name)
+		var data []byte
+		switch {
+		case strings.HasSuffix(name, ".gz"):
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			zw := gzip.NewWriter(f)
+			_, _ = zw.Write([]byte(content))
+			_ = zw.Close()
+			_ = f.Close()
+			continue
+		case strings.HasSuffix(name, ".lz4"):
+			var buf bytes.Buffer
+			zw := lz4.NewWriter(&buf)
+			_, _ = zw.Write([]byte(content))
+			if err := zw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			data = buf.Bytes()
+		default:
+			data = []byte(content)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 指向当前文件的软链接不重复读取
+	_ = os.Symlink("app-20240103.log", filepath.Join(dir, "app.log"))
+
+	if got := messages(search(t, &Query{Dir: dir, Name: "app"})); got != "day one,day two,day three,day four" {
+		t.Fatalf("messages = %s", got)
+	}
+
+	// 回调返回 ErrStop 时停止
+	count := 0
+	err := Search(context.Background(), &Query{Dir: dir, Name: "app"}, func(*Record) error {
+		count++
+		return ErrStop
+	})
+	if err != nil || count != 1 {
+		t.Fatalf("err = %v, count = %d", err, count)
+	}
+}
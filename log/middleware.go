@@ -0,0 +1,194 @@
+package log
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bobwong89757/gnbutils/util/netutil"
+	"github.com/bobwong89757/gnbutils/util/random"
+)
+
+// 默认的请求 ID 请求头
+const defaultRequestIDHeader = "X-Request-Id"
+
+// 客户端传入的请求 ID 的最大长度，超过时重新生成
+const maxRequestIDLen = 128
+
+// AccessLogOptions HTTP 访问日志中间件选项
+type AccessLogOptions struct {
+	// 读取和回写请求 ID 的请求头，默认 X-Request-Id
+	// 请求头中的 ID 只能包含字母、数字和 -_.:，且不超过 128 字节，否则重新生成
+	RequestIDHeader string
+	// 不记录访问日志的路径（精确匹配），如健康检查接口
+	SkipPaths []string
+	// 从请求中提取额外的日志字段（如用户 ID），返回 key、value 交替的列表
+	Fields func(r *http.Request) []interface{}
+}
+
+// AccessLogMiddleware 返回 HTTP 中间件：为每个请求生成（或沿用请求头中合法的）请求 ID，
+// 从 traceparent 请求头解析链路追踪 ID，写入 request context 并在响应头中回写请求 ID，
+// 请求结束后输出一行访问日志。5xx 以 error 级别输出，4xx 以 warn 级别输出，其余为 info
+//
+// 处理函数中通过 logger.WithContext(r.Context()) 获取带有请求 ID 的 logger
+//
+// 使用示例:
+//
+//	mux := http.NewServeMux()
+//	http.ListenAndServe(":8080", logger.AccessLogMiddleware(nil)(mux))
+func (l *Log) AccessLogMiddleware(opts *AccessLogOptions) func(http.Handler) http.Handler {
+	header := defaultRequestIDHeader
+	skip := make(map[string]bool)
+	var extraFields func(r *http.Request) []interface{}
+	if opts != nil {
+		if opts.RequestIDHeader != "" {
+			header = opts.RequestIDHeader
+		}
+		for _, p := range opts.SkipPaths {
+			skip[p] = true
+		}
+		extraFields = opts.Fields
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := strings.TrimSpace(r.Header.Get(header))
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			ctx := ContextWithRequestID(r.Context(), requestID)
+			if traceID := parseTraceParent(r.Header.Get("traceparent")); traceID != "" {
+				ctx = ContextWithTraceID(ctx, traceID)
+			}
+			if extraFields != nil {
+				ctx = ContextWithFields(ctx, extraFields(r)...)
+			}
+			r = r.WithContext(ctx)
+			w.Header().Set(header, requestID)
+
+			rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			if skip[r.URL.Path] {
+				return
+			}
+			fields := []interface{}{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"query", r.URL.RawQuery,
+				"status", rw.status,
+				"bytes", rw.bytes,
+				"elapsed", time.Since(start),
+				"client_ip", netutil.GetRequestPublicIp(r),
+				"user_agent", r.UserAgent(),
+			}
+			logger := l.WithContext(ctx)
+			switch {
+			case rw.status >= http.StatusInternalServerError:
+				logger.Errorw("access", fields...)
+			case rw.status >= http.StatusBadRequest:
+				logger.Warnw("access", fields...)
+			default:
+				logger.Infow("access", fields...)
+			}
+		})
+	}
+}
+
+// responseRecorder 记录响应状态码和字节数
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += n
+	return n, err
+}
+
+// Flush 支持流式响应
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// newRequestID 生成请求 ID，UUID 生成失败时退回随机字符串
+func newRequestID() string {
+	if id, err := random.UUIdV4(); err == nil {
+		return id
+	}
+	return random.RandNumeralOrLetter(32)
+}
+
+// validRequestID 检查客户端传入的请求 ID：非空、不超过 maxRequestIDLen 字节、只包含字母数字和 -_.:
+// 避免超长或含控制字符的值写入日志和响应头
+func validRequestID(s string) bool {
+	if s == "" || len(s) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseTraceParent 从 W3C traceparent 请求头（version-traceid-spanid-flags）中解析 trace ID
+// 各字段必须是指定长度的小写十六进制，version 不能为 ff，trace ID 和 span ID 不能全为 0，否则返回空字符串
+func parseTraceParent(s string) string {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || parts[0] == "ff" {
+		return ""
+	}
+	// version 00 只有 4 个字段，更高版本可以在后面追加字段
+	if parts[0] == "00" && len(parts) != 4 {
+		return ""
+	}
+	for i, size := range []int{2, 32, 16, 2} {
+		if !lowerHex(parts[i], size) {
+			return ""
+		}
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return ""
+	}
+	return parts[1]
+}
+
+// lowerHex 检查 s 是否为 size 个小写十六进制字符
+func lowerHex(s string, size int) bool {
+	if len(s) != size {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
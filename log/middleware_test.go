@@ -0,0 +1,180 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLog_WithContext(t *testing.T) {
+	var uninitialized Log
+	uninitialized.WithContext(ContextWithRequestID(context.Background(), "r1")).Info("dropped")
+
+	l, logs := newObservedLog()
+	ctx := ContextWithRequestID(context.Background(), "r1")
+	ctx = ContextWithUserID(ctx, 42)
+	ctx = ContextWithFields(ctx, "order_id", "o1", RequestIDKey, "r2")
+	l.WithContext(ctx).Info("paid")
+	l.WithContext(context.Background()).Info("plain")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("want 2 logs, got %d", len(entries))
+	}
+	// 同名字段以后写入的为准，字段保持首次写入的顺序
+	fields := entries[0].Context
+	var keys []string
+	for _, f := range fields {
+		keys = append(keys, f.Key)
+	}
+	if strings.Join(keys, ",") != "request_id,user_id,order_id" {
+		t.Fatalf("field order: %v", keys)
+	}
+	if m := entries[0].ContextMap(); m["request_id"] != "r2" || m["user_id"] != int64(42) || m["order_id"] != "o1" {
+		t.Fatalf("fields: %v", m)
+	}
+	if len(entries[1].Context) != 0 {
+		t.Fatalf("empty context should add no fields: %v", entries[1].ContextMap())
+	}
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	l, logs := newObservedLog()
+	var handlerRequestID string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		handlerRequestID = RequestIDFromContext(r.Context())
+		l.WithContext(r.Context()).Info("handling")
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+	handler := l.AccessLogMiddleware(&AccessLogOptions{
+		SkipPaths: []string{"/healthz"},
+		Fields: func(r *http.Request) []interface{} {
+			return []interface{}{"tenant", r.Header.Get("X-Tenant")}
+		},
+	})(mux)
+
+	serve := func(target string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/orders?page=2", map[string]string{
+		"X-Request-Id": "req-1",
+		"X-Tenant":     "acme",
+		"traceparent":  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+	if rec.Header().Get("X-Request-Id") != "req-1" || handlerRequestID != "req-1" {
+		t.Fatalf("request id: header %q, handler %q", rec.Header().Get("X-Request-Id"), handlerRequestID)
+	}
+	entries := logs.TakeAll()
+	if len(entries) != 2 || entries[0].Message != "handling" || entries[1].Message != "access" {
+		t.Fatalf("logs: %v", entries)
+	}
+	access := entries[1].ContextMap()
+	for k, want := range map[string]interface{}{
+		"request_id": "req-1", "trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "tenant": "acme",
+		"method": "GET", "path": "/orders", "query": "page=2", "status": int64(200), "bytes": int64(2),
+	} {
+		if access[k] != want {
+			t.Errorf("%s = %v, want %v", k, access[k], want)
+		}
+	}
+	if entries[0].ContextMap()["request_id"] != "req-1" || entries[1].Level != zapcore.InfoLevel {
+		t.Fatalf("handler log fields %v, access level %v", entries[0].ContextMap(), entries[1].Level)
+	}
+
+	// 状态码决定日志级别，跳过的路径不输出访问日志
+	serve("/fail", nil)
+	serve("/missing", nil)
+	serve("/healthz", nil)
+	entries = logs.TakeAll()
+	if len(entries) != 2 || entries[0].Level != zapcore.ErrorLevel || entries[1].Level != zapcore.WarnLevel {
+		t.Fatalf("logs: %v", entries)
+	}
+}
+
+func TestAccessLogMiddleware_RequestID(t *testing.T) {
+	l, _ := newObservedLog()
+	handler := l.AccessLogMiddleware(&AccessLogOptions{RequestIDHeader: "X-Trace"})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(RequestIDFromContext(r.Context())))
+		}))
+
+	tests := []struct {
+		name string
+		id   string
+		keep bool
+	}{
+		{"uuid", "3f2b8c9e-1d4a-4f6b-9c2e-7a8b9c0d1e2f", true},
+		{"allowed punctuation", "svc.order:42_a", true},
+		{"surrounding spaces", "  abc  ", true},
+		{"max length", strings.Repeat("a", maxRequestIDLen), true},
+		{"empty", "", false},
+		{"too long", strings.Repeat("a", maxRequestIDLen+1), false},
+		{"space", "a b", false},
+		{"control character", "abc\x1b[31m", false},
+		{"quote", `a"b`, false},
+		{"non-ascii", "请求1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header["X-Trace"] = []string{tt.id}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Body.String()
+			if got != rec.Header().Get("X-Trace") {
+				t.Fatalf("context %q and response header %q differ", got, rec.Header().Get("X-Trace"))
+			}
+			if kept := got == strings.TrimSpace(tt.id); kept != tt.keep {
+				t.Fatalf("request id %q: kept = %v, want %v", got, kept, tt.keep)
+			}
+			if !validRequestID(got) {
+				t.Fatalf("generated request id %q is invalid", got)
+			}
+		})
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"00-" + traceID + "-00f067aa0ba902b7-01", traceID},
+		{"  00-" + traceID + "-00f067aa0ba902b7-00  ", traceID},
+		{"01-" + traceID + "-00f067aa0ba902b7-01-extra", traceID},
+		{"00-" + traceID + "-00f067aa0ba902b7-01-extra", ""},
+		{"ff-" + traceID + "-00f067aa0ba902b7-01", ""},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", ""},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01", ""},
+		{"00-4bf92f3577b34da6a3ce929d0e0e47\n6-00f067aa0ba902b7-01", ""},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", ""},
+		{"00-" + traceID + "-0000000000000000-01", ""},
+		{"00-" + traceID + "-00f067aa0ba902b-01", ""},
+		{"0-" + traceID + "-00f067aa0ba902b7-01", ""},
+		{"00-" + traceID + "-00f067aa0ba902b7", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := parseTraceParent(tt.header); got != tt.want {
+			t.Errorf("parseTraceParent(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Named 返回名为 name 的子 logger，日志的 logger 字段为模块名（嵌套调用时以 "." 连接，如 "sharding.db"）
+// 子 logger 默认与上一级 logger 共用输出和级别；LogConfig.Modules 中配置了该模块时：
+//   - Level 不为空：使用独立的级别，不受上一级 logger SetLevel 影响，可通过子 logger 的 SetLevel 单独调整
+//   - File 为 true：写入独立的日志文件（文件名为 "<主日志文件名>_<模块名>"），不再写入上一级 logger 的日志文件
+//
+// 同名的子 logger 只创建一次，需在 InitLog 之后调用，未初始化时返回不输出任何日志的 logger
+//
+// 使用示例:
+//
+//	logger.Named("sharding").GetLog().Infow("shard ready", "db", 3)
+func (l *Log) Named(name string) *Log {
+	if l.root == nil {
+		return l.named(l, name)
+	}
+	return l.root.named(l, l.name+"."+name)
+}
+
+// named 在主 logger 上创建或返回缓存的子 logger，parent 为上一级 logger（可以是主 logger 自身）
+func (l *Log) named(parent *Log, name string) *Log {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.logger == nil || l.config == nil {
+		return newNopLog(name)
+	}
+	key := strings.ToLower(name)
+	if child, ok := l.children[key]; ok {
+		return child
+	}
+
+	child := &Log{name: name, root: l, level: parent.level, files: parent.files}
+	module, hasModule := l.config.module(name)
+	if hasModule && module.Level != nil {
+		child.level = zap.NewAtomicLevelAt(*module.Level)
+	}
+	if hasModule && module.File && l.config.hasFileOutput() {
+		child.files = l.buildModuleFilesLocked(name)
+	}
+
+	core := wrapSampling(buildTee(child.level, l.shared, child.files), l.sampling, l.limiter)
+	child.logger = zap.New(core, l.options...).Named(name).Sugar()
+	l.children[key] = child
+	return child
+}
+
+// buildModuleFilesLocked 为模块创建独立的日志文件输出，writer 登记到主 logger 上统一刷新和关闭，调用方需持有 l.mu
+func (l *Log) buildModuleFilesLocked(name string) []outputFactory {
+	var asyncClosers, fileClosers []io.Closer
+	wrapWriter := newWriterWrapper(l.config, func(ws syncer, async, file io.Closer) {
+		l.writers = append(l.writers, ws)
+		if async != nil {
+			asyncClosers = append(asyncClosers, async)
+		}
+		if file != nil {
+			fileClosers = append(fileClosers, file)
+		}
+	})
+	fileName := fmt.Sprintf("%s_%s", l.fileName, strings.ReplaceAll(name, ".", "_"))
+	files := l.redact.wrap(buildFileOutputs(l.fileEncoder, wrapWriter, fileName, l.config))
+	// 与 init 一致：先关闭异步 writer，再关闭文件
+	l.closers = append(append(asyncClosers, fileClosers...), l.closers...)
+	return files
+}
+
+// newNopLog 返回不输出任何日志的 Log
+func newNopLog(name string) *Log {
+	return &Log{
+		name:   name,
+		logger: zap.NewNop().Sugar(),
+		level:  zap.NewAtomicLevel(),
+	}
+}
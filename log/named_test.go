@@ -0,0 +1,132 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogConfig_FilePath(t *testing.T) {
+	config := NewLogConfig()
+	if got := config.filePath("app", ""); got != filepath.Join("logs", "app.log") {
+		t.Fatalf("single = %s", got)
+	}
+	if got := config.filePath("app", "error"); got != filepath.Join("logs", "app_error.log") {
+		t.Fatalf("separate = %s", got)
+	}
+
+	config.Type = TypeFile
+	config.Dir = "/var/log/svc"
+	config.FileNameTemplate = "{level}/{name}.log"
+	if got := config.filePath("app", "warn"); got != "/var/log/svc/warn/app.log" {
+		t.Fatalf("template = %s", got)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	// separate 模式的模板必须包含 {level}，否则各级别写入同一个文件
+	config.FileNameTemplate = "{name}.log"
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "{level}") {
+		t.Fatalf("want {level} error, got %v", err)
+	}
+}
+
+func TestLog_Named(t *testing.T) {
+	dir := t.TempDir()
+	config, err := LogConfigFromMap(map[string]string{
+		"type":                   "file",
+		"level":                  "info",
+		"fileMode":               "single",
+		"dir":                    dir,
+		"modules.sharding.level": "debug",
+		"modules.sharding.file":  "true",
+	})
+	if err != nil {
+		t.Fatalf("LogConfigFromMap: %v", err)
+	}
+	var root Log
+	if err := root.InitLogWithConfig(config, "app"); err != nil {
+		t.Fatalf("InitLogWithConfig: %v", err)
+	}
+
+	sharding := root.Named("sharding")
+	if root.Named("Sharding") != sharding {
+		t.Fatal("Named should return the cached child")
+	}
+	static := root.Named("static")
+
+	root.GetLog().Debug("root debug")
+	root.GetLog().Info("root info")
+	sharding.GetLog().Debug("sharding debug")
+	static.GetLog().Debug("static debug")
+	static.GetLog().Info("static info")
+	sharding.Named("db").GetLog().Info("db info")
+
+	// 子 logger 的级别独立调整
+	root.SetLevel(zapcore.ErrorLevel)
+	sharding.GetLog().Info("sharding info")
+	if err := root.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	main := readFile(t, filepath.Join(dir, "app.log"))
+	for _, want := range []string{"root info", "static info", "static"} {
+		if !strings.Contains(main, want) {
+			t.Errorf("app.log missing %q:\n%s", want, main)
+		}
+	}
+	for _, unwanted := range []string{"root debug", "static debug", "sharding"} {
+		if strings.Contains(main, unwanted) {
+			t.Errorf("app.log should not contain %q:\n%s", unwanted, main)
+		}
+	}
+
+	module := readFile(t, filepath.Join(dir, "app_sharding.log"))
+	for _, want := range []string{"sharding debug", "sharding info", "sharding.db", "db info"} {
+		if !strings.Contains(module, want) {
+			t.Errorf("app_sharding.log missing %q:\n%s", want, module)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	t.Cleanup(func() {
+		SetDefault(nil)
+		Register("custom", nil)
+	})
+
+	if _, ok := Lookup("sharding"); ok {
+		t.Fatal("Lookup should fail without default logger")
+	}
+	if l := Get("sharding"); l == nil || l.GetLog() == nil {
+		t.Fatal("Get should return a nop logger")
+	}
+
+	var root Log
+	root.InitLog(map[string]string{"type": "console"}, "app")
+	SetDefault(&root)
+	if l, ok := Lookup("sharding"); !ok || l != root.Named("sharding") {
+		t.Fatal("Lookup should derive child from default logger")
+	}
+
+	var custom Log
+	custom.InitLog(map[string]string{"type": "console"}, "custom")
+	Register("Custom", &custom)
+	if Get("custom") != &custom {
+		t.Fatal("Get should return the registered logger")
+	}
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
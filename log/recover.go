@@ -0,0 +1,144 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"github.com/bobwong89757/gnbutils/util/xerror"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 记录 panic 时的默认消息
+const defaultPanicMessage = "panic recovered"
+
+// RecoverOptions Recover / SafeGo 的选项
+type RecoverOptions struct {
+	// 日志消息，默认 "panic recovered"
+	Message string
+	// 附加的字段，格式同 SugaredLogger.Errorw 的 keysAndValues
+	Fields []interface{}
+	// 记录日志并刷新后重新 panic（原 panic 值），用于让进程按原有方式退出
+	RePanic bool
+	// 记录日志后调用，在 RePanic 之前执行
+	OnPanic func(value interface{})
+}
+
+// Recover 捕获 panic 并以 error 级别记录 panic 值和堆栈，必须直接通过 defer 调用
+// panic 值为 *xerror.XError（或包装了 XError 的 error）时，同时记录 XError 的 id、附加值和创建时的堆栈
+//
+// 使用示例:
+//
+//	func handle() {
+//		defer logger.Recover()
+//		...
+//	}
+func (l *Log) Recover() {
+	if p := recover(); p != nil {
+		l.logPanic(p, nil)
+	}
+}
+
+// RecoverWith 同 Recover，可通过 opts 指定消息、字段和是否重新 panic，必须直接通过 defer 调用
+func (l *Log) RecoverWith(opts *RecoverOptions) {
+	if p := recover(); p != nil {
+		l.logPanic(p, opts)
+	}
+}
+
+// SafeGo 在新的 goroutine 中执行 fn，fn 发生 panic 时记录日志而不是让进程退出
+//
+// 使用示例:
+//
+//	logger.SafeGo(func() {
+//		consume(queue)
+//	})
+func (l *Log) SafeGo(fn func()) {
+	l.SafeGoWith(fn, nil)
+}
+
+// SafeGoWith 同 SafeGo，opts 见 RecoverOptions
+func (l *Log) SafeGoWith(fn func(), opts *RecoverOptions) {
+	go func() {
+		defer l.RecoverWith(opts)
+		fn()
+	}()
+}
+
+// logPanic 记录 panic，按选项执行回调和重新 panic
+func (l *Log) logPanic(p interface{}, opts *RecoverOptions) {
+	var o RecoverOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Message == "" {
+		o.Message = defaultPanicMessage
+	}
+
+	// 跳过 logPanic 和 Recover / RecoverWith，堆栈从 panic 处开始
+	stack := zap.StackSkip("stacktrace", 2)
+	fields := append(panicFields(p), stack)
+
+	if logger := l.GetLog(); logger != nil {
+		// 堆栈已作为字段记录，不再由 AddStacktrace 重复附加；调用位置在本文件中，没有意义
+		logger.Desugar().
+			WithOptions(zap.WithCaller(false), zap.AddStacktrace(zapcore.FatalLevel+1)).
+			Sugar().
+			With(o.Fields...).
+			Errorw(o.Message, fieldsToArgs(fields)...)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: %v\n%s\n", o.Message, p, debug.Stack())
+	}
+
+	if o.OnPanic != nil {
+		o.OnPanic(p)
+	}
+	if o.RePanic {
+		_ = l.Sync()
+		panic(p)
+	}
+}
+
+// panicFields 按 panic 值的类型生成日志字段
+func panicFields(p interface{}) []zap.Field {
+	err, ok := p.(error)
+	if !ok {
+		return []zap.Field{zap.Any("panic", p)}
+	}
+
+	fields := []zap.Field{zap.String("panic", err.Error())}
+	var xerr *xerror.XError
+	if errors.As(err, &xerr) {
+		info := xerr.Info()
+		fields = append(fields, zap.String("error_id", info.Id))
+		if values := xerr.Values(); len(values) > 0 {
+			fields = append(fields, zap.Any("error_values", values))
+		}
+		fields = append(fields, zap.String("error_stack", formatXErrorStack(info.StackTrace)))
+	}
+	return fields
+}
+
+// formatXErrorStack 将 XError 创建时的堆栈格式化为与 zap 堆栈相同的多行文本
+func formatXErrorStack(stacks []*xerror.Stack) string {
+	var b strings.Builder
+	for i, s := range stacks {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%s\n\t%s:%d", s.Func, s.File, s.Line)
+	}
+	return b.String()
+}
+
+// fieldsToArgs 将 zap.Field 转换为 SugaredLogger 的参数
+func fieldsToArgs(fields []zap.Field) []interface{} {
+	args := make([]interface{}, len(fields))
+	for i, f := range fields {
+		args[i] = f
+	}
+	return args
+}
@@ -0,0 +1,98 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobwong89757/gnbutils/util/xerror"
+)
+
+// newHookedLog 创建只通过 Hook 收集 error 日志的 Log
+func newHookedLog(t *testing.T) (*Log, <-chan HookEntry) {
+	t.Helper()
+	l := &Log{}
+	if err := l.InitLogWithConfig(mustLogConfig(t, map[string]string{"consoleLevels": "fatal"}), "app"); err != nil {
+		t.Fatalf("InitLogWithConfig: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close(context.Background()) })
+
+	entries := make(chan HookEntry, 10)
+	l.AddHook(func(batch []HookEntry) error {
+		for _, e := range batch {
+			entries <- e
+		}
+		return nil
+	}, &HookOptions{DedupeWindow: -1, BatchSize: 1})
+	return l, entries
+}
+
+func receiveEntry(t *testing.T, entries <-chan HookEntry) HookEntry {
+	t.Helper()
+	select {
+	case e := <-entries:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("no log entry")
+		return HookEntry{}
+	}
+}
+
+func TestSafeGo(t *testing.T) {
+	l, entries := newHookedLog(t)
+	l.SafeGoWith(func() {
+		panic("boom")
+	}, &RecoverOptions{Fields: []interface{}{"job", "sync"}})
+
+	e := receiveEntry(t, entries)
+	if e.Message != defaultPanicMessage || e.Fields["panic"] != "boom" || e.Fields["job"] != "sync" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	stack, _ := e.Fields["stacktrace"].(string)
+	if !strings.Contains(stack, "TestSafeGo") {
+		t.Fatalf("stack should start at the panic site:\n%s", stack)
+	}
+}
+
+func TestRecover_XError(t *testing.T) {
+	l, entries := newHookedLog(t)
+	func() {
+		defer l.Recover()
+		panic(xerror.New("shard %d unavailable", 3).Id("shard_down").With("db", 3))
+	}()
+
+	e := receiveEntry(t, entries)
+	if e.Fields["panic"] != "shard 3 unavailable" || e.Fields["error_id"] != "shard_down" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	if values, _ := e.Fields["error_values"].(map[string]any); values["db"] != 3 {
+		t.Fatalf("error_values = %v", e.Fields["error_values"])
+	}
+	if stack, _ := e.Fields["error_stack"].(string); !strings.Contains(stack, "TestRecover_XError") {
+		t.Fatalf("error_stack = %q", stack)
+	}
+}
+
+func TestRecover_RePanic(t *testing.T) {
+	l, entries := newHookedLog(t)
+	var handled interface{}
+	defer func() {
+		if p := recover(); p != "again" {
+			t.Fatalf("recovered %v, want re-panic", p)
+		}
+		if handled != "again" {
+			t.Fatalf("OnPanic got %v", handled)
+		}
+		if e := receiveEntry(t, entries); e.Message != "worker crashed" {
+			t.Fatalf("unexpected entry: %+v", e)
+		}
+	}()
+
+	defer l.RecoverWith(&RecoverOptions{
+		Message: "worker crashed",
+		RePanic: true,
+		OnPanic: func(v interface{}) { handled = v },
+	})
+	panic("again")
+}
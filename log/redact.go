@@ -0,0 +1,278 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bobwong89757/gnbutils/util/validator"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 默认的脱敏替换文本
+const defaultRedactMask = "***"
+
+// 消息中的连续数字（身份证号最后一位可能为 X）
+var redactDigitsPattern = regexp.MustCompile(`\d+[Xx]?`)
+
+// redactor 对日志消息和字段脱敏
+type redactor struct {
+	// 需要整体替换的字段名（小写）
+	fields map[string]bool
+	// 匹配消息中 name=value、name: value、"name":"value" 形式的敏感字段
+	keyValue *regexp.Regexp
+	patterns []*regexp.Regexp
+	mobile   bool
+	idNumber bool
+	mask     string
+}
+
+// newRedactor 按配置创建脱敏器，没有配置任何规则时返回 nil
+// 无效的正则由 LogConfig.Validate 报告，这里跳过
+func newRedactor(c RedactConfig) *redactor {
+	if len(c.Fields) == 0 && len(c.Patterns) == 0 && !c.Mobile && !c.IDNumber {
+		return nil
+	}
+	r := &redactor{mobile: c.Mobile, idNumber: c.IDNumber, mask: c.Mask}
+	if r.mask == "" {
+		r.mask = defaultRedactMask
+	}
+
+	var names []string
+	for _, name := range c.Fields {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if r.fields == nil {
+			r.fields = make(map[string]bool)
+		}
+		r.fields[name] = true
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	if len(names) > 0 {
+		r.keyValue = regexp.MustCompile(`(?i)(["']?\b(?:` + strings.Join(names, "|") + `)\b["']?\s*[:=]\s*["']?)([^\s"'&,;]+)`)
+	}
+
+	for _, p := range c.Patterns {
+		if re, err := regexp.Compile(p); err == nil {
+			r.patterns = append(r.patterns, re)
+		}
+	}
+	return r
+}
+
+// wrap 为输出包装脱敏，r 为 nil 时原样返回
+func (r *redactor) wrap(outputs []outputFactory) []outputFactory {
+	if r == nil {
+		return outputs
+	}
+	wrapped := make([]outputFactory, len(outputs))
+	for i, output := range outputs {
+		output := output
+		wrapped[i] = func(level zapcore.LevelEnabler) zapcore.Core {
+			return &redactCore{Core: output(level), r: r}
+		}
+	}
+	return wrapped
+}
+
+// text 对文本脱敏：依次替换自定义正则、敏感字段的值、手机号和身份证号
+func (r *redactor) text(s string) string {
+	for _, re := range r.patterns {
+		s = r.replacePattern(re, s)
+	}
+	if r.keyValue != nil {
+		s = r.keyValue.ReplaceAllString(s, "${1}"+r.mask)
+	}
+	if r.mobile || r.idNumber {
+		s = redactDigitsPattern.ReplaceAllStringFunc(s, r.digits)
+	}
+	return s
+}
+
+// replacePattern 正则不含分组时替换整个匹配，含分组时只替换各分组匹配的部分
+func (r *redactor) replacePattern(re *regexp.Regexp, s string) string {
+	if re.NumSubexp() == 0 {
+		return re.ReplaceAllString(s, r.mask)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+		for i := 2; i < len(m); i += 2 {
+			start, end := m[i], m[i+1]
+			if start < last || start < 0 {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(r.mask)
+			last = end
+		}
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// digits 对一串数字脱敏：手机号（可带 86 前缀）保留前 3 位和后 4 位，身份证号保留前 3 位和后 4 位
+func (r *redactor) digits(s string) string {
+	switch {
+	case r.mobile && len(s) == 11 && validator.IsChineseMobile(s):
+		return s[:3] + "****" + s[7:]
+	case r.mobile && len(s) == 13 && strings.HasPrefix(s, "86") && validator.IsChineseMobile(s[2:]):
+		return s[:5] + "****" + s[9:]
+	case r.idNumber && len(s) == 18 && validator.IsChineseIdNum(s):
+		return s[:3] + strings.Repeat("*", 11) + s[14:]
+	}
+	return s
+}
+
+// redactFields 返回脱敏后的字段，不修改传入的切片
+func (r *redactor) redactFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		rf, changed := r.field(f)
+		if !changed {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fields))
+			copy(out, fields[:i])
+		}
+		out = append(out, rf)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// field 对单个字段脱敏，返回是否有修改
+func (r *redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if f.Type == zapcore.NamespaceType || f.Type == zapcore.SkipType {
+		return f, false
+	}
+	if r.fields[strings.ToLower(f.Key)] {
+		return zap.String(f.Key, r.mask), true
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		if s := r.text(f.String); s != f.String {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			if s := r.text(string(b)); s != string(b) {
+				return zap.String(f.Key, s), true
+			}
+		}
+	case zapcore.ErrorType, zapcore.StringerType:
+		s, ok := safeString(f.Interface)
+		if red := r.text(s); ok && red != s {
+			return zap.String(f.Key, red), true
+		}
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType:
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		if v, changed := r.value(enc.Fields[f.Key], false); changed {
+			return zap.Any(f.Key, v), true
+		}
+	}
+	return f, false
+}
+
+// value 对结构化的值递归脱敏，结构体等类型先转换为 JSON 形式
+func (r *redactor) value(v interface{}, converted bool) (interface{}, bool) {
+	switch t := v.(type) {
+	case nil, bool, float64, float32, int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8, uintptr:
+		return v, false
+	case string:
+		s := r.text(t)
+		return s, s != t
+	case map[string]interface{}:
+		changed := false
+		out := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			if r.fields[strings.ToLower(k)] {
+				out[k] = r.mask
+				changed = true
+				continue
+			}
+			var c bool
+			out[k], c = r.value(item, converted)
+			changed = changed || c
+		}
+		return out, changed
+	case []interface{}:
+		changed := false
+		out := make([]interface{}, len(t))
+		for i, item := range t {
+			var c bool
+			out[i], c = r.value(item, converted)
+			changed = changed || c
+		}
+		return out, changed
+	}
+	if converted {
+		return v, false
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v, false
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return v, false
+	}
+	if out, changed := r.value(generic, true); changed {
+		return out, true
+	}
+	return v, false
+}
+
+// safeString 取 error / fmt.Stringer 的文本，对 nil 指针等导致的 panic 返回 false
+func safeString(v interface{}) (s string, ok bool) {
+	defer func() {
+		if recover() != nil {
+			s, ok = "", false
+		}
+	}()
+	switch t := v.(type) {
+	case error:
+		return t.Error(), true
+	case fmt.Stringer:
+		return t.String(), true
+	}
+	return "", false
+}
+
+// redactCore 在日志写入下层 core 之前对消息和字段脱敏
+type redactCore struct {
+	zapcore.Core
+	r *redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.redactFields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.r.text(ent.Message)
+	return c.Core.Write(ent, c.r.redactFields(fields))
+}
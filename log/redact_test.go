@@ -0,0 +1,90 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestRedactor_Text(t *testing.T) {
+	r := newRedactor(RedactConfig{
+		Fields:   []string{"password", "token"},
+		Patterns: []string{`card=(\d{12})\d{4}`, `sk-[A-Za-z0-9]+`},
+		Mobile:   true,
+		IDNumber: true,
+	})
+	tests := []struct{ in, want string }{
+		{"user:pass dsn password=secret&charset=utf8", "user:pass dsn password=***&charset=utf8"},
+		{`{"token": "abc.def", "id": 1}`, `{"token": "***", "id": 1}`},
+		{"call 13812345678 or +8613812345678", "call 138****5678 or +86138****5678"},
+		{"order 12345678901 not a mobile", "order 12345678901 not a mobile"},
+		{"id 11010519491231002X ok", "id 110***********002X ok"},
+		{"card=6222020200112233 key sk-abc123", "card=***2233 key ***"},
+	}
+	for _, tt := range tests {
+		if got := r.text(tt.in); got != tt.want {
+			t.Errorf("text(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLog_Redact(t *testing.T) {
+	dir := t.TempDir()
+	config := mustLogConfig(t, map[string]string{
+		"type":                "hybrid",
+		"consoleLevels":       "fatal",
+		"format":              "json",
+		"fileMode":            "single",
+		"dir":                 dir,
+		"redactFields":        "password,Authorization",
+		"redactPatterns.card": `\d{4}-\d{4}-\d{4}-\d{4}`,
+		"redactMobile":        "true",
+	})
+	var l Log
+	if err := l.InitLogWithConfig(config, "app"); err != nil {
+		t.Fatalf("InitLogWithConfig: %v", err)
+	}
+
+	entries := make(chan HookEntry, 1)
+	l.AddHook(func(batch []HookEntry) error {
+		entries <- batch[0]
+		return nil
+	}, nil)
+
+	type login struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+	}
+	logger := l.GetLog().With("authorization", "Bearer xyz")
+	logger.Infow("login password=hunter2 from 13812345678",
+		"password", "hunter2",
+		"req", login{User: "bob", Password: "hunter2"},
+		"card", "6222-0202-0011-2233",
+	)
+	logger.Desugar().Error("failed", zap.Error(errors.New("mobile 13812345678 rejected")))
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	out := readFile(t, filepath.Join(dir, "app.log"))
+	for _, leaked := range []string{"hunter2", "13812345678", "xyz", "6222-0202"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("log output leaked %q:\n%s", leaked, out)
+		}
+	}
+	for _, want := range []string{`"password":"***"`, `"authorization":"***"`, `"user":"bob"`, "138****5678", `"card":"***"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log output missing %q:\n%s", want, out)
+		}
+	}
+
+	// Hook 收到的也是脱敏后的日志
+	e := <-entries
+	if e.Fields["error"] != "mobile 138****5678 rejected" || e.Fields["authorization"] != "***" {
+		t.Fatalf("hook entry not redacted: %+v", e)
+	}
+}
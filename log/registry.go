@@ -0,0 +1,72 @@
+package log
+
+import (
+	"strings"
+	"sync"
+)
+
+// 按名称登记的 logger，供 sharding、static 等模块按名称获取
+var registry = struct {
+	sync.RWMutex
+	def    *Log
+	byName map[string]*Log
+}{byName: make(map[string]*Log)}
+
+// SetDefault 设置默认 logger，Get / Lookup 未找到登记的 logger 时从默认 logger 派生子 logger
+func SetDefault(l *Log) {
+	registry.Lock()
+	registry.def = l
+	registry.Unlock()
+}
+
+// Default 返回默认 logger，未设置时返回 nil
+func Default() *Log {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.def
+}
+
+// Register 按名称登记 logger（名称不区分大小写），l 为 nil 时取消登记
+func Register(name string, l *Log) {
+	key := strings.ToLower(name)
+	registry.Lock()
+	defer registry.Unlock()
+	if l == nil {
+		delete(registry.byName, key)
+		return
+	}
+	registry.byName[key] = l
+}
+
+// Lookup 按名称查找 logger：优先返回 Register 登记的 logger，其次返回默认 logger 的同名子 logger，
+// 都没有时返回 false
+func Lookup(name string) (*Log, bool) {
+	registry.RLock()
+	l, ok := registry.byName[strings.ToLower(name)]
+	def := registry.def
+	registry.RUnlock()
+
+	if ok {
+		return l, true
+	}
+	if def != nil && def.GetLog() != nil {
+		return def.Named(name), true
+	}
+	return nil, false
+}
+
+// Get 按名称获取 logger，规则同 Lookup，都没有时返回不输出任何日志的 logger（不会返回 nil）
+//
+// 使用示例:
+//
+//	var logger log.Log
+//	logger.InitLog(logConfig, "app")
+//	log.SetDefault(&logger)
+//
+//	log.Get("sharding").GetLog().Info("shard ready")
+func Get(name string) *Log {
+	if l, ok := Lookup(name); ok {
+		return l
+	}
+	return newNopLog(name)
+}
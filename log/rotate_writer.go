@@ -0,0 +1,512 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bobwong89757/gnbutils/lz4"
+	"github.com/lestrrat-go/strftime"
+)
+
+// 压缩格式
+const (
+	compressNone = ""
+	compressGzip = "gzip"
+	compressLz4  = "lz4"
+)
+
+// rotateOptions 日志切割选项
+type rotateOptions struct {
+	// 按时间切割的间隔
+	rotationTime time.Duration
+	// 保留时长，<0 表示不按时间清理
+	maxAge time.Duration
+	// 保留文件数量，<=0 表示不限制
+	rotationCount int
+	// 单个文件最大字节数，<=0 表示不按大小切割
+	maxSize int64
+	// 所有日志文件（含压缩包）的总字节数上限，<=0 表示不限制
+	maxTotalSize int64
+	// 切割后文件的压缩格式：""、gzip、lz4
+	compress string
+}
+
+// rotateWriter 同时支持按时间和按大小切割的日志 Writer
+//
+// 文件命名：<base>-<时间>.log，同一时间段内按大小切割时依次为 <base>-<时间>.1.log、<base>-<时间>.2.log ...
+// 切割出的旧文件在后台压缩，并按保留时长、数量和总大小清理。首次打开文件时还会压缩重启前遗留的未压缩文件。
+type rotateWriter struct {
+	mu       sync.Mutex
+	linkName string
+	baseName string
+	pattern  *strftime.Strftime
+	// 匹配本 writer 切割出的文件名（不含目录），不匹配共享前缀的其他 logger 的文件
+	namePattern *regexp.Regexp
+	opts        rotateOptions
+
+	file *os.File
+	// 当前文件路径，Close 后仍保留，后台清理不会删除或压缩它
+	current  string
+	timeName string
+	index    int
+	size     int64
+
+	// 待压缩的文件，空字符串表示扫描重启前遗留的文件
+	archiveCh   chan string
+	archiveDone chan struct{}
+	// 队列满时有文件未入队，后台下次处理时扫描遗留文件压缩
+	leftovers atomic.Bool
+	scanned   bool
+	closed    bool
+}
+
+// newRotateWriter 创建切割 Writer，linkName 为指向当前文件的软链接路径
+func newRotateWriter(linkName, rotationFormat string, opts rotateOptions) (*rotateWriter, error) {
+	baseName := strings.TrimSuffix(linkName, ".log")
+	pattern, err := strftime.New(fmt.Sprintf("%s-%s.log", baseName, rotationFormat))
+	if err != nil {
+		return nil, fmt.Errorf("invalid rotation format %q: %w", rotationFormat, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(linkName), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	w := &rotateWriter{
+		linkName:    linkName,
+		baseName:    baseName,
+		pattern:     pattern,
+		namePattern: rotationPattern(filepath.Base(baseName), rotationFormat),
+		opts:        opts,
+		archiveCh:   make(chan string, 64),
+		archiveDone: make(chan struct{}),
+	}
+	go w.archiveLoop()
+	return w, nil
+}
+
+// Write 实现 io.Writer，写入前检查是否需要切割
+func (w *rotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	timeName := w.pattern.FormatString(w.truncateTime(time.Now()))
+	switch {
+	case w.file == nil || timeName != w.timeName:
+		if err := w.openLocked(timeName); err != nil {
+			return 0, err
+		}
+	case w.opts.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.opts.maxSize:
+		if err := w.rotateLocked(w.index + 1); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Sync 将当前文件刷到磁盘
+func (w *rotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close 关闭当前文件，并等待后台压缩和清理完成
+func (w *rotateWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	close(w.archiveCh)
+	w.mu.Unlock()
+
+	<-w.archiveDone
+	return err
+}
+
+// truncateTime 按本地时间将 t 对齐到切割周期的起点
+func (w *rotateWriter) truncateTime(t time.Time) time.Time {
+	if w.opts.rotationTime <= 0 {
+		return t
+	}
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(w.opts.rotationTime).Add(-shift)
+}
+
+// fileName 返回指定时间段和序号对应的文件名
+func (w *rotateWriter) fileName(timeName string, index int) string {
+	if index == 0 {
+		return timeName
+	}
+	return fmt.Sprintf("%s.%d.log", strings.TrimSuffix(timeName, ".log"), index)
+}
+
+// openLocked 进入新的时间段，续写该时间段内的最后一个文件；该文件已写满或已压缩时使用下一个序号
+func (w *rotateWriter) openLocked(timeName string) error {
+	index := 0
+	if w.rotatedExists(timeName, 0) {
+		for w.rotatedExists(timeName, index+1) {
+			index++
+		}
+		info, err := os.Stat(w.fileName(timeName, index))
+		if err != nil || (w.opts.maxSize > 0 && info.Size() >= w.opts.maxSize) {
+			index++
+		}
+	}
+	w.timeName = timeName
+	if err := w.rotateLocked(index); err != nil {
+		return err
+	}
+	if !w.scanned {
+		// 当前文件确定后才能区分遗留文件，此时队列为空，不会阻塞
+		w.scanned = true
+		select {
+		case w.archiveCh <- "":
+		default:
+		}
+	}
+	return nil
+}
+
+// rotatedExists 检查时间段内第 index 个文件是否存在（含压缩后的文件）
+func (w *rotateWriter) rotatedExists(timeName string, index int) bool {
+	name := w.fileName(timeName, index)
+	for _, path := range []string{name, name + ".gz", name + ".lz4"} {
+		if _, err := os.Lstat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// rotateLocked 关闭当前文件并切换到当前时间段的第 index 个文件
+func (w *rotateWriter) rotateLocked(index int) error {
+	previous := ""
+	if w.file != nil {
+		previous = w.file.Name()
+		if err := w.file.Close(); err != nil {
+			return fmt.Errorf("failed to close log file: %w", err)
+		}
+		w.file = nil
+	}
+
+	filename := w.fileName(w.timeName, index)
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	w.file = f
+	w.current = filename
+	w.index = index
+	w.size = info.Size()
+	w.updateLink(filename)
+
+	if previous != "" && previous != filename {
+		select {
+		case w.archiveCh <- previous:
+		default:
+			// 后台积压时不在写日志的 goroutine 中压缩，由后台扫描遗留文件时压缩
+			w.leftovers.Store(true)
+		}
+	}
+	return nil
+}
+
+// updateLink 更新指向当前文件的软链接，失败时忽略
+func (w *rotateWriter) updateLink(filename string) {
+	target := filename
+	if rel, err := filepath.Rel(filepath.Dir(w.linkName), filename); err == nil {
+		target = rel
+	}
+	tmp := filename + "_symlink"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, w.linkName); err != nil {
+		_ = os.Remove(tmp)
+	}
+}
+
+// archiveLoop 后台压缩切割出的文件并执行保留策略
+func (w *rotateWriter) archiveLoop() {
+	defer close(w.archiveDone)
+	for filename := range w.archiveCh {
+		if filename != "" {
+			w.compress(filename)
+		}
+		if filename == "" || w.leftovers.Swap(false) {
+			w.compressLeftovers()
+		}
+		w.cleanup()
+	}
+	if w.leftovers.Swap(false) {
+		w.compressLeftovers()
+		w.cleanup()
+	}
+}
+
+// compress 按配置压缩切割出的文件，失败时输出到标准错误
+func (w *rotateWriter) compress(filename string) {
+	if w.opts.compress == compressNone {
+		return
+	}
+	if err := compressFile(filename, w.opts.compress); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "log: failed to compress %s: %v\n", filename, err)
+	}
+}
+
+// compressLeftovers 压缩重启前切割出但还未压缩的文件
+func (w *rotateWriter) compressLeftovers() {
+	if w.opts.compress == compressNone {
+		return
+	}
+	current := w.currentFile()
+	for _, path := range w.rotatedFiles() {
+		if path != current && strings.HasSuffix(path, ".log") {
+			w.compress(path)
+		}
+	}
+}
+
+// currentFile 返回正在写入（或关闭前最后写入）的文件路径
+func (w *rotateWriter) currentFile() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// rotatedFiles 返回本 writer 切割出的所有文件（含当前文件和压缩包）
+func (w *rotateWriter) rotatedFiles() []string {
+	matches, err := filepath.Glob(w.baseName + "-*")
+	if err != nil {
+		return nil
+	}
+	files := matches[:0]
+	for _, path := range matches {
+		if w.namePattern.MatchString(filepath.Base(path)) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// cleanup 按保留时长、数量和总大小删除最旧的日志文件（不删除当前文件）
+func (w *rotateWriter) cleanup() {
+	matches := w.rotatedFiles()
+	current := w.currentFile()
+
+	type logFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []logFile
+	var total int64
+	for _, path := range matches {
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		total += info.Size()
+		if path == current {
+			continue
+		}
+		files = append(files, logFile{path: path, size: info.Size(), modTime: info.ModTime()})
+	}
+	// 新的在前；同一时刻切割出的文件按序号排序
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].modTime.Equal(files[j].modTime) {
+			return files[i].modTime.After(files[j].modTime)
+		}
+		return rotationIndex(files[i].path) > rotationIndex(files[j].path)
+	})
+
+	cutoff := time.Time{}
+	if w.opts.maxAge > 0 {
+		cutoff = time.Now().Add(-w.opts.maxAge)
+	}
+	// 从最旧的开始删除，直到满足所有限制
+	kept := len(files)
+	if current != "" {
+		kept++
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		expired := !cutoff.IsZero() && f.modTime.Before(cutoff)
+		tooMany := w.opts.rotationCount > 0 && kept > w.opts.rotationCount
+		tooLarge := w.opts.maxTotalSize > 0 && total > w.opts.maxTotalSize
+		if !expired && !tooMany && !tooLarge {
+			continue
+		}
+		if err := os.Remove(f.path); err == nil {
+			kept--
+			total -= f.size
+		}
+	}
+}
+
+// compressFile 将 filename 压缩为 filename.gz 或 filename.lz4，成功后删除原文件
+func compressFile(filename, format string) error {
+	src, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	target := filename + "." + compressExt(format)
+	tmp := target + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case compressGzip:
+		zw := gzip.NewWriter(dst)
+		if _, err = io.Copy(zw, src); err == nil {
+			err = zw.Close()
+		}
+	case compressLz4:
+		// 标准 LZ4 帧格式，可用 lz4 命令行工具解压
+		zw := lz4.NewWriter(dst)
+		if _, err = io.Copy(zw, src); err == nil {
+			err = zw.Close()
+		}
+	default:
+		err = fmt.Errorf("unknown compress format: %s", format)
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	// 保留原文件的修改时间，清理时按切割先后排序
+	_ = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	if err := os.Rename(tmp, target); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	src.Close()
+	return os.Remove(filename)
+}
+
+// strftimeDigits 常用 strftime 转换符对应的数字位数
+var strftimeDigits = map[byte]int{'Y': 4, 'y': 2, 'm': 2, 'd': 2, 'H': 2, 'I': 2, 'M': 2, 'S': 2, 'j': 3}
+
+// rotationPattern 返回匹配切割文件名的正则：<base>-<时间>[.序号].log[.gz|.lz4]
+// 时间部分按 rotationFormat 匹配，数字转换符只匹配对应位数的数字，其余转换符匹配任意非空内容
+func rotationPattern(base, rotationFormat string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^" + regexp.QuoteMeta(base+"-"))
+	for rest := rotationFormat; rest != ""; {
+		i := strings.IndexByte(rest, '%')
+		if i < 0 || i == len(rest)-1 {
+			b.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(rest[:i]))
+		verb := rest[i+1]
+		switch {
+		case verb == '%':
+			b.WriteString("%")
+		case strftimeDigits[verb] > 0:
+			fmt.Fprintf(&b, `\d{%d}`, strftimeDigits[verb])
+		default:
+			b.WriteString(`.+?`)
+		}
+		rest = rest[i+2:]
+	}
+	b.WriteString(`(\.\d+)?\.log(\.gz|\.lz4)?$`)
+	return regexp.MustCompile(b.String())
+}
+
+// rotationIndex 从文件名中解析按大小切割的序号，如 app-20240101.3.log.gz 返回 3
+func rotationIndex(path string) int {
+	name := filepath.Base(path)
+	if i := strings.Index(name, ".log"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		if n, err := strconv.Atoi(name[i+1:]); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// compressExt 返回压缩格式对应的文件扩展名
+func compressExt(format string) string {
+	if format == compressGzip {
+		return "gz"
+	}
+	return format
+}
+
+// parseSize 解析大小配置，支持 B/KB/MB/GB 后缀（不区分大小写，可省略 B），纯数字按 MB 处理
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"GB", 1 << 30}, {"G", 1 << 30},
+		{"MB", 1 << 20}, {"M", 1 << 20},
+		{"KB", 1 << 10}, {"K", 1 << 10},
+		{"B", 1},
+	}
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), 64)
+			if err != nil {
+				return 0, err
+			}
+			return int64(n * float64(u.size)), nil
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(n * (1 << 20)), nil
+}
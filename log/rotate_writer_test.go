@@ -0,0 +1,301 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobwong89757/gnbutils/lz4"
+)
+
+// newTestRotateWriter 在临时目录创建按天切割的 writer，返回 writer 和当前时间段的文件名前缀
+func newTestRotateWriter(t *testing.T, dir string, opts rotateOptions) (*rotateWriter, string) {
+	t.Helper()
+	if opts.rotationTime == 0 {
+		opts.rotationTime = 24 * time.Hour
+	}
+	w, err := newRotateWriter(filepath.Join(dir, "app.log"), "%Y%m%d", opts)
+	if err != nil {
+		t.Fatalf("newRotateWriter: %v", err)
+	}
+	return w, filepath.Join(dir, "app-"+time.Now().Format("20060102"))
+}
+
+// writeLines 写入 n 行固定长度（20 字节）的日志，返回写入的全部内容
+func writeLines(t *testing.T, w io.Writer, n int) string {
+	t.Helper()
+	var all strings.Builder
+	for i := 0; i < n; i++ {
+		line := fmt.Sprintf("line %014d\n", i)
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		all.WriteString(line)
+	}
+	return all.String()
+}
+
+// readRotated 按切割顺序读取并解压目录中以 prefix 开头的所有文件
+func readRotated(t *testing.T, prefix string) string {
+	t.Helper()
+	files, _ := filepath.Glob(prefix + "*")
+	sort.Slice(files, func(i, j int) bool { return rotationIndex(files[i]) < rotationIndex(files[j]) })
+
+	var all strings.Builder
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.Reader = bytes.NewReader(data)
+		switch {
+		case strings.HasSuffix(path, ".gz"):
+			if r, err = gzip.NewReader(r); err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+		case strings.HasSuffix(path, ".lz4"):
+			r = lz4.NewReader(r)
+		}
+		if _, err := io.Copy(&all, r); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+	return all.String()
+}
+
+func TestRotateWriter_SizeRotation(t *testing.T) {
+	dir := t.TempDir()
+	w, prefix := newTestRotateWriter(t, dir, rotateOptions{maxSize: 100})
+	written := writeLines(t, w, 12)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// 每个文件最多 5 行（100 字节），12 行切出 3 个文件
+	for _, name := range []string{prefix + ".log", prefix + ".1.log", prefix + ".2.log"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 100 {
+			t.Fatalf("%s: %d bytes exceeds maxSize", name, info.Size())
+		}
+	}
+	if got := readRotated(t, prefix); got != written {
+		t.Fatalf("content mismatch:\n%s", got)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "app.log")); err != nil || target != filepath.Base(prefix)+".2.log" {
+		t.Fatalf("link = %s, %v", target, err)
+	}
+
+	// 重新打开时续写最后一个未写满的文件
+	w, _ = newTestRotateWriter(t, dir, rotateOptions{maxSize: 100})
+	written += writeLines(t, w, 1)
+	_ = w.Close()
+	if _, err := os.Stat(prefix + ".3.log"); !os.IsNotExist(err) {
+		t.Fatal("reopen should append to the last file")
+	}
+	if got := readRotated(t, prefix); got != written {
+		t.Fatalf("content mismatch after reopen:\n%s", got)
+	}
+
+	// 已压缩的文件不会被重新打开覆盖
+	w, _ = newTestRotateWriter(t, dir, rotateOptions{maxSize: 100, compress: compressGzip})
+	written += writeLines(t, w, 4)
+	_ = w.Close()
+	w, _ = newTestRotateWriter(t, dir, rotateOptions{maxSize: 100, compress: compressGzip})
+	written += writeLines(t, w, 1)
+	_ = w.Close()
+	if got := readRotated(t, prefix); got != written {
+		t.Fatalf("content mismatch after compression:\n%s", got)
+	}
+}
+
+func TestRotateWriter_Compress(t *testing.T) {
+	for _, format := range []string{compressGzip, compressLz4} {
+		t.Run(format, func(t *testing.T) {
+			w, prefix := newTestRotateWriter(t, t.TempDir(), rotateOptions{maxSize: 100, compress: format})
+			written := writeLines(t, w, 12)
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			ext := "." + compressExt(format)
+			for _, name := range []string{prefix + ".log" + ext, prefix + ".1.log" + ext, prefix + ".2.log"} {
+				if _, err := os.Stat(name); err != nil {
+					t.Fatalf("missing %s", filepath.Base(name))
+				}
+			}
+			if _, err := os.Stat(prefix + ".log"); !os.IsNotExist(err) {
+				t.Fatal("rotated file not removed after compression")
+			}
+			if got := readRotated(t, prefix); got != written {
+				t.Fatalf("content mismatch:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestRotateWriter_CompressWhenQueueFull(t *testing.T) {
+	dir := t.TempDir()
+	w, prefix := newTestRotateWriter(t, dir, rotateOptions{maxSize: 100, compress: compressGzip})
+	_ = w.Close()
+
+	// 后台 goroutine 尚未启动、队列无法写入的 writer：写日志时不压缩，切割出的文件由后台之后补上
+	full := &rotateWriter{
+		linkName:    w.linkName,
+		baseName:    w.baseName,
+		pattern:     w.pattern,
+		namePattern: w.namePattern,
+		opts:        w.opts,
+		archiveCh:   make(chan string),
+		archiveDone: make(chan struct{}),
+		scanned:     true,
+	}
+	written := writeLines(t, full, 6)
+	if _, err := os.Stat(prefix + ".log"); err != nil {
+		t.Fatal("rotated file compressed while holding the writer lock")
+	}
+
+	go full.archiveLoop()
+	_ = full.Close()
+	if _, err := os.Stat(prefix + ".log.gz"); err != nil {
+		t.Fatal("rotated file not compressed after the queue drained")
+	}
+	if _, err := os.Stat(prefix + ".1.log"); err != nil {
+		t.Fatal("current file should stay uncompressed")
+	}
+	if got := readRotated(t, prefix); got != written {
+		t.Fatal("content mismatch")
+	}
+}
+
+func TestRotateWriter_CompressLeftovers(t *testing.T) {
+	dir := t.TempDir()
+	// 重启前切割出的文件
+	old := filepath.Join(dir, "app-20200101.log")
+	if err := os.WriteFile(old, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "app-audit-20200101.log")
+	if err := os.WriteFile(other, []byte("audit\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, prefix := newTestRotateWriter(t, dir, rotateOptions{maxAge: -1, compress: compressLz4})
+	writeLines(t, w, 1)
+	_ = w.Close()
+
+	if _, err := os.Stat(old + ".lz4"); err != nil {
+		t.Fatal("leftover file not compressed")
+	}
+	if got := readRotated(t, filepath.Join(dir, "app-2020")); got != "old\n" {
+		t.Fatalf("leftover content = %q", got)
+	}
+	if _, err := os.Stat(prefix + ".log"); err != nil {
+		t.Fatal("current file should stay uncompressed")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatal("file of another logger was compressed")
+	}
+}
+
+func TestRotateWriter_Cleanup(t *testing.T) {
+	t.Run("rotationCount", func(t *testing.T) {
+		dir := t.TempDir()
+		w, prefix := newTestRotateWriter(t, dir, rotateOptions{maxSize: 100, maxAge: -1, rotationCount: 2})
+		writeLines(t, w, 30)
+		_ = w.Close()
+
+		// 保留当前文件和最近一个切割出的文件
+		files, _ := filepath.Glob(filepath.Join(dir, "app-*"))
+		sort.Strings(files)
+		want := []string{prefix + ".4.log", prefix + ".5.log"}
+		if strings.Join(files, ",") != strings.Join(want, ",") {
+			t.Fatalf("files = %v, want %v", files, want)
+		}
+	})
+
+	t.Run("maxAge", func(t *testing.T) {
+		dir := t.TempDir()
+		old := time.Now().Add(-48 * time.Hour)
+		for _, name := range []string{"app-20200101.log", "app-20200102.log.gz", "app-audit-20200101.log", "app-20200103.log.bak"} {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte("x\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_ = os.Chtimes(path, old, old)
+		}
+		recent := filepath.Join(dir, "app-20200104.log.gz")
+		_ = os.WriteFile(recent, []byte("x\n"), 0644)
+
+		w, _ := newTestRotateWriter(t, dir, rotateOptions{maxAge: 24 * time.Hour})
+		writeLines(t, w, 1)
+		_ = w.Close()
+
+		for name, kept := range map[string]bool{
+			"app-20200101.log":    false,
+			"app-20200102.log.gz": false,
+			"app-20200104.log.gz": true,
+			// 共享前缀的其他 logger 和不属于切割文件的文件不受影响
+			"app-audit-20200101.log": true,
+			"app-20200103.log.bak":   true,
+		} {
+			_, err := os.Stat(filepath.Join(dir, name))
+			if exists := err == nil; exists != kept {
+				t.Errorf("%s: exists = %v, want %v", name, exists, kept)
+			}
+		}
+	})
+
+	t.Run("maxTotalSize", func(t *testing.T) {
+		dir := t.TempDir()
+		w, prefix := newTestRotateWriter(t, dir, rotateOptions{maxSize: 100, maxAge: -1, maxTotalSize: 250})
+		writeLines(t, w, 30)
+		_ = w.Close()
+
+		var total int64
+		files, _ := filepath.Glob(prefix + "*")
+		for _, path := range files {
+			info, _ := os.Stat(path)
+			total += info.Size()
+		}
+		if total > 250 || len(files) != 2 {
+			t.Fatalf("%d files with %d bytes left", len(files), total)
+		}
+	})
+}
+
+func TestRotationPattern(t *testing.T) {
+	daily := rotationPattern("app", "%Y%m%d")
+	hourly := rotationPattern("app.v1", "%Y-%m-%d_%H")
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"app-20240101.log", true},
+		{"app-20240101.3.log", true},
+		{"app-20240101.log.gz", true},
+		{"app-20240101.12.log.lz4", true},
+		{"app-audit-20240101.log", false},
+		{"app-2024010.log", false},
+		{"app-20240101.log.tmp", false},
+		{"app-20240101.log_symlink", false},
+		{"app.log", false},
+	}
+	for _, tt := range tests {
+		if got := daily.MatchString(tt.name); got != tt.want {
+			t.Errorf("%s: match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if !hourly.MatchString("app.v1-2024-01-01_08.log") || hourly.MatchString("appxv1-2024-01-01_08.log") {
+		t.Error("literal parts of the name must match exactly")
+	}
+}
@@ -0,0 +1,261 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 限流时最多跟踪的不同消息数量，超出时清理已过期的计数
+const rateLimitMaxKeys = 10000
+
+// samplingOptions 采样与限流选项
+type samplingOptions struct {
+	// zap 采样器：每个 tick 内同级别同消息的前 first 条输出，之后每 thereafter 条输出一条
+	sampling           bool
+	samplingTick       time.Duration
+	samplingFirst      int
+	samplingThereafter int
+
+	// 按消息限流：每个 interval 内同级别同消息的前 first 条输出，之后每 thereafter 条输出一条，
+	// 窗口结束时输出一条 "suppressed N messages" 汇总
+	rateLimit           bool
+	rateLimitInterval   time.Duration
+	rateLimitFirst      int
+	rateLimitThereafter int
+}
+
+// samplingOptionsFromConfig 从日志配置中取出采样与限流选项
+func samplingOptionsFromConfig(config *LogConfig) samplingOptions {
+	opts := samplingOptions{
+		sampling:            config.Sampling,
+		samplingTick:        config.SamplingTick,
+		samplingFirst:       config.SamplingFirst,
+		samplingThereafter:  config.SamplingThereafter,
+		rateLimit:           config.RateLimit,
+		rateLimitInterval:   config.RateLimitInterval,
+		rateLimitFirst:      config.RateLimitFirst,
+		rateLimitThereafter: config.RateLimitThereafter,
+	}
+	if opts.samplingTick <= 0 {
+		opts.samplingTick = time.Second
+	}
+	if opts.rateLimitInterval <= 0 {
+		opts.rateLimitInterval = time.Second
+	}
+	return opts
+}
+
+// newSamplingLimiter 按选项创建按消息限流器，未启用时返回 nil
+// 同一个 Log 及其子 logger 共用一个限流器（计数按 logger 名称区分）
+func newSamplingLimiter(opts samplingOptions) *rateLimiter {
+	if !opts.rateLimit {
+		return nil
+	}
+	return newRateLimiter(opts.rateLimitInterval, opts.rateLimitFirst, opts.rateLimitThereafter)
+}
+
+// wrapSampling 按选项为 core 包装 zap 采样器和按消息限流器（limiter 为 nil 时不限流）
+func wrapSampling(core zapcore.Core, opts samplingOptions, limiter *rateLimiter) zapcore.Core {
+	if opts.sampling {
+		core = zapcore.NewSamplerWithOptions(core, opts.samplingTick, opts.samplingFirst, opts.samplingThereafter)
+	}
+	if limiter == nil {
+		return core
+	}
+	return &rateLimitCore{Core: core, limiter: limiter}
+}
+
+// rateKey 限流的计数维度：logger 名称、级别和消息
+type rateKey struct {
+	name    string
+	level   zapcore.Level
+	message string
+}
+
+// rateCounter 单条消息在当前窗口内的计数
+type rateCounter struct {
+	start      time.Time
+	count      int
+	suppressed int
+	// 最近一次被抑制的日志及其所属 core，用于输出汇总
+	entry zapcore.Entry
+	core  zapcore.Core
+}
+
+// rateLimiter 按消息限流，同一个 Log 的所有 core 共用
+// 有日志被抑制时启动后台 goroutine，每个窗口结束后输出汇总，不必等同一条消息再次出现；
+// 所有计数都清理后 goroutine 退出
+type rateLimiter struct {
+	mu         sync.Mutex
+	interval   time.Duration
+	first      int
+	thereafter int
+	counters   map[rateKey]*rateCounter
+	now        func() time.Time
+
+	// ticking 后台 goroutine 是否在运行，closed 后不再启动
+	ticking bool
+	closed  bool
+	done    chan struct{}
+}
+
+func newRateLimiter(interval time.Duration, first, thereafter int) *rateLimiter {
+	return &rateLimiter{
+		interval:   interval,
+		first:      first,
+		thereafter: thereafter,
+		counters:   make(map[rateKey]*rateCounter),
+		now:        time.Now,
+		done:       make(chan struct{}),
+	}
+}
+
+// allow 判断 ent 是否输出；窗口切换时如有被抑制的日志，返回需要先输出的汇总
+func (r *rateLimiter) allow(ent zapcore.Entry, core zapcore.Core) (bool, *rateCounter) {
+	key := rateKey{name: ent.LoggerName, level: ent.Level, message: ent.Message}
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var summary *rateCounter
+	c, ok := r.counters[key]
+	if !ok || now.Sub(c.start) >= r.interval {
+		if ok && c.suppressed > 0 {
+			expired := *c
+			summary = &expired
+		}
+		if !ok && len(r.counters) >= rateLimitMaxKeys {
+			r.sweepLocked(now)
+		}
+		c = &rateCounter{start: now}
+		r.counters[key] = c
+	}
+
+	c.count++
+	if c.count <= r.first || (r.thereafter > 0 && (c.count-r.first)%r.thereafter == 0) {
+		return true, summary
+	}
+	c.suppressed++
+	c.entry = ent
+	c.core = core
+	if !r.ticking && !r.closed {
+		r.ticking = true
+		go r.run()
+	}
+	return false, summary
+}
+
+// run 每个窗口输出一次已结束窗口的汇总，没有待处理的计数或已关闭时退出
+func (r *rateLimiter) run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.flush(false)
+		case <-r.done:
+		}
+
+		r.mu.Lock()
+		idle := len(r.counters) == 0 || r.closed
+		if idle {
+			r.ticking = false
+		}
+		r.mu.Unlock()
+		if idle {
+			return
+		}
+	}
+}
+
+// close 停止后台 goroutine 并输出所有待输出的汇总，重复调用只输出汇总
+func (r *rateLimiter) close() {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.done)
+	}
+	r.mu.Unlock()
+	r.flush(true)
+}
+
+// sweepLocked 清理窗口已结束且没有待输出汇总的计数
+func (r *rateLimiter) sweepLocked(now time.Time) {
+	for k, c := range r.counters {
+		if now.Sub(c.start) >= r.interval && c.suppressed == 0 {
+			delete(r.counters, k)
+		}
+	}
+}
+
+// flush 输出所有已结束窗口的汇总；force 为 true 时不等窗口结束（用于关闭前）
+func (r *rateLimiter) flush(force bool) {
+	now := r.now()
+
+	r.mu.Lock()
+	var pending []rateCounter
+	for k, c := range r.counters {
+		if !force && now.Sub(c.start) < r.interval {
+			continue
+		}
+		if c.suppressed > 0 {
+			pending = append(pending, *c)
+		}
+		delete(r.counters, k)
+	}
+	r.mu.Unlock()
+
+	for i := range pending {
+		writeSuppressed(&pending[i], r.interval)
+	}
+}
+
+// writeSuppressed 以被抑制日志的级别输出汇总
+func writeSuppressed(c *rateCounter, interval time.Duration) {
+	ent := c.entry
+	ent.Message = fmt.Sprintf("suppressed %d messages: %s", c.suppressed, c.entry.Message)
+	ent.Time = time.Now()
+	ent.Stack = ""
+	if ce := c.core.Check(ent, nil); ce != nil {
+		ce.Write(
+			zapcore.Field{Key: "suppressed", Type: zapcore.Int64Type, Integer: int64(c.suppressed)},
+			zapcore.Field{Key: "window", Type: zapcore.DurationType, Integer: int64(interval)},
+		)
+	}
+}
+
+// rateLimitCore 对同一条消息限流的 core，dpanic 及以上级别不限流
+type rateLimitCore struct {
+	zapcore.Core
+	limiter *rateLimiter
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{Core: c.Core.With(fields), limiter: c.limiter}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) {
+		return ce
+	}
+	if ent.Level >= zapcore.DPanicLevel {
+		return c.Core.Check(ent, ce)
+	}
+	allowed, summary := c.limiter.allow(ent, c.Core)
+	if summary != nil {
+		writeSuppressed(summary, c.limiter.interval)
+	}
+	if !allowed {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+func (c *rateLimitCore) Sync() error {
+	c.limiter.flush(false)
+	return c.Core.Sync()
+}
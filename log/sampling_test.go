@@ -0,0 +1,97 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newRateLimitedLogger 返回按消息限流、输出到内存的 logger：每个窗口只输出第一条
+func newRateLimitedLogger(interval time.Duration) (*zap.Logger, *rateLimiter, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	opts := samplingOptions{rateLimit: true, rateLimitInterval: interval, rateLimitFirst: 1}
+	limiter := newSamplingLimiter(opts)
+	return zap.New(wrapSampling(core, opts, limiter)), limiter, logs
+}
+
+// waitLogs 等待 logs 中至少有 n 条日志
+func waitLogs(t *testing.T, logs *observer.ObservedLogs, n int) []observer.LoggedEntry {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); logs.Len() < n; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("want %d logs, got %d: %v", n, logs.Len(), logs.All())
+		}
+	}
+	return logs.All()
+}
+
+// waitStopped 等待限流器的后台 goroutine 退出
+func waitStopped(t *testing.T, r *rateLimiter) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		r.mu.Lock()
+		ticking := r.ticking
+		r.mu.Unlock()
+		if !ticking {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background flush did not stop")
+		}
+	}
+}
+
+func TestRateLimiter_PeriodicSummary(t *testing.T) {
+	logger, limiter, logs := newRateLimitedLogger(100 * time.Millisecond)
+	defer limiter.close()
+
+	for i := 0; i < 5; i++ {
+		logger.Warn("db timeout", zap.Int("i", i))
+	}
+	logger.Info("other")
+
+	// 不再有同样的日志，也不调用 Sync，窗口结束后自动输出汇总
+	entries := waitLogs(t, logs, 3)
+	summary := entries[2]
+	if summary.Message != "suppressed 4 messages: db timeout" || summary.Level != zapcore.WarnLevel {
+		t.Fatalf("summary: %v %q", summary.Level, summary.Message)
+	}
+	fields := summary.ContextMap()
+	if fields["suppressed"] != int64(4) || fields["window"] != 100*time.Millisecond {
+		t.Fatalf("summary fields: %v", fields)
+	}
+
+	// 计数清理后后台 goroutine 退出
+	waitStopped(t, limiter)
+	if logs.Len() != 3 {
+		t.Fatalf("unexpected logs: %v", logs.All())
+	}
+}
+
+func TestRateLimiter_SummaryOnClose(t *testing.T) {
+	logger, limiter, logs := newRateLimitedLogger(time.Hour)
+	for i := 0; i < 3; i++ {
+		logger.Error("queue full")
+	}
+	// 未启用限流的级别不受影响
+	logger.DPanic("critical")
+	logger.DPanic("critical")
+	if logs.Len() != 3 {
+		t.Fatalf("want 3 logs before Close, got %v", logs.All())
+	}
+
+	limiter.close()
+	limiter.close()
+	entries := logs.All()
+	if len(entries) != 4 || entries[3].Message != "suppressed 2 messages: queue full" {
+		t.Fatalf("logs after close: %v", entries)
+	}
+
+	// 关闭后后台 goroutine 退出，且不再启动
+	logger.Error("queue full")
+	logger.Error("queue full")
+	waitStopped(t, limiter)
+}
@@ -0,0 +1,132 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// consoleWriter 包装标准输出，标准输出无缓冲，Sync 为空操作
+// （对终端或管道执行 fsync 会返回 EINVAL，不应作为错误上报）
+type consoleWriter struct {
+	io.Writer
+}
+
+func (consoleWriter) Sync() error {
+	return nil
+}
+
+// Sync 将所有 writer 中缓冲的日志写出，并刷新到磁盘（包括子 logger 的独立文件）
+func (l *Log) Sync() error {
+	if l.root != nil {
+		return l.root.Sync()
+	}
+	l.mu.Lock()
+	writers := l.writers
+	limiter := l.limiter
+	l.mu.Unlock()
+
+	// 先输出已结束窗口的限流汇总
+	if limiter != nil {
+		limiter.flush(false)
+	}
+	var errs []error
+	for _, w := range writers {
+		if err := w.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 刷新并关闭 InitLog 创建的所有异步 writer 和日志文件
+// 超过 ctx 的截止时间仍未完成时返回 ctx.Err()，剩余的关闭操作继续在后台执行
+// Close 之后不应再写日志，多次调用只有第一次生效；子 logger 调用时关闭所属的主 logger
+func (l *Log) Close(ctx context.Context) error {
+	if l.root != nil {
+		return l.root.Close(ctx)
+	}
+	l.mu.Lock()
+	closers := l.closers
+	limiter := l.limiter
+	l.closers = nil
+	l.writers = nil
+	l.mu.Unlock()
+
+	// 关闭前输出所有待输出的限流汇总
+	if limiter != nil {
+		limiter.close()
+	}
+
+	if len(closers) == 0 {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		var errs []error
+		for _, c := range closers {
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		done <- errors.Join(errs...)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CloseOnSignal 收到指定信号时在 timeout 内关闭日志，然后调用 onShutdown
+// sigs 为空时监听 SIGINT 和 SIGTERM；onShutdown 为 nil 时以状态码 0 退出进程
+// 返回停止监听的函数
+//
+// 使用示例:
+//
+//	stop := logger.CloseOnSignal(5*time.Second, nil)
+//	defer stop()
+func (l *Log) CloseOnSignal(timeout time.Duration, onShutdown func(os.Signal), sigs ...os.Signal) func() {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	if onShutdown == nil {
+		onShutdown = func(os.Signal) { os.Exit(0) }
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-ch:
+			signal.Stop(ch)
+			if l.logger != nil {
+				l.logger.Infof("received signal %s, closing log", sig)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			if err := l.Close(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "log: failed to close: %v\n", err)
+			}
+			cancel()
+			onShutdown(sig)
+		case <-done:
+			signal.Stop(ch)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLog_CloseFlushesAsyncWriters(t *testing.T) {
+	dir := t.TempDir()
+	// 刷新间隔足够长，日志只会在 Close 时写出
+	l := newFileLog(t, dir, map[string]string{
+		"level": "info", "fileMode": "single", "async": "true", "asyncFlushInterval": "1h",
+	})
+	for i := 0; i < 100; i++ {
+		l.GetLog().Infof("message %d", i)
+	}
+
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	out := readFile(t, filepath.Join(dir, "app.log"))
+	if n := strings.Count(out, "message "); n != 100 {
+		t.Fatalf("%d of 100 messages written after Close", n)
+	}
+	if s := l.AsyncStats(); s != (AsyncStats{}) {
+		t.Fatalf("writers should be released after Close, got %v", s)
+	}
+
+	// 重复关闭不再执行任何操作
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.Close(ctx); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if err := l.Sync(); err != nil {
+		t.Fatalf("Sync after Close: %v", err)
+	}
+}
+
+func TestLog_CloseTimeout(t *testing.T) {
+	l := &Log{}
+	release := make(chan struct{})
+	l.closers = append(l.closers, closerFunc(func() error {
+		<-release
+		return nil
+	}))
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Close(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Close = %v, want DeadlineExceeded", err)
+	}
+}
+
+// closerFunc 将函数适配为 io.Closer
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
@@ -0,0 +1,145 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 远程日志输出类型
+const (
+	sinkSyslog = "syslog"
+	sinkNDJSON = "ndjson"
+	sinkHTTP   = "http"
+)
+
+// entryWriter 远程日志输出，写入时可以拿到日志条目（如 syslog 需要按级别计算优先级）
+type entryWriter interface {
+	WriteEntry(ent zapcore.Entry, line []byte) error
+	Sync() error
+	Close() error
+}
+
+// sinkCore 将编码后的日志交给 entryWriter 的 core
+type sinkCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	out entryWriter
+}
+
+func newSinkCore(enc zapcore.Encoder, out entryWriter, enab zapcore.LevelEnabler) zapcore.Core {
+	return &sinkCore{LevelEnabler: enab, enc: enc, out: out}
+}
+
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	return &sinkCore{LevelEnabler: c.LevelEnabler, enc: enc, out: c.out}
+}
+
+func (c *sinkCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sinkCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	err = c.out.WriteEntry(ent, buf.Bytes())
+	buf.Free()
+	return err
+}
+
+func (c *sinkCore) Sync() error {
+	return c.out.Sync()
+}
+
+// buildSinkOutputs 根据 Sinks 配置创建远程日志输出，返回输出和对应的 writer（供 Sync / Close 使用）
+// 支持 syslog（RFC5424）、ndjson（TCP / UDP 每行一个 JSON）和 http（按批 POST，application/x-ndjson），
+// 各自的配置见 SyslogSinkConfig / NDJSONSinkConfig / HTTPSinkConfig
+func buildSinkOutputs(config *LogConfig, logFileName, format string,
+	encoderConfig zapcore.EncoderConfig) ([]outputFactory, []entryWriter) {
+	if len(config.Sinks) == 0 {
+		return nil, nil
+	}
+
+	timeout := config.SinkTimeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	jsonEncoder := buildEncoder(formatJSON, encoderConfig, false)
+
+	var outputs []outputFactory
+	var writers []entryWriter
+	for _, name := range config.Sinks {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		var enc zapcore.Encoder
+		var out entryWriter
+		var minLevel *zapcore.Level
+		var err error
+		switch name {
+		case sinkSyslog:
+			tag := config.Syslog.Tag
+			if tag == "" {
+				tag = logFileName
+			}
+			enc = buildEncoder(parseFormat(config.Syslog.Format, format), encoderConfig, false)
+			out, err = newSyslogWriter(config.Syslog.Network, config.Syslog.Addr, tag, config.Syslog.Facility, timeout)
+			minLevel = config.Syslog.Level
+		case sinkNDJSON:
+			enc = jsonEncoder.Clone()
+			out, err = newNDJSONWriter(config.NDJSON.Network, config.NDJSON.Addr, timeout)
+			minLevel = config.NDJSON.Level
+		case sinkHTTP:
+			enc = jsonEncoder.Clone()
+			out, err = newHTTPWriter(config.HTTP.URL, httpSinkOptionsFromConfig(config.HTTP))
+			minLevel = config.HTTP.Level
+		default:
+			err = fmt.Errorf("unknown sink type: %s", name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "log: failed to create %s sink: %v\n", name, err)
+			continue
+		}
+
+		outputs = append(outputs, newSinkOutput(enc, out, minLevel))
+		writers = append(writers, out)
+	}
+	return outputs, writers
+}
+
+// newSinkOutput 创建写入 out 的输出，minLevel 不为空时只输出该级别及以上的日志
+func newSinkOutput(enc zapcore.Encoder, out entryWriter, minLevel *zapcore.Level) outputFactory {
+	return func(level zapcore.LevelEnabler) zapcore.Core {
+		enab := level
+		if minLevel != nil {
+			min := *minLevel
+			enab = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+				return lvl >= min && level.Enabled(lvl)
+			})
+		}
+		return newSinkCore(enc.Clone(), out, enab)
+	}
+}
+
+// trimNewline 去掉编码器追加的行尾换行
+func trimNewline(line []byte) []byte {
+	for len(line) > 0 && (line[len(line)-1] == '\n' || line[len(line)-1] == '\r') {
+		line = line[:len(line)-1]
+	}
+	return line
+}
@@ -0,0 +1,306 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 落盘批次文件的扩展名
+const httpSpoolExt = ".ndjson"
+
+// httpSinkOptions HTTP 批量发送选项
+type httpSinkOptions struct {
+	// 缓冲区可容纳的日志条数，满时丢弃新日志
+	bufferSize int
+	// 每批最多条数
+	batchSize int
+	// 未攒满一批时的最长等待时间
+	flushInterval time.Duration
+	// 单次请求超时
+	timeout time.Duration
+	// 失败重试次数
+	maxRetries int
+	// 首次重试的等待时间，之后每次翻倍，最长 retryMaxWait
+	retryWait    time.Duration
+	retryMaxWait time.Duration
+	// 发送失败时落盘的目录，为空时不落盘
+	spoolDir string
+	// 落盘总大小上限
+	spoolMaxSize int64
+}
+
+// httpSinkOptionsFromConfig 从日志配置中取出 HTTP 输出选项，无效值使用默认值
+func httpSinkOptionsFromConfig(config HTTPSinkConfig) httpSinkOptions {
+	opts := httpSinkOptions{
+		bufferSize:    10000,
+		batchSize:     config.BatchSize,
+		flushInterval: config.FlushInterval,
+		timeout:       config.Timeout,
+		maxRetries:    config.MaxRetries,
+		retryWait:     200 * time.Millisecond,
+		retryMaxWait:  5 * time.Second,
+		spoolDir:      config.SpoolDir,
+		spoolMaxSize:  config.SpoolMaxSize,
+	}
+	if opts.batchSize <= 0 {
+		opts.batchSize = 100
+	}
+	if opts.flushInterval <= 0 {
+		opts.flushInterval = time.Second
+	}
+	if opts.timeout <= 0 {
+		opts.timeout = 5 * time.Second
+	}
+	if opts.maxRetries < 0 {
+		opts.maxRetries = 0
+	}
+	if opts.spoolMaxSize <= 0 {
+		opts.spoolMaxSize = 100 << 20
+	}
+	return opts
+}
+
+// httpWriter 按批将日志以 NDJSON 格式 POST 到 HTTP 接口
+// 发送失败时按指数退避重试，仍失败则写入落盘目录，之后发送成功时按顺序补发
+type httpWriter struct {
+	url    string
+	opts   httpSinkOptions
+	client *http.Client
+
+	ch      chan []byte
+	flushCh chan chan error
+	done    chan struct{}
+
+	// mu 保护 closed，保证关闭 ch 时没有并发的发送
+	mu     sync.RWMutex
+	closed bool
+
+	dropped atomic.Uint64
+}
+
+func newHTTPWriter(url string, opts httpSinkOptions) (*httpWriter, error) {
+	if url == "" {
+		return nil, errors.New("httpURL is required")
+	}
+	if opts.spoolDir != "" {
+		if err := os.MkdirAll(opts.spoolDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create spool directory: %w", err)
+		}
+	}
+	w := &httpWriter{
+		url:     url,
+		opts:    opts,
+		client:  &http.Client{Timeout: opts.timeout},
+		ch:      make(chan []byte, opts.bufferSize),
+		flushCh: make(chan chan error),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// WriteEntry 将日志放入缓冲区，缓冲区满或已关闭时丢弃
+func (w *httpWriter) WriteEntry(ent zapcore.Entry, line []byte) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.dropped.Add(1)
+		return nil
+	}
+
+	cp := make([]byte, 0, len(line)+1)
+	cp = append(append(cp, trimNewline(line)...), '\n')
+	select {
+	case w.ch <- cp:
+	default:
+		w.dropped.Add(1)
+	}
+	return nil
+}
+
+// Sync 立即发送缓冲区中的日志
+func (w *httpWriter) Sync() error {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return nil
+	}
+	ack := make(chan error, 1)
+	w.flushCh <- ack
+	w.mu.RUnlock()
+	return <-ack
+}
+
+// Close 发送剩余的日志后停止
+func (w *httpWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.ch)
+	w.mu.Unlock()
+
+	<-w.done
+	return nil
+}
+
+func (w *httpWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.flushInterval)
+	defer ticker.Stop()
+
+	var batch bytes.Buffer
+	count := 0
+	flush := func() error {
+		if count == 0 {
+			w.replaySpool()
+			return nil
+		}
+		body := append([]byte(nil), batch.Bytes()...)
+		batch.Reset()
+		count = 0
+		return w.deliver(body)
+	}
+
+	for {
+		select {
+		case line, ok := <-w.ch:
+			if !ok {
+				_ = flush()
+				return
+			}
+			batch.Write(line)
+			count++
+			if count >= w.opts.batchSize {
+				_ = flush()
+			}
+		case <-ticker.C:
+			_ = flush()
+		case ack := <-w.flushCh:
+			for n := len(w.ch); n > 0; n-- {
+				batch.Write(<-w.ch)
+				count++
+			}
+			ack <- flush()
+		}
+	}
+}
+
+// deliver 发送一批日志，失败时重试，仍失败则落盘；成功后补发落盘的批次
+func (w *httpWriter) deliver(body []byte) error {
+	wait := w.opts.retryWait
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		if retry, err = w.post(body); err == nil {
+			w.replaySpool()
+			return nil
+		}
+		if !retry || attempt >= w.opts.maxRetries {
+			break
+		}
+		time.Sleep(wait)
+		if wait *= 2; wait > w.opts.retryMaxWait {
+			wait = w.opts.retryMaxWait
+		}
+	}
+
+	if w.opts.spoolDir != "" {
+		if serr := w.spool(body); serr != nil {
+			fmt.Fprintf(os.Stderr, "log: failed to spool http batch: %v\n", serr)
+		}
+	}
+	return err
+}
+
+// post 发送一次请求，返回失败时是否值得重试（网络错误、429 和 5xx）
+func (w *httpWriter) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("log sink %s returned %s", w.url, resp.Status)
+}
+
+// spool 将一批日志写入落盘目录，并按总大小上限删除最旧的批次
+func (w *httpWriter) spool(body []byte) error {
+	name := filepath.Join(w.opts.spoolDir, fmt.Sprintf("batch-%020d%s", time.Now().UnixNano(), httpSpoolExt))
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, body, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	files := w.spoolFiles()
+	var total int64
+	sizes := make([]int64, len(files))
+	for i, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+	for i := 0; i < len(files) && total > w.opts.spoolMaxSize; i++ {
+		if os.Remove(files[i]) == nil {
+			total -= sizes[i]
+		}
+	}
+	return nil
+}
+
+// spoolFiles 返回落盘目录中的批次文件，按写入先后排序
+func (w *httpWriter) spoolFiles() []string {
+	if w.opts.spoolDir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(w.opts.spoolDir, "batch-*"+httpSpoolExt))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(files)
+	return files
+}
+
+// replaySpool 按顺序补发落盘的批次，遇到失败即停止（不重试，等下次发送成功后再补发）
+func (w *httpWriter) replaySpool() {
+	for _, f := range w.spoolFiles() {
+		body, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		if retry, err := w.post(body); err != nil && retry {
+			return
+		}
+		// 成功或不可重试的失败（如 4xx）都删除，避免反复发送
+		_ = os.Remove(f)
+	}
+}
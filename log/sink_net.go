@@ -0,0 +1,220 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 重连等待时间：首次失败后等待 sinkRedialInterval，之后每次翻倍，最长 sinkMaxRedialInterval
+const (
+	sinkRedialInterval    = time.Second
+	sinkMaxRedialInterval = 30 * time.Second
+)
+
+// 缓冲区可容纳的日志条数，满时丢弃新日志
+const sinkQueueSize = 1000
+
+// netConn 懒连接的网络连接，日志放入缓冲区后由后台 goroutine 连接并写出，不阻塞写日志的调用方
+// 连接失败时按退避间隔重连，期间的日志在缓冲区中等待；写入失败时断开连接，该条日志丢弃
+// 失败和丢弃只计数，不逐条输出到 zap 的 ErrorOutput
+type netConn struct {
+	timeout   time.Duration
+	dialFuncs []func() (net.Conn, error)
+
+	ch   chan func(stream bool) []byte
+	stop chan struct{}
+	done chan struct{}
+
+	// mu 保护 closed，保证关闭 ch 时没有并发的发送
+	mu     sync.RWMutex
+	closed bool
+
+	// 因缓冲区满、写入失败或已关闭被丢弃的日志条数
+	dropped atomic.Uint64
+	// 写入失败的次数
+	writeErrors atomic.Uint64
+	// 连接失败的次数
+	dialErrors atomic.Uint64
+}
+
+func newNetConn(network, addr string, timeout time.Duration) *netConn {
+	c := &netConn{
+		timeout: timeout,
+		ch:      make(chan func(stream bool) []byte, sinkQueueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	dial := func(network string) func() (net.Conn, error) {
+		return func() (net.Conn, error) {
+			return net.DialTimeout(network, addr, timeout)
+		}
+	}
+	if network == "unix" {
+		// 与标准库 log/syslog 一致，本地 syslog 通常是 unixgram
+		c.dialFuncs = []func() (net.Conn, error){dial("unixgram"), dial("unix")}
+	} else {
+		c.dialFuncs = []func() (net.Conn, error){dial(network)}
+	}
+	go c.run()
+	return c
+}
+
+// isStream 返回连接是否为流式连接（需要分帧）
+func isStream(conn net.Conn) bool {
+	switch conn.LocalAddr().Network() {
+	case "udp", "udp4", "udp6", "unixgram":
+		return false
+	}
+	return true
+}
+
+// write 将一条日志放入缓冲区，build 在写出时根据是否流式连接生成数据（流式连接需要分帧）
+// 缓冲区满或已关闭时丢弃
+func (c *netConn) write(build func(stream bool) []byte) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		c.dropped.Add(1)
+		return
+	}
+	select {
+	case c.ch <- build:
+	default:
+		c.dropped.Add(1)
+	}
+}
+
+// run 按顺序写出缓冲区中的日志，没有连接时先连接，失败时按退避间隔重试
+// 关闭后继续写出剩余的日志，连接或写入失败时不再重试，丢弃剩余的日志
+func (c *netConn) run() {
+	defer close(c.done)
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	wait := sinkRedialInterval
+	for build := range c.ch {
+		for conn == nil {
+			var err error
+			if conn, err = c.dial(); err == nil {
+				wait = sinkRedialInterval
+				break
+			}
+			c.dialErrors.Add(1)
+			select {
+			case <-c.stop:
+				c.dropped.Add(1)
+				c.dropRemaining()
+				return
+			case <-time.After(wait):
+				if wait *= 2; wait > sinkMaxRedialInterval {
+					wait = sinkMaxRedialInterval
+				}
+			}
+		}
+
+		if c.timeout > 0 {
+			_ = conn.SetWriteDeadline(time.Now().Add(c.timeout))
+		}
+		if _, err := conn.Write(build(isStream(conn))); err != nil {
+			c.writeErrors.Add(1)
+			c.dropped.Add(1)
+			_ = conn.Close()
+			conn = nil
+			if c.stopped() {
+				c.dropRemaining()
+				return
+			}
+		}
+	}
+}
+
+// stopped 返回是否已调用 Close
+func (c *netConn) stopped() bool {
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// dropRemaining 关闭后连接不可用时丢弃缓冲区中剩余的日志
+func (c *netConn) dropRemaining() {
+	for range c.ch {
+		c.dropped.Add(1)
+	}
+}
+
+func (c *netConn) dial() (conn net.Conn, err error) {
+	for _, dial := range c.dialFuncs {
+		if conn, err = dial(); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// Close 停止接收新日志，写出缓冲区中剩余的日志后关闭连接
+// 连接不可用时剩余的日志计入丢弃，写入受 timeout 限制，不会无限等待
+func (c *netConn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.stop)
+	close(c.ch)
+	c.mu.Unlock()
+
+	<-c.done
+	return nil
+}
+
+// ndjsonWriter 通过 TCP / UDP 发送每行一个 JSON 的日志
+type ndjsonWriter struct {
+	conn *netConn
+}
+
+func newNDJSONWriter(network, addr string, timeout time.Duration) (*ndjsonWriter, error) {
+	network = strings.ToLower(strings.TrimSpace(network))
+	if network == "" {
+		network = "tcp"
+	}
+	if network != "tcp" && network != "udp" {
+		return nil, fmt.Errorf("unsupported ndjson network: %s", network)
+	}
+	if addr == "" {
+		return nil, errors.New("ndjsonAddr is required")
+	}
+	return &ndjsonWriter{conn: newNetConn(network, addr, timeout)}, nil
+}
+
+func (w *ndjsonWriter) WriteEntry(ent zapcore.Entry, line []byte) error {
+	// zap 会复用 line 的缓冲区，暂存前先复制
+	data := append(append([]byte(nil), trimNewline(line)...), '\n')
+	w.conn.write(func(bool) []byte {
+		return data
+	})
+	return nil
+}
+
+func (w *ndjsonWriter) Sync() error {
+	return nil
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.conn.Close()
+}
@@ -0,0 +1,371 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newSinkLogger 按配置创建只输出到远程 sink 的 logger
+func newSinkLogger(t *testing.T, logConfig map[string]string) (*zap.Logger, []entryWriter) {
+	t.Helper()
+	config, err := LogConfigFromMap(logConfig)
+	if err != nil {
+		t.Fatalf("LogConfigFromMap: %v", err)
+	}
+	outputs, writers := buildSinkOutputs(config, "app", formatJSON, buildEncoderConfig(config))
+	if len(outputs) == 0 {
+		t.Fatalf("no sink created for %v", logConfig)
+	}
+	t.Cleanup(func() {
+		for _, w := range writers {
+			_ = w.Close()
+		}
+	})
+	return zap.New(buildTee(zapcore.DebugLevel, outputs)), writers
+}
+
+func TestSyslogSink_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	logger, _ := newSinkLogger(t, map[string]string{
+		"sinks": "syslog", "syslogAddr": pc.LocalAddr().String(), "syslogTag": "order-svc",
+	})
+	logger.Warn("shard down", zap.Int("db", 3))
+
+	buf := make([]byte, 4096)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	// local0(16)*8 + warning(4) = 132
+	if !strings.HasPrefix(msg, "<132>1 ") {
+		t.Fatalf("unexpected header: %q", msg)
+	}
+	fields := strings.SplitN(msg, " ", 8)
+	if len(fields) != 8 || fields[3] != "order-svc" || fields[4] != strconv.Itoa(os.Getpid()) || fields[6] != "-" {
+		t.Fatalf("unexpected header fields: %q", msg)
+	}
+	if !strings.Contains(fields[7], `"msg":"shard down"`) || !strings.Contains(fields[7], `"db":3`) {
+		t.Fatalf("unexpected body: %q", fields[7])
+	}
+}
+
+func TestSyslogSink_TCPOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	logger, _ := newSinkLogger(t, map[string]string{
+		"sinks": "syslog", "syslogNetwork": "tcp", "syslogAddr": ln.Addr().String(), "syslogFacility": "user",
+	})
+	logger.Error("first")
+	logger.Info("second")
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	r := bufio.NewReader(conn)
+
+	for _, want := range []string{"<11>1 ", "<14>1 "} { // user(1)*8 + error(3) / info(6)
+		lenStr, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(lenStr))
+		if err != nil {
+			t.Fatalf("bad frame length %q", lenStr)
+		}
+		frame := make([]byte, size)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(frame), want) {
+			t.Fatalf("frame %q: want prefix %q", frame, want)
+		}
+	}
+}
+
+func TestNDJSONSink_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	logger, _ := newSinkLogger(t, map[string]string{"sinks": "ndjson", "ndjsonAddr": ln.Addr().String()})
+	logger.Info("one", zap.String("k", "v"))
+	logger.Info("two")
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	scanner := bufio.NewScanner(conn)
+
+	for _, want := range []string{"one", "two"} {
+		if !scanner.Scan() {
+			t.Fatalf("read line: %v", scanner.Err())
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid json %q: %v", scanner.Text(), err)
+		}
+		if record["msg"] != want {
+			t.Fatalf("msg = %v, want %s", record["msg"], want)
+		}
+	}
+}
+
+func TestHTTPSink_RetryAndSpool(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+		failures atomic.Int32
+		down     atomic.Bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		// 第一次请求失败，验证重试
+		if failures.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &record); err == nil {
+				received = append(received, record["msg"].(string))
+			}
+		}
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	spoolDir := t.TempDir()
+	config := NewLogConfig()
+	config.Sinks = []string{"http"}
+	config.HTTP.URL = server.URL
+	config.HTTP.BatchSize = 2
+	config.HTTP.FlushInterval = time.Hour
+	config.HTTP.MaxRetries = 1
+	config.HTTP.SpoolDir = spoolDir
+	outputs, writers := buildSinkOutputs(config, "app", formatJSON, buildEncoderConfig(config))
+	hw := writers[0].(*httpWriter)
+	hw.opts.retryWait = time.Millisecond
+	logger := zap.New(outputs[0](zapcore.DebugLevel))
+
+	logger.Info("a")
+	logger.Info("b")
+	if err := hw.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	// 接收端不可用：重试失败后落盘
+	down.Store(true)
+	logger.Info("c")
+	if err := hw.Sync(); err == nil {
+		t.Fatal("Sync: want error while receiver is down")
+	}
+	if files, _ := filepath.Glob(filepath.Join(spoolDir, "*"+httpSpoolExt)); len(files) != 1 {
+		t.Fatalf("spool files = %v, want 1", files)
+	}
+
+	// 恢复后下一批发送成功，并补发落盘的批次
+	down.Store(false)
+	logger.Info("d")
+	if err := hw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(spoolDir, "*"+httpSpoolExt)); len(files) != 0 {
+		t.Fatalf("spool not replayed: %v", files)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(received, ","); got != "a,b,d,c" {
+		t.Fatalf("received %s, want a,b,d,c", got)
+	}
+}
+
+// newTestNetConn 创建使用 dial 连接的 netConn
+func newTestNetConn(timeout time.Duration, dial func() (net.Conn, error)) *netConn {
+	c := newNetConn("tcp", "", timeout)
+	// 后台 goroutine 收到第一条日志后才会连接，此时已完成替换
+	c.dialFuncs = []func() (net.Conn, error){dial}
+	return c
+}
+
+func netLine(s string) func(bool) []byte {
+	return func(bool) []byte { return []byte(s + "\n") }
+}
+
+func TestNetConn_BackgroundDial(t *testing.T) {
+	dials := make(chan net.Conn)
+	var dialCount atomic.Int32
+	c := newTestNetConn(time.Second, func() (net.Conn, error) {
+		dialCount.Add(1)
+		return <-dials, nil
+	})
+	// accept 返回新连接的对端，读到的每一行发送到 lines
+	lines := make(chan string, 10)
+	accept := func() net.Conn {
+		client, server := net.Pipe()
+		go func() {
+			scanner := bufio.NewScanner(server)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+		}()
+		dials <- client
+		return server
+	}
+	expect := func(want ...string) {
+		t.Helper()
+		for _, w := range want {
+			select {
+			case got := <-lines:
+				if got != w {
+					t.Fatalf("got %q, want %q", got, w)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("timeout waiting for %q", w)
+			}
+		}
+	}
+
+	// 连接阻塞时写入立即返回，日志在缓冲区中等待连接建立后按顺序写出
+	start := time.Now()
+	c.write(netLine("a"))
+	c.write(netLine("b"))
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("write blocked for %v while dialing", d)
+	}
+	server := accept()
+	expect("a", "b")
+	c.write(netLine("c"))
+	expect("c")
+
+	// 连接断开：写入失败的日志丢弃并计数，下一条日志触发重连
+	_ = server.Close()
+	c.write(netLine("lost"))
+	c.write(netLine("d"))
+	server = accept()
+	defer server.Close()
+	expect("d")
+
+	// 关闭时写出缓冲区中剩余的日志
+	c.write(netLine("e"))
+	c.write(netLine("f"))
+	go func() { _ = c.Close() }()
+	expect("e", "f")
+	<-c.done
+
+	if c.writeErrors.Load() != 1 || c.dropped.Load() != 1 || dialCount.Load() != 2 {
+		t.Fatalf("writeErrors=%d dropped=%d dials=%d",
+			c.writeErrors.Load(), c.dropped.Load(), dialCount.Load())
+	}
+	c.write(netLine("closed"))
+	if c.dropped.Load() != 2 {
+		t.Fatalf("write after Close: dropped=%d", c.dropped.Load())
+	}
+}
+
+func TestNetConn_StalledPeer(t *testing.T) {
+	// 对端从不读取，每次写入都会等到超时
+	c := newTestNetConn(50*time.Millisecond, func() (net.Conn, error) {
+		client, server := net.Pipe()
+		t.Cleanup(func() { _ = server.Close() })
+		return client, nil
+	})
+
+	const total = sinkQueueSize + 100
+	start := time.Now()
+	for i := 0; i < total; i++ {
+		c.write(netLine("x"))
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("writes blocked for %v on a stalled peer", d)
+	}
+	if c.dropped.Load() < 99 {
+		t.Fatalf("dropped=%d, want entries beyond the queue dropped", c.dropped.Load())
+	}
+
+	for deadline := time.Now().Add(2 * time.Second); c.writeErrors.Load() == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("write to the stalled peer did not time out")
+		}
+	}
+
+	// 关闭最多等待一次写入超时，之后丢弃剩余的日志
+	start = time.Now()
+	_ = c.Close()
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("Close took %v", d)
+	}
+	if c.dropped.Load() != total || c.writeErrors.Load() == 0 {
+		t.Fatalf("dropped=%d writeErrors=%d, want all %d dropped", c.dropped.Load(), c.writeErrors.Load(), total)
+	}
+}
+
+func TestNetConn_DialError(t *testing.T) {
+	c := newTestNetConn(0, func() (net.Conn, error) {
+		return nil, io.ErrClosedPipe
+	})
+
+	const total = sinkQueueSize + 10
+	for i := 0; i < total; i++ {
+		c.write(netLine("x"))
+	}
+	for deadline := time.Now().Add(2 * time.Second); c.dialErrors.Load() == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no dial attempt")
+		}
+	}
+	// 缓冲区满后的日志丢弃；关闭时在退避等待中立即返回，缓冲区中的日志计入丢弃
+	if d := c.dropped.Load(); d < 9 || d > 10 {
+		t.Fatalf("dropped=%d, want 9 or 10", d)
+	}
+	start := time.Now()
+	_ = c.Close()
+	if d := time.Since(start); d > sinkRedialInterval/2 {
+		t.Fatalf("Close waited %v for the redial backoff", d)
+	}
+	if c.dialErrors.Load() != 1 || c.dropped.Load() != total {
+		t.Fatalf("dialErrors=%d dropped=%d", c.dialErrors.Load(), c.dropped.Load())
+	}
+}
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookOptions Webhook 通知选项
+type WebhookOptions struct {
+	// 请求超时，默认 5s
+	Timeout time.Duration
+	// 额外的请求头，如鉴权 token
+	Headers map[string]string
+	// 自定义请求体，用于适配钉钉、飞书、Slack 等机器人的格式；为空时发送
+	//	{"count": 条目数, "entries": [HookEntry...]}
+	Body func(entries []HookEntry) ([]byte, error)
+	// 请求体的 Content-Type，默认 application/json
+	ContentType string
+	// 自定义 http.Client，为空时按 Timeout 创建
+	Client *http.Client
+}
+
+// webhookPayload 默认的请求体
+type webhookPayload struct {
+	Count   int         `json:"count"`
+	Entries []HookEntry `json:"entries"`
+}
+
+// NewWebhookNotifier 返回将条目 POST 到 url 的 Hook，非 2xx 响应视为失败
+//
+// 使用示例:
+//
+//	logger.AddHook(log.NewWebhookNotifier("https://alert.example.com/hook", &log.WebhookOptions{
+//		Headers: map[string]string{"Authorization": "Bearer " + token},
+//	}), nil)
+func NewWebhookNotifier(url string, opts *WebhookOptions) Hook {
+	var o WebhookOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	if o.ContentType == "" {
+		o.ContentType = "application/json"
+	}
+	client := o.Client
+	if client == nil {
+		client = &http.Client{Timeout: o.Timeout}
+	}
+
+	return func(entries []HookEntry) error {
+		var body []byte
+		var err error
+		if o.Body != nil {
+			body, err = o.Body(entries)
+		} else {
+			body, err = json.Marshal(webhookPayload{Count: len(entries), Entries: entries})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to build webhook body: %w", err)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", o.ContentType)
+		for k, v := range o.Headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s returned %s", url, resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package lz4
+
+// Raw block API. These functions read and write bare LZ4 blocks without the
+// 4-byte size header of Encode/Decode, as produced and consumed by
+// LZ4_compress_default/LZ4_decompress_safe in the C library and the block
+// compressors of other implementations. The uncompressed size is not stored
+// in a raw block, so the caller has to transmit it separately.
+
+// CompressBlock returns the raw LZ4 block for src. The returned array may be
+// a sub-slice of dst if it was large enough to hold the entire output.
+func CompressBlock(dst, src []byte) ([]byte, error) {
+	c := Compressor{accel: 1}
+	return c.compressRaw(dst, src, nil)
+}
+
+// CompressBlockHC is CompressBlock using the high compression encoder at level
+// (see EncodeHC).
+func CompressBlockHC(dst, src []byte, level int) ([]byte, error) {
+	if level <= 0 {
+		level = DefaultLevelHC
+	}
+	c := Compressor{level: max(level, MinLevelHC)}
+	return c.compressRaw(dst, src, nil)
+}
+
+// CompressBlockWithDict is CompressBlock with a dictionary: matches may refer
+// to the last 64KB of dict, which improves the ratio of small blocks sharing
+// content with it. The block must be decompressed with the same dictionary,
+// by UncompressBlockWithDict or LZ4_decompress_safe_usingDict.
+func CompressBlockWithDict(dst, src, dict []byte) ([]byte, error) {
+	c := Compressor{accel: 1}
+	return c.compressRaw(dst, src, dict)
+}
+
+// UncompressBlock decodes the raw LZ4 block src whose uncompressed size is
+// size. The returned array may be a sub-slice of dst if it was large enough
+// to hold the entire output. It returns ErrCorrupt if src is malformed or
+// does not decode to exactly size bytes.
+func UncompressBlock(dst, src []byte, size int) ([]byte, error) {
+	if size < 0 || size > MaxInputSize {
+		return nil, ErrTooLarge
+	}
+	if uint64(size) > uint64(len(src))*maxRatio {
+		return nil, ErrCorrupt
+	}
+	if cap(dst) < size {
+		dst = make([]byte, size)
+	}
+	dst = dst[:size]
+	n, err := decodeBlock(dst, 0, src)
+	if err != nil {
+		return nil, err
+	}
+	if n != size {
+		return nil, ErrCorrupt
+	}
+	return dst, nil
+}
+
+// UncompressBlockWithDict is UncompressBlock for blocks compressed with the
+// dictionary dict.
+func UncompressBlockWithDict(dst, src, dict []byte, size int) ([]byte, error) {
+	dict = dictWindow(dict)
+	if len(dict) == 0 {
+		return UncompressBlock(dst, src, size)
+	}
+	if size < 0 || size > MaxInputSize {
+		return nil, ErrTooLarge
+	}
+	if uint64(size) > uint64(len(src))*maxRatio {
+		return nil, ErrCorrupt
+	}
+	buf := make([]byte, len(dict)+size)
+	copy(buf, dict)
+	n, err := decodeBlock(buf, len(dict), src)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(buf) {
+		return nil, ErrCorrupt
+	}
+	if cap(dst) < size {
+		dst = make([]byte, size)
+	}
+	dst = dst[:size]
+	copy(dst, buf[len(dict):])
+	return dst, nil
+}
+
+// dictWindow returns the part of dict that matches can reach.
+func dictWindow(dict []byte) []byte {
+	if len(dict) > windowSize {
+		dict = dict[len(dict)-windowSize:]
+	}
+	return dict
+}
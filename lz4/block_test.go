@@ -0,0 +1,191 @@
+package lz4
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// The golden blocks in testdata were produced by the reference lz4 v1.9.4
+// command line tool and extracted from its single-block frames:
+//
+//	lz4 -B4 --no-frame-crc log.txt             -> log.txt.lz4
+//	lz4 -9 -B4 --no-frame-crc log.txt          -> log.txt.hc.lz4
+//	lz4 -B4 --no-frame-crc -D dict.txt log.txt -> log.txt.dict.lz4
+
+func readTestdata(t testing.TB, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestUncompressBlock_Golden(t *testing.T) {
+	want := readTestdata(t, "log.txt")
+	dict := readTestdata(t, "dict.txt")
+
+	for _, name := range []string{"log.txt.lz4", "log.txt.hc.lz4"} {
+		got, err := UncompressBlock(nil, readTestdata(t, name), len(want))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("%s: output mismatch", name)
+		}
+	}
+
+	block := readTestdata(t, "log.txt.dict.lz4")
+	got, err := UncompressBlockWithDict(nil, block, dict, len(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("dict: output mismatch")
+	}
+	if _, err := UncompressBlock(nil, block, len(want)); err == nil {
+		t.Fatal("dict block decoded without the dictionary")
+	}
+
+	// the size must match exactly
+	for _, size := range []int{len(want) - 1, len(want) + 1} {
+		if _, err := UncompressBlock(nil, readTestdata(t, "log.txt.lz4"), size); !errors.Is(err, ErrCorrupt) {
+			t.Fatalf("size %d: err = %v, want ErrCorrupt", size, err)
+		}
+	}
+}
+
+func TestCompressBlock(t *testing.T) {
+	data := readTestdata(t, "log.txt")
+	dict := readTestdata(t, "dict.txt")
+
+	plain, err := CompressBlock(nil, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc, err := CompressBlockHC(nil, data, MaxLevelHC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withDict, err := CompressBlockWithDict(nil, data, dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// no worse than the reference encoder at the same settings
+	for name, pair := range map[string][2]int{
+		"fast": {len(plain), len(readTestdata(t, "log.txt.lz4"))},
+		"hc":   {len(hc), len(readTestdata(t, "log.txt.hc.lz4"))},
+		"dict": {len(withDict), len(readTestdata(t, "log.txt.dict.lz4"))},
+	} {
+		if pair[0] > pair[1]*11/10 {
+			t.Errorf("%s: %d bytes, reference %d bytes", name, pair[0], pair[1])
+		}
+	}
+	if len(withDict) >= len(plain) {
+		t.Errorf("dictionary did not help: %d >= %d", len(withDict), len(plain))
+	}
+
+	for name, block := range map[string][]byte{"fast": plain, "hc": hc} {
+		got, err := UncompressBlock(make([]byte, 0, len(data)), block, len(data))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("%s: round trip: %v", name, err)
+		}
+	}
+	got, err := UncompressBlockWithDict(nil, withDict, dict, len(data))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("dict: round trip: %v", err)
+	}
+
+	// small inputs and a dictionary larger than the window
+	bigDict := append(bytes.Repeat([]byte{'x'}, 2*windowSize), dict...)
+	for _, src := range [][]byte{nil, []byte("a"), data[:12], data[:13], data[:100]} {
+		block, err := CompressBlockWithDict(nil, src, bigDict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := UncompressBlockWithDict(nil, block, bigDict, len(src))
+		if err != nil || !bytes.Equal(got, src) {
+			t.Fatalf("%d bytes: round trip: %v", len(src), err)
+		}
+	}
+}
+
+// TestCompressBlock_CLI checks that the reference decoder accepts our raw
+// blocks by wrapping them in a frame.
+func TestCompressBlock_CLI(t *testing.T) {
+	data := readTestdata(t, "log.txt")
+	block, err := CompressBlockHC(nil, data, DefaultLevelHC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frame bytes.Buffer
+	zw, _ := NewWriterWithOptions(&frame, &FrameOptions{BlockSize: BlockSize64K})
+	_ = zw.writeHeader()
+	frame.Write([]byte{byte(len(block)), byte(len(block) >> 8), 0, 0})
+	frame.Write(block)
+	frame.Write([]byte{0, 0, 0, 0})
+
+	bin, err := exec.LookPath("lz4")
+	if err != nil {
+		t.Skip("lz4 command not found")
+	}
+	cmd := exec.Command(bin, "-d", "-c")
+	cmd.Stdin = &frame
+	got, err := cmd.Output()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("lz4 -d: %v", err)
+	}
+}
+
+func FuzzUncompressBlock(f *testing.F) {
+	f.Add(readTestdata(f, "log.txt.lz4"), 23493)
+	f.Add(readTestdata(f, "log.txt.hc.lz4"), 23493)
+	f.Add([]byte{0x00}, 0)
+	f.Add([]byte{0x1f, 'a', 0x01, 0x00, 0xff, 0xff, 0x10, 'b'}, 300)
+	f.Add([]byte{0xf0, 0xff}, 10)
+	f.Fuzz(func(t *testing.T, block []byte, size int) {
+		if size < 0 || size > 1<<20 {
+			return
+		}
+		// must not panic, and a successful decode has exactly size bytes
+		got, err := UncompressBlock(nil, block, size)
+		if err == nil && len(got) != size {
+			t.Fatalf("decoded %d bytes, want %d", len(got), size)
+		}
+		_, _ = UncompressBlockWithDict(nil, block, []byte("dictionary"), size)
+	})
+}
+
+func FuzzCompressBlock(f *testing.F) {
+	f.Add(readTestdata(f, "log.txt"), []byte{})
+	f.Add([]byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), []byte("aaaa"))
+	f.Add([]byte{}, readTestdata(f, "dict.txt"))
+	f.Fuzz(func(t *testing.T, src, dict []byte) {
+		for name, compress := range map[string]func() ([]byte, error){
+			"fast": func() ([]byte, error) { return CompressBlock(nil, src) },
+			"hc":   func() ([]byte, error) { return CompressBlockHC(nil, src, MinLevelHC) },
+			"dict": func() ([]byte, error) { return CompressBlockWithDict(nil, src, dict) },
+		} {
+			block, err := compress()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(block) > CompressBound(len(src)) {
+				t.Fatalf("%s: %d bytes exceeds bound", name, len(block))
+			}
+			var got []byte
+			if name == "dict" {
+				got, err = UncompressBlockWithDict(nil, block, dict, len(src))
+			} else {
+				got, err = UncompressBlock(nil, block, len(src))
+			}
+			if err != nil || !bytes.Equal(got, src) {
+				t.Fatalf("%s: round trip: %v", name, err)
+			}
+		}
+	})
+}
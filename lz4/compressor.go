@@ -0,0 +1,136 @@
+package lz4
+
+import (
+	"encoding/binary"
+	"slices"
+	"sync"
+)
+
+// Encoder tables are large (512KB for the fast encoder, 256KB for HC), so
+// they are pooled and shared by all compression calls instead of being
+// allocated per call.
+var (
+	hashTablePool = sync.Pool{New: func() interface{} { return new(fastTable) }}
+	hcTablesPool  = sync.Pool{New: func() interface{} { return newHCTables() }}
+	// history + input for compression with a dictionary
+	dictBufPool = sync.Pool{New: func() interface{} { return new([]byte) }}
+)
+
+// larger dictionary buffers are left to the garbage collector
+const maxPooledDictBuf = windowSize + BlockSize4M
+
+// CompressorOptions configures a Compressor.
+type CompressorOptions struct {
+	// Level selects the high compression encoder when at least MinLevelHC
+	// (see EncodeHC); lower values use the fast encoder.
+	Level int
+	// Acceleration of the fast encoder (see EncodeFast).
+	Acceleration int
+}
+
+// NewCompressorOptions returns the default options: the fast encoder
+// without acceleration, as Encode.
+func NewCompressorOptions() *CompressorOptions {
+	return &CompressorOptions{Acceleration: 1}
+}
+
+// Compressor compresses blocks with pooled encoder tables and appends the
+// output to caller supplied buffers, so that compressing into a buffer with
+// enough capacity does not allocate. A Compressor is safe for concurrent use.
+type Compressor struct {
+	level int
+	accel int
+}
+
+// NewCompressor returns a Compressor with opts. A nil opts uses
+// NewCompressorOptions.
+func NewCompressor(opts *CompressorOptions) *Compressor {
+	if opts == nil {
+		opts = NewCompressorOptions()
+	}
+	return &Compressor{level: opts.Level, accel: opts.Acceleration}
+}
+
+// AppendBlock appends the raw LZ4 block for src to dst and returns the
+// extended buffer.
+func (c *Compressor) AppendBlock(dst, src []byte) ([]byte, error) {
+	return c.AppendBlockWithDict(dst, src, nil)
+}
+
+// AppendBlockWithDict appends the raw LZ4 block for src compressed with dict
+// (see CompressBlockWithDict) to dst and returns the extended buffer.
+func (c *Compressor) AppendBlockWithDict(dst, src, dict []byte) ([]byte, error) {
+	if len(src) >= MaxInputSize {
+		return nil, ErrTooLarge
+	}
+	return c.appendBlock(dst, src, dict), nil
+}
+
+// AppendEncode appends the encoded form of src, as returned by Encode, to
+// dst and returns the extended buffer.
+func (c *Compressor) AppendEncode(dst, src []byte, headSizeFirst bool) ([]byte, error) {
+	if len(src) >= MaxInputSize {
+		return nil, ErrTooLarge
+	}
+	if headSizeFirst {
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(src)))
+	}
+	dst = c.appendBlock(dst, src, nil)
+	if !headSizeFirst {
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(len(src)))
+	}
+	return dst, nil
+}
+
+// encode implements Encode: the output starts at dst[0] when dst is large
+// enough to hold the entire output.
+func (c *Compressor) encode(dst, src []byte, headSizeFirst bool) ([]byte, error) {
+	if n := CompressBound(len(src)); len(dst) < n {
+		dst = make([]byte, 0, n)
+	}
+	return c.AppendEncode(dst[:0], src, headSizeFirst)
+}
+
+// compressRaw implements the CompressBlock functions: the output starts at
+// dst[0] when dst is large enough to hold the entire output.
+func (c *Compressor) compressRaw(dst, src, dict []byte) ([]byte, error) {
+	if n := CompressBound(len(src)); len(dst) < n {
+		dst = make([]byte, 0, n)
+	}
+	return c.AppendBlockWithDict(dst[:0], src, dict)
+}
+
+func (c *Compressor) appendBlock(dst, src, dict []byte) []byte {
+	bound := CompressBound(len(src))
+	n := len(dst)
+	dst = slices.Grow(dst, bound)
+	out := dst[n : n+bound]
+
+	dict = dictWindow(dict)
+	if len(dict) == 0 {
+		return dst[:n+c.compress(out, src, 0)]
+	}
+
+	bufp := dictBufPool.Get().(*[]byte)
+	buf := append(append((*bufp)[:0], dict...), src...)
+	written := c.compress(out, buf, len(dict))
+	if cap(buf) <= maxPooledDictBuf {
+		*bufp = buf
+		dictBufPool.Put(bufp)
+	}
+	return dst[:n+written]
+}
+
+// compress writes the raw block for src[start:] to dst with pooled tables.
+func (c *Compressor) compress(dst, src []byte, start int) int {
+	if c.level >= MinLevelHC {
+		t := hcTablesPool.Get().(*hcTables)
+		n := compressBlockHC(dst, src, start, t, c.level)
+		hcTablesPool.Put(t)
+		return n
+	}
+	t := hashTablePool.Get().(*fastTable)
+	n := compressBlock(dst, src, start, t, c.accel)
+	hashTablePool.Put(t)
+	return n
+}
@@ -0,0 +1,184 @@
+package lz4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompressor_Append(t *testing.T) {
+	data := testData(100000)
+	dict := testData(2000)
+	prefix := []byte("prefix")
+
+	for _, opts := range []*CompressorOptions{nil, {Acceleration: 4}, {Level: DefaultLevelHC}} {
+		c := NewCompressor(opts)
+
+		out, err := c.AppendBlock(append([]byte{}, prefix...), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(out, prefix) {
+			t.Fatal("prefix overwritten")
+		}
+		got, err := UncompressBlock(nil, out[len(prefix):], len(data))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("%+v: block round trip: %v", opts, err)
+		}
+
+		out, err = c.AppendBlockWithDict(nil, data[:5000], dict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err = UncompressBlockWithDict(nil, out, dict, 5000)
+		if err != nil || !bytes.Equal(got, data[:5000]) {
+			t.Fatalf("%+v: dict round trip: %v", opts, err)
+		}
+
+		for _, headSizeFirst := range []bool{true, false} {
+			out, err = c.AppendEncode(append([]byte{}, prefix...), data, headSizeFirst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err = Decode(nil, out[len(prefix):], headSizeFirst)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Fatalf("%+v: encode round trip: %v", opts, err)
+			}
+		}
+	}
+
+	// the fast Compressor and Encode produce the same output
+	encoded, _ := Encode(nil, data, true)
+	appended, _ := NewCompressor(nil).AppendEncode(nil, data, true)
+	if !bytes.Equal(encoded, appended) {
+		t.Fatal("Compressor output differs from Encode")
+	}
+}
+
+func TestFastTable_Reuse(t *testing.T) {
+	data := testData(50000)
+	compress := func(table *fastTable, src []byte) []byte {
+		dst := make([]byte, CompressBound(len(src)))
+		return dst[:compressBlock(dst, src, 0, table, 1)]
+	}
+	want := compress(new(fastTable), data)
+
+	// stale entries of earlier blocks never produce matches, so a reused
+	// table gives the same output as a fresh one
+	table := new(fastTable)
+	for _, n := range []int{100000, 20, 50000} {
+		compress(table, testData(n))
+		if got := compress(table, data); !bytes.Equal(got, want) {
+			t.Fatalf("output after a %d byte block differs", n)
+		}
+	}
+	// the table is cleared before the offset wraps around
+	table.used = 1<<31 - 100
+	if got := compress(table, data); !bytes.Equal(got, want) || table.used != uint64(len(data))+windowSize {
+		t.Fatalf("output after clearing differs, used = %d", table.used)
+	}
+}
+
+func TestCompressor_Allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops objects under the race detector")
+	}
+	data := testData(4096)
+	dst := make([]byte, 0, CompressBound(len(data))+4)
+	for _, opts := range []*CompressorOptions{nil, {Level: MinLevelHC}} {
+		c := NewCompressor(opts)
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = c.AppendBlock(dst, data)
+			_, _ = c.AppendBlockWithDict(dst, data, data[:100])
+			_, _ = c.AppendEncode(dst, data, false)
+		})
+		if allocs != 0 {
+			t.Errorf("%+v: %v allocs per run, want 0", opts, allocs)
+		}
+	}
+}
+
+// The packet benchmarks compress small messages, where allocating and
+// clearing the hash table per call used to dominate.
+
+func benchmarkPacket(b *testing.B, compress func(dst, src []byte) []byte) {
+	data := testData(1024)
+	dst := make([]byte, 0, CompressBound(len(data))+4)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst = compress(dst[:0], data)
+	}
+}
+
+func BenchmarkEncode_Packet(b *testing.B) {
+	benchmarkPacket(b, func(dst, src []byte) []byte {
+		out, _ := Encode(dst[:cap(dst)], src, false)
+		return out
+	})
+}
+
+func BenchmarkCompressor_AppendEncodePacket(b *testing.B) {
+	c := NewCompressor(nil)
+	benchmarkPacket(b, func(dst, src []byte) []byte {
+		out, _ := c.AppendEncode(dst, src, false)
+		return out
+	})
+}
+
+func BenchmarkCompressor_AppendBlockPacket(b *testing.B) {
+	c := NewCompressor(nil)
+	benchmarkPacket(b, func(dst, src []byte) []byte {
+		out, _ := c.AppendBlock(dst, src)
+		return out
+	})
+}
+
+func BenchmarkCompressor_AppendBlockParallel(b *testing.B) {
+	c := NewCompressor(nil)
+	data := testData(1024)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		dst := make([]byte, 0, CompressBound(len(data)))
+		for pb.Next() {
+			dst, _ = c.AppendBlock(dst[:0], data)
+		}
+	})
+}
+
+func benchmarkLarge(b *testing.B, opts *CompressorOptions) {
+	c := NewCompressor(opts)
+	data := testData(1 << 20)
+	dst := make([]byte, 0, CompressBound(len(data)))
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst, _ = c.AppendBlock(dst[:0], data)
+	}
+}
+
+func BenchmarkCompressor_Fast(b *testing.B) {
+	benchmarkLarge(b, nil)
+}
+
+func BenchmarkCompressor_Accel8(b *testing.B) {
+	benchmarkLarge(b, &CompressorOptions{Acceleration: 8})
+}
+
+func BenchmarkCompressor_HC(b *testing.B) {
+	benchmarkLarge(b, &CompressorOptions{Level: DefaultLevelHC})
+}
+
+func BenchmarkUncompressBlock(b *testing.B) {
+	data := testData(1 << 20)
+	block, _ := CompressBlock(nil, data)
+	dst := make([]byte, len(data))
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = UncompressBlock(dst, block, len(data))
+	}
+}
@@ -0,0 +1,132 @@
+package lz4
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LZ4 frame format constants.
+// See https://github.com/lz4/lz4/blob/dev/doc/lz4_Frame_format.md
+const (
+	frameMagic         uint32 = 0x184D2204
+	skippableMagic     uint32 = 0x184D2A50
+	skippableMagicMask uint32 = 0xFFFFFFF0
+	legacyMagic        uint32 = 0x184C2102
+
+	frameVersion = 1
+
+	flagBlockIndependence = 1 << 5
+	flagBlockChecksum     = 1 << 4
+	flagContentSize       = 1 << 3
+	flagContentChecksum   = 1 << 2
+	flagDictID            = 1 << 0
+
+	// high bit of a block size marks an uncompressed block
+	blockUncompressed uint32 = 1 << 31
+
+	// maximum match distance, the history kept for linked blocks
+	windowSize = 64 << 10
+)
+
+// Block sizes supported by the frame format.
+const (
+	BlockSize64K  = 64 << 10
+	BlockSize256K = 256 << 10
+	BlockSize1M   = 1 << 20
+	BlockSize4M   = 4 << 20
+)
+
+var (
+	// ErrChecksum indicates a header, block or content checksum mismatch
+	ErrChecksum = errors.New("checksum mismatch")
+	// ErrUnsupported indicates a frame using features this package does not support
+	ErrUnsupported = errors.New("unsupported frame")
+)
+
+// FrameOptions configures the frame written by a Writer.
+type FrameOptions struct {
+	// BlockSize is the maximum uncompressed size of a block, one of
+	// BlockSize64K, BlockSize256K, BlockSize1M or BlockSize4M (default, as the lz4 CLI).
+	BlockSize int
+	// BlockChecksum appends an xxHash32 checksum to every block.
+	BlockChecksum bool
+	// ContentChecksum appends an xxHash32 checksum of the uncompressed content.
+	ContentChecksum bool
+	// ContentSize is the total uncompressed size stored in the frame header,
+	// 0 if unknown. Close fails if the written size differs.
+	ContentSize uint64
+	// Level selects the high compression encoder when at least MinLevelHC
+	// (see EncodeHC); lower values use the fast encoder.
+	Level int
+	// Acceleration of the fast encoder (see EncodeFast), ignored by the
+	// high compression encoder.
+	Acceleration int
+	// Concurrency is the number of blocks compressed in parallel by
+	// background goroutines. Blocks are still written in order. 0 or 1
+	// compresses in the goroutine calling Write.
+	Concurrency int
+}
+
+// ReaderOptions configures a Reader.
+type ReaderOptions struct {
+	// Concurrency is the number of blocks read ahead and decompressed in
+	// parallel by background goroutines. It only applies to frames with
+	// independent blocks; linked blocks are always decompressed in order.
+	// 0 or 1 decompresses in the goroutine calling Read.
+	Concurrency int
+}
+
+// NewReaderOptions returns the default reader options: blocks are
+// decompressed in the goroutine calling Read.
+func NewReaderOptions() *ReaderOptions {
+	return &ReaderOptions{Concurrency: 1}
+}
+
+// NewFrameOptions returns the default frame options: 4MB blocks and a
+// content checksum, matching the lz4 command line tool.
+func NewFrameOptions() *FrameOptions {
+	return &FrameOptions{
+		BlockSize:       BlockSize4M,
+		ContentChecksum: true,
+	}
+}
+
+// frameBlock is a block compressed or decompressed in the background.
+type frameBlock struct {
+	data []byte // uncompressed data
+	raw  []byte // block as stored in the frame
+	// stored uncompressed, and its checksum when the frame has block checksums
+	uncompressed bool
+	sum          uint32
+
+	err  error
+	done chan struct{}
+}
+
+// blockSizeID returns the frame descriptor code for a block size.
+func blockSizeID(size int) (byte, error) {
+	switch size {
+	case BlockSize64K:
+		return 4, nil
+	case BlockSize256K:
+		return 5, nil
+	case BlockSize1M:
+		return 6, nil
+	case BlockSize4M:
+		return 7, nil
+	}
+	return 0, fmt.Errorf("invalid block size %d", size)
+}
+
+// blockSizeFromID returns the block size for a frame descriptor code.
+func blockSizeFromID(id byte) (int, error) {
+	if id < 4 || id > 7 {
+		return 0, ErrCorrupt
+	}
+	return 1 << (8 + 2*uint(id)), nil
+}
+
+// headerChecksum returns the frame descriptor checksum byte.
+func headerChecksum(descriptor []byte) byte {
+	return byte(xxh32Checksum(descriptor) >> 8)
+}
@@ -0,0 +1,343 @@
+package lz4
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Reader decompresses data in the LZ4 frame format. It reads concatenated
+// frames as a single stream, skips skippable frames and verifies the header,
+// block and content checksums present in each frame.
+type Reader struct {
+	r    io.Reader
+	err  error
+	conc int
+
+	// current frame
+	inFrame     bool
+	flags       byte
+	blockSize   int
+	contentSize uint64
+	size        uint64
+	checksum    xxh32
+	// stored content checksum, read with the end mark
+	sum uint32
+
+	// decoded data not read yet
+	out []byte
+
+	block frameBlock
+	// decoded data; for linked blocks buf[:end] also keeps up to
+	// windowSize bytes of history the next block may refer to
+	buf []byte
+	end int
+
+	// blocks read ahead and decompressed in the background, in order
+	queue   []*frameBlock
+	free    []*frameBlock
+	cur     *frameBlock
+	ending  bool
+	readErr error
+
+	scratch [16]byte
+}
+
+// NewReader returns a Reader that decompresses the frames read from r.
+func NewReader(r io.Reader) *Reader {
+	return NewReaderWithOptions(r, nil)
+}
+
+// NewReaderWithOptions returns a Reader with opts that decompresses the
+// frames read from r. A nil opts uses NewReaderOptions.
+func NewReaderWithOptions(r io.Reader, opts *ReaderOptions) *Reader {
+	if opts == nil {
+		opts = NewReaderOptions()
+	}
+	zr := &Reader{conc: opts.Concurrency}
+	zr.Reset(r)
+	return zr
+}
+
+// Reset discards the Reader's state and makes it read from r, keeping the
+// buffers.
+func (zr *Reader) Reset(r io.Reader) {
+	zr.r = r
+	zr.err = nil
+	zr.inFrame = false
+	zr.out = nil
+	zr.end = 0
+	// blocks still decoding in the background are left to the garbage collector
+	zr.queue = nil
+	zr.cur = nil
+	zr.ending = false
+	zr.readErr = nil
+}
+
+// Read decompresses data into p.
+func (zr *Reader) Read(p []byte) (int, error) {
+	for len(zr.out) == 0 {
+		if zr.err != nil {
+			return 0, zr.err
+		}
+		switch {
+		case !zr.inFrame:
+			zr.err = zr.readHeader()
+		case zr.conc > 1 && zr.flags&flagBlockIndependence != 0:
+			zr.err = zr.nextBlock()
+		default:
+			zr.err = zr.readBlock()
+		}
+	}
+	n := copy(p, zr.out)
+	zr.out = zr.out[n:]
+	return n, nil
+}
+
+// readHeader reads the next frame header, skipping skippable frames.
+// It returns io.EOF when the input ends between frames.
+func (zr *Reader) readHeader() error {
+	for {
+		if _, err := io.ReadFull(zr.r, zr.scratch[:4]); err != nil {
+			return err
+		}
+		magic := binary.LittleEndian.Uint32(zr.scratch[:])
+		if magic&skippableMagicMask == skippableMagic {
+			if err := zr.readFull(zr.scratch[:4]); err != nil {
+				return err
+			}
+			n := int64(binary.LittleEndian.Uint32(zr.scratch[:]))
+			if _, err := io.CopyN(io.Discard, zr.r, n); err != nil {
+				return noEOF(err)
+			}
+			continue
+		}
+		switch magic {
+		case frameMagic:
+		case legacyMagic:
+			return ErrUnsupported
+		default:
+			return ErrCorrupt
+		}
+		break
+	}
+
+	descriptor := zr.scratch[:2]
+	if err := zr.readFull(descriptor); err != nil {
+		return err
+	}
+	flags, bd := descriptor[0], descriptor[1]
+	if flags>>6 != frameVersion || flags&0x02 != 0 || bd&0x8F != 0 {
+		return ErrCorrupt
+	}
+	if flags&flagDictID != 0 {
+		return ErrUnsupported
+	}
+	blockSize, err := blockSizeFromID(bd >> 4)
+	if err != nil {
+		return err
+	}
+
+	n := 2
+	if flags&flagContentSize != 0 {
+		n += 8
+	}
+	descriptor = zr.scratch[:n+1]
+	if err := zr.readFull(descriptor[2:]); err != nil {
+		return err
+	}
+	if headerChecksum(descriptor[:n]) != descriptor[n] {
+		return ErrChecksum
+	}
+	zr.contentSize = 0
+	if flags&flagContentSize != 0 {
+		zr.contentSize = binary.LittleEndian.Uint64(descriptor[2:])
+	}
+
+	zr.flags = flags
+	zr.blockSize = blockSize
+	zr.size = 0
+	zr.checksum.reset(0)
+	zr.end = 0
+	zr.inFrame = true
+	return nil
+}
+
+// readBlock reads and decodes the next block of the current frame, or the
+// end mark and content checksum.
+func (zr *Reader) readBlock() error {
+	b := &zr.block
+	end, err := zr.readRawBlock(b)
+	if err != nil {
+		return err
+	}
+	if end {
+		return zr.endFrame()
+	}
+
+	if size := windowSize + zr.blockSize; cap(zr.buf) < size {
+		zr.buf = make([]byte, size)
+	}
+	start := 0
+	if zr.flags&flagBlockIndependence == 0 && zr.end > 0 {
+		// keep the last windowSize bytes as history for linked blocks
+		if zr.end > windowSize {
+			copy(zr.buf, zr.buf[zr.end-windowSize:zr.end])
+			zr.end = windowSize
+		}
+		start = zr.end
+	}
+	n, err := b.decode(zr.buf[:start+zr.blockSize], start, zr.flags&flagBlockChecksum != 0)
+	if err != nil {
+		return err
+	}
+	zr.end = n
+	zr.consume(zr.buf[start:n])
+	return nil
+}
+
+// nextBlock makes the next block of a frame with independent blocks
+// available, keeping up to Concurrency blocks decompressing in the
+// background.
+func (zr *Reader) nextBlock() error {
+	if zr.cur != nil {
+		zr.free = append(zr.free, zr.cur)
+		zr.cur = nil
+	}
+	for !zr.ending && zr.readErr == nil && len(zr.queue) < zr.conc {
+		b := zr.newBlock()
+		end, err := zr.readRawBlock(b)
+		if err != nil || end {
+			// blocks already queued are returned before the error
+			zr.readErr, zr.ending = err, end
+			zr.free = append(zr.free, b)
+			break
+		}
+		b.done = make(chan struct{})
+		blockSize, checksum := zr.blockSize, zr.flags&flagBlockChecksum != 0
+		go func() {
+			var n int
+			if cap(b.data) < blockSize {
+				b.data = make([]byte, blockSize)
+			}
+			n, b.err = b.decode(b.data[:blockSize], 0, checksum)
+			b.data = b.data[:n]
+			close(b.done)
+		}()
+		zr.queue = append(zr.queue, b)
+	}
+
+	if len(zr.queue) == 0 {
+		if zr.readErr != nil {
+			return zr.readErr
+		}
+		zr.ending = false
+		return zr.endFrame()
+	}
+	b := zr.queue[0]
+	copy(zr.queue, zr.queue[1:])
+	zr.queue = zr.queue[:len(zr.queue)-1]
+	<-b.done
+	if b.err != nil {
+		return b.err
+	}
+	zr.cur = b
+	zr.consume(b.data)
+	return nil
+}
+
+func (zr *Reader) newBlock() *frameBlock {
+	if n := len(zr.free); n > 0 {
+		b := zr.free[n-1]
+		zr.free = zr.free[:n-1]
+		return b
+	}
+	return &frameBlock{}
+}
+
+// readRawBlock reads the next block of the current frame into b. At the end
+// mark it reads the content checksum and returns true.
+func (zr *Reader) readRawBlock(b *frameBlock) (bool, error) {
+	if err := zr.readFull(zr.scratch[:4]); err != nil {
+		return false, err
+	}
+	size := binary.LittleEndian.Uint32(zr.scratch[:])
+	if size == 0 {
+		if zr.flags&flagContentChecksum != 0 {
+			if err := zr.readFull(zr.scratch[:4]); err != nil {
+				return false, err
+			}
+			zr.sum = binary.LittleEndian.Uint32(zr.scratch[:])
+		}
+		return true, nil
+	}
+	b.uncompressed = size&blockUncompressed != 0
+	size &^= blockUncompressed
+	if int(size) > zr.blockSize {
+		return false, ErrCorrupt
+	}
+	if cap(b.raw) < int(size) {
+		b.raw = make([]byte, zr.blockSize)
+	}
+	b.raw = b.raw[:size]
+	if err := zr.readFull(b.raw); err != nil {
+		return false, err
+	}
+	if zr.flags&flagBlockChecksum != 0 {
+		if err := zr.readFull(zr.scratch[:4]); err != nil {
+			return false, err
+		}
+		b.sum = binary.LittleEndian.Uint32(zr.scratch[:])
+	}
+	return false, nil
+}
+
+// decode verifies the block checksum and decodes the block into dst[start:],
+// returning the end of the decoded data.
+func (b *frameBlock) decode(dst []byte, start int, checksum bool) (int, error) {
+	if checksum && xxh32Checksum(b.raw) != b.sum {
+		return 0, ErrChecksum
+	}
+	if !b.uncompressed {
+		return decodeBlock(dst, start, b.raw)
+	}
+	if len(b.raw) > len(dst)-start {
+		return 0, ErrCorrupt
+	}
+	return start + copy(dst[start:], b.raw), nil
+}
+
+// consume makes data available to Read and adds it to the content checksum.
+func (zr *Reader) consume(data []byte) {
+	if zr.flags&flagContentChecksum != 0 {
+		_, _ = zr.checksum.Write(data)
+	}
+	zr.size += uint64(len(data))
+	zr.out = data
+}
+
+// endFrame verifies the content size and checksum at the end of a frame.
+func (zr *Reader) endFrame() error {
+	if zr.flags&flagContentChecksum != 0 && zr.sum != zr.checksum.Sum32() {
+		return ErrChecksum
+	}
+	if zr.flags&flagContentSize != 0 && zr.size != zr.contentSize {
+		return ErrCorrupt
+	}
+	zr.inFrame = false
+	zr.end = 0
+	return nil
+}
+
+// readFull reads exactly len(p) bytes inside a frame, where running out of
+// input is always an error.
+func (zr *Reader) readFull(p []byte) error {
+	_, err := io.ReadFull(zr.r, p)
+	return noEOF(err)
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,336 @@
+package lz4
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// testData returns n bytes mixing compressible text and random noise.
+func testData(n int) []byte {
+	rng := rand.New(rand.NewSource(int64(n)))
+	var b bytes.Buffer
+	for b.Len() < n {
+		if rng.Intn(4) == 0 {
+			noise := make([]byte, rng.Intn(512))
+			rng.Read(noise)
+			b.Write(noise)
+			continue
+		}
+		b.WriteString("2024-01-02 10:00:00\tINFO\tshard/manager.go:42\tshard ready\t{\"db\": 3}\n")
+	}
+	return b.Bytes()[:n]
+}
+
+func compressFrame(t *testing.T, data []byte, opts *FrameOptions) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw, err := NewWriterWithOptions(&buf, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// uneven writes cross block boundaries
+	for p := data; len(p) > 0; {
+		n := len(p)
+		if n > 7777 {
+			n = 7777
+		}
+		if _, err := zw.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decompressFrame(data []byte) ([]byte, error) {
+	return io.ReadAll(NewReader(bytes.NewReader(data)))
+}
+
+func TestXXH32(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint32
+	}{
+		{"", 0x02CC5D05},
+		{"a", 0x550D7456},
+		{"abc", 0x32D153FF},
+		{"Nobody inspects the spammish repetition", 0xE2293B2F},
+	}
+	for _, tt := range tests {
+		if got := xxh32Checksum([]byte(tt.in)); got != tt.want {
+			t.Errorf("xxh32(%q) = %#x, want %#x", tt.in, got, tt.want)
+		}
+		// streaming in small pieces gives the same result
+		h := newXXH32(0)
+		for i := 0; i < len(tt.in); i += 3 {
+			_, _ = h.Write([]byte(tt.in[i:min(i+3, len(tt.in))]))
+		}
+		if got := h.Sum32(); got != tt.want {
+			t.Errorf("streaming xxh32(%q) = %#x, want %#x", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFrame_RoundTrip(t *testing.T) {
+	noise := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(noise)
+	for _, tt := range []struct {
+		name string
+		data []byte
+		opts *FrameOptions
+	}{
+		{"empty", nil, nil},
+		{"small", []byte("hello"), nil},
+		{"default", testData(300000), nil},
+		{"noise", noise, &FrameOptions{BlockSize: BlockSize64K, ContentChecksum: true}},
+		{"checksums", testData(200000), &FrameOptions{BlockSize: BlockSize64K, BlockChecksum: true, ContentChecksum: true}},
+		{"content size", testData(70000), &FrameOptions{BlockSize: BlockSize256K, ContentSize: 70000}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := compressFrame(t, tt.data, tt.opts)
+			got, err := decompressFrame(frame)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Fatalf("round trip mismatch: got %d bytes, want %d", len(got), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestFrame_ConcatenatedAndSkippable(t *testing.T) {
+	a, b := testData(1000), testData(2000)
+	var stream []byte
+	stream = append(stream, compressFrame(t, a, nil)...)
+	// skippable frame with 3 bytes of user data
+	stream = append(stream, 0x51, 0x2A, 0x4D, 0x18, 3, 0, 0, 0, 'x', 'y', 'z')
+	stream = append(stream, compressFrame(t, b, nil)...)
+
+	got, err := decompressFrame(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, append(append([]byte{}, a...), b...)) {
+		t.Fatal("concatenated frames mismatch")
+	}
+}
+
+func TestFrame_Corrupt(t *testing.T) {
+	data := testData(50000)
+	frame := compressFrame(t, data, &FrameOptions{BlockSize: BlockSize64K, BlockChecksum: true, ContentChecksum: true})
+
+	for name, mutate := range map[string]func([]byte){
+		"header":   func(b []byte) { b[5] ^= 0x10 },
+		"block":    func(b []byte) { b[20] ^= 0xFF },
+		"content":  func(b []byte) { b[len(b)-1] ^= 0xFF },
+		"magic":    func(b []byte) { b[0] = 0 },
+		"truncate": nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			bad := append([]byte{}, frame...)
+			if mutate == nil {
+				bad = bad[:len(bad)/2]
+			} else {
+				mutate(bad)
+			}
+			if _, err := decompressFrame(bad); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	frame = compressFrame(t, data, nil)
+	frame[len(frame)-1] ^= 0xFF
+	if _, err := decompressFrame(frame); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("err = %v, want ErrChecksum", err)
+	}
+}
+
+func TestWriter_FlushAndReset(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewWriter(&buf)
+	_, _ = zw.Write([]byte("first "))
+	if err := zw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// flushed data is readable before the frame ends
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	p := make([]byte, 6)
+	if _, err := io.ReadFull(r, p); err != nil || string(p) != "first " {
+		t.Fatalf("read %q, %v", p, err)
+	}
+	_, _ = zw.Write([]byte("second"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := zw.Write([]byte("x")); !errors.Is(err, ErrClosed) {
+		t.Fatalf("write after close: %v", err)
+	}
+
+	var buf2 bytes.Buffer
+	zw.Reset(&buf2)
+	_, _ = zw.Write([]byte("third"))
+	_ = zw.Close()
+	got, err := decompressFrame(append(buf.Bytes(), buf2.Bytes()...))
+	if err != nil || string(got) != "first secondthird" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	opts := NewFrameOptions()
+	opts.ContentSize = 10
+	zw, _ = NewWriterWithOptions(io.Discard, opts)
+	_, _ = zw.Write([]byte("short"))
+	if err := zw.Close(); err == nil {
+		t.Fatal("expected content size mismatch")
+	}
+	if _, err := NewWriterWithOptions(io.Discard, &FrameOptions{BlockSize: 1000}); err == nil {
+		t.Fatal("expected invalid block size error")
+	}
+}
+
+func TestFrame_Concurrent(t *testing.T) {
+	size := 3 << 20
+	if raceEnabled {
+		// still spans several blocks of every size below
+		size = BlockSize1M + BlockSize256K + 12345
+	}
+	data := testData(size)
+	for _, opts := range []*FrameOptions{
+		{BlockSize: BlockSize64K, ContentChecksum: true, Concurrency: 4},
+		{BlockSize: BlockSize256K, BlockChecksum: true, ContentChecksum: true, ContentSize: uint64(len(data)), Concurrency: 8},
+		{BlockSize: BlockSize1M, ContentChecksum: true, Level: MinLevelHC, Concurrency: 2},
+	} {
+		frame := compressFrame(t, data, opts)
+		sequential := *opts
+		sequential.Concurrency = 0
+		if !bytes.Equal(frame, compressFrame(t, data, &sequential)) {
+			t.Fatalf("%+v: output differs from the sequential writer", opts)
+		}
+
+		for _, conc := range []int{1, 3, 16} {
+			zr := NewReaderWithOptions(bytes.NewReader(frame), &ReaderOptions{Concurrency: conc})
+			got, err := io.ReadAll(zr)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Fatalf("%+v, read concurrency %d: %v", opts, conc, err)
+			}
+		}
+
+		// a corrupt block is reported after the blocks before it
+		bad := append([]byte{}, frame...)
+		bad[len(bad)/2] ^= 0xFF
+		zr := NewReaderWithOptions(bytes.NewReader(bad), &ReaderOptions{Concurrency: 4})
+		got, err := io.ReadAll(zr)
+		if err == nil || opts.BlockChecksum && (!bytes.Equal(got, data[:len(got)]) || len(got) == 0) {
+			t.Fatalf("%+v: corrupt frame read %d bytes, err = %v", opts, len(got), err)
+		}
+	}
+}
+
+// TestFrame_ConcurrentLarge streams more than MaxInputSize bytes through
+// concurrent writers and readers.
+func TestFrame_ConcurrentLarge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large stream in short mode")
+	}
+	if raceEnabled {
+		// the stream cannot be shrunk below MaxInputSize and takes minutes under the race detector
+		t.Skip("skipping large stream under the race detector")
+	}
+	const total = MaxInputSize + BlockSize4M + 12345
+	pr, pw := io.Pipe()
+	go func() {
+		zw, _ := NewWriterWithOptions(pw, &FrameOptions{BlockSize: BlockSize4M, ContentChecksum: true, ContentSize: total, Concurrency: 4})
+		chunk := bytes.Repeat([]byte("0123456789abcdef"), BlockSize1M/16)
+		for n := 0; n < total; n += len(chunk) {
+			if _, err := zw.Write(chunk[:min(len(chunk), total-n)]); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(zw.Close())
+	}()
+
+	n, err := io.Copy(io.Discard, NewReaderWithOptions(pr, &ReaderOptions{Concurrency: 4}))
+	if err != nil || n != total {
+		t.Fatalf("read %d bytes, want %d: %v", n, total, err)
+	}
+}
+
+// TestFrame_CLI checks interoperability with the reference lz4 command line tool.
+func TestFrame_CLI(t *testing.T) {
+	bin, err := exec.LookPath("lz4")
+	if err != nil {
+		t.Skip("lz4 command not found")
+	}
+	dir := t.TempDir()
+	data := testData(5 << 20)
+
+	// our frames decompress with the CLI
+	for name, opts := range map[string]*FrameOptions{
+		"default":   nil,
+		"checksums": {BlockSize: BlockSize64K, BlockChecksum: true, ContentChecksum: true, ContentSize: uint64(len(data))},
+		"hc":        {BlockSize: BlockSize1M, ContentChecksum: true, Level: MaxLevelHC},
+		"accel":     {BlockSize: BlockSize4M, Acceleration: 4},
+	} {
+		in := filepath.Join(dir, name+".lz4")
+		if err := os.WriteFile(in, compressFrame(t, data, opts), 0644); err != nil {
+			t.Fatal(err)
+		}
+		out, err := exec.Command(bin, "-d", "-c", in).Output()
+		if err != nil {
+			t.Fatalf("%s: lz4 -d: %v", name, err)
+		}
+		if !bytes.Equal(out, data) {
+			t.Fatalf("%s: lz4 -d output mismatch", name)
+		}
+	}
+
+	// CLI frames decompress with NewReader, including linked blocks
+	raw := filepath.Join(dir, "raw")
+	if err := os.WriteFile(raw, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{}, {"-BD", "-B4"}, {"-BX", "-B5", "--content-size"}, {"-9"}} {
+		cmd := exec.Command(bin, append(append([]string{"-c", "-f"}, args...), raw)...)
+		frame, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("lz4 %v: %v", args, err)
+		}
+		got, err := decompressFrame(frame)
+		if err != nil {
+			t.Fatalf("lz4 %v: %v", args, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("lz4 %v: output mismatch", args)
+		}
+	}
+}
+
+func FuzzReader(f *testing.F) {
+	for _, opts := range []*FrameOptions{
+		{BlockSize: BlockSize64K},
+		{BlockSize: BlockSize64K, BlockChecksum: true, ContentChecksum: true, ContentSize: 3000},
+	} {
+		var buf bytes.Buffer
+		zw, _ := NewWriterWithOptions(&buf, opts)
+		_, _ = zw.Write(testData(3000))
+		_ = zw.Close()
+		f.Add(buf.Bytes(), 1)
+		f.Add(buf.Bytes(), 4)
+	}
+	f.Fuzz(func(t *testing.T, frame []byte, conc int) {
+		// must fail cleanly on corrupt frames
+		zr := NewReaderWithOptions(bytes.NewReader(frame), &ReaderOptions{Concurrency: conc % 8})
+		_, _ = io.Copy(io.Discard, io.LimitReader(zr, 1<<20))
+	})
+}
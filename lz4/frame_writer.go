@@ -0,0 +1,266 @@
+package lz4
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrClosed is returned when writing to a closed Writer.
+var ErrClosed = errors.New("writer closed")
+
+// Writer compresses data into the LZ4 frame format, readable by the lz4
+// command line tool and any other conforming implementation. The frame is
+// streamed block by block, so there is no limit on the total size.
+type Writer struct {
+	w    io.Writer
+	opts FrameOptions
+	bd   byte
+
+	buf      []byte // pending uncompressed data, at most opts.BlockSize
+	zbuf     []byte // compressed block
+	c        Compressor
+	checksum xxh32
+	size     uint64
+
+	// blocks being compressed in the background, in output order
+	pending []*frameBlock
+	free    []*frameBlock
+
+	wroteHeader bool
+	closed      bool
+	err         error
+}
+
+// NewWriter returns a Writer that writes a frame with the default options to w.
+func NewWriter(w io.Writer) *Writer {
+	zw, _ := NewWriterWithOptions(w, nil)
+	return zw
+}
+
+// NewWriterWithOptions returns a Writer that writes a frame with opts to w.
+// A nil opts uses NewFrameOptions.
+func NewWriterWithOptions(w io.Writer, opts *FrameOptions) (*Writer, error) {
+	if opts == nil {
+		opts = NewFrameOptions()
+	}
+	bd, err := blockSizeID(opts.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	zw := &Writer{opts: *opts, bd: bd, c: Compressor{level: opts.Level, accel: opts.Acceleration}}
+	zw.Reset(w)
+	return zw, nil
+}
+
+// Reset discards the Writer's state and makes it write a new frame to w,
+// keeping the options and buffers.
+func (zw *Writer) Reset(w io.Writer) {
+	for _, b := range zw.pending {
+		<-b.done
+		zw.free = append(zw.free, b)
+	}
+	zw.pending = zw.pending[:0]
+	zw.w = w
+	zw.buf = zw.buf[:0]
+	zw.checksum.reset(0)
+	zw.size = 0
+	zw.wroteHeader = false
+	zw.closed = false
+	zw.err = nil
+}
+
+// Write compresses p. Data is buffered until a full block is available.
+func (zw *Writer) Write(p []byte) (int, error) {
+	if err := zw.writeHeader(); err != nil {
+		return 0, err
+	}
+	n := 0
+	for len(p) > 0 {
+		// compress whole blocks straight from p
+		if len(zw.buf) == 0 && len(p) >= zw.opts.BlockSize {
+			if err := zw.writeBlock(p[:zw.opts.BlockSize]); err != nil {
+				return n, err
+			}
+			n += zw.opts.BlockSize
+			p = p[zw.opts.BlockSize:]
+			continue
+		}
+		if zw.buf == nil {
+			zw.buf = make([]byte, 0, zw.opts.BlockSize)
+		}
+		c := copy(zw.buf[len(zw.buf):cap(zw.buf)], p)
+		zw.buf = zw.buf[:len(zw.buf)+c]
+		n += c
+		p = p[c:]
+		if len(zw.buf) == zw.opts.BlockSize {
+			if err := zw.writeBlock(zw.buf); err != nil {
+				return n, err
+			}
+			zw.buf = zw.buf[:0]
+		}
+	}
+	return n, nil
+}
+
+// Flush compresses any pending data as a block and writes it out, waiting
+// for blocks being compressed in the background. The frame stays open; more
+// data may follow.
+func (zw *Writer) Flush() error {
+	if err := zw.writeHeader(); err != nil {
+		return err
+	}
+	if len(zw.buf) > 0 {
+		err := zw.writeBlock(zw.buf)
+		zw.buf = zw.buf[:0]
+		if err != nil {
+			return err
+		}
+	}
+	return zw.writePending(0)
+}
+
+// Close flushes pending data and writes the frame end mark and content
+// checksum. It does not close the underlying writer.
+func (zw *Writer) Close() error {
+	if zw.closed {
+		return zw.err
+	}
+	if err := zw.Flush(); err != nil {
+		return err
+	}
+	zw.closed = true
+
+	var trailer [8]byte
+	n := 4
+	if zw.opts.ContentChecksum {
+		binary.LittleEndian.PutUint32(trailer[4:], zw.checksum.Sum32())
+		n = 8
+	}
+	if _, err := zw.w.Write(trailer[:n]); err != nil {
+		zw.err = err
+		return err
+	}
+	if zw.opts.ContentSize > 0 && zw.size != zw.opts.ContentSize {
+		zw.err = errors.New("content size mismatch")
+	}
+	return zw.err
+}
+
+func (zw *Writer) writeHeader() error {
+	if zw.closed {
+		return ErrClosed
+	}
+	if zw.err != nil || zw.wroteHeader {
+		return zw.err
+	}
+	zw.wroteHeader = true
+
+	var header [19]byte
+	binary.LittleEndian.PutUint32(header[:], frameMagic)
+	flags := byte(frameVersion<<6) | flagBlockIndependence
+	if zw.opts.BlockChecksum {
+		flags |= flagBlockChecksum
+	}
+	if zw.opts.ContentChecksum {
+		flags |= flagContentChecksum
+	}
+	header[4] = flags
+	header[5] = zw.bd << 4
+	n := 6
+	if zw.opts.ContentSize > 0 {
+		header[4] |= flagContentSize
+		binary.LittleEndian.PutUint64(header[n:], zw.opts.ContentSize)
+		n += 8
+	}
+	header[n] = headerChecksum(header[4:n])
+	n++
+
+	_, zw.err = zw.w.Write(header[:n])
+	return zw.err
+}
+
+// writeBlock compresses data as one independent block, in the background
+// when Concurrency is above 1.
+func (zw *Writer) writeBlock(data []byte) error {
+	if zw.opts.ContentChecksum {
+		_, _ = zw.checksum.Write(data)
+	}
+	zw.size += uint64(len(data))
+
+	if zw.opts.Concurrency <= 1 {
+		if zw.zbuf == nil {
+			zw.zbuf = make([]byte, 0, zw.blockBound())
+		}
+		zw.zbuf = zw.encodeBlock(zw.zbuf[:0], data)
+		_, zw.err = zw.w.Write(zw.zbuf)
+		return zw.err
+	}
+
+	// data belongs to the caller or zw.buf, so the block works on a copy
+	b := zw.newBlock()
+	b.data = append(b.data[:0], data...)
+	go func() {
+		b.raw = zw.encodeBlock(b.raw[:0], b.data)
+		close(b.done)
+	}()
+	zw.pending = append(zw.pending, b)
+	return zw.writePending(zw.opts.Concurrency - 1)
+}
+
+// writePending writes out compressed blocks in order until at most keep
+// blocks are left in the background.
+func (zw *Writer) writePending(keep int) error {
+	for len(zw.pending) > keep {
+		b := zw.pending[0]
+		<-b.done
+		if zw.err == nil {
+			_, zw.err = zw.w.Write(b.raw)
+		}
+		copy(zw.pending, zw.pending[1:])
+		zw.pending = zw.pending[:len(zw.pending)-1]
+		zw.free = append(zw.free, b)
+	}
+	return zw.err
+}
+
+func (zw *Writer) newBlock() *frameBlock {
+	var b *frameBlock
+	if n := len(zw.free); n > 0 {
+		b = zw.free[n-1]
+		zw.free = zw.free[:n-1]
+	} else {
+		b = &frameBlock{
+			data: make([]byte, 0, zw.opts.BlockSize),
+			raw:  make([]byte, 0, zw.blockBound()),
+		}
+	}
+	b.done = make(chan struct{})
+	return b
+}
+
+// blockBound returns the maximum stored size of a block with its size and
+// checksum.
+func (zw *Writer) blockBound() int {
+	return 4 + CompressBound(zw.opts.BlockSize) + 4
+}
+
+// encodeBlock appends the stored form of data to dst: its size, the
+// compressed block and the block checksum. Data that does not shrink is
+// stored uncompressed.
+func (zw *Writer) encodeBlock(dst, data []byte) []byte {
+	dst = append(dst, 0, 0, 0, 0)
+	dst = zw.c.appendBlock(dst, data, nil)
+	block := dst[4:]
+	size := uint32(len(block))
+	if len(block) >= len(data) {
+		dst = append(dst[:4], data...)
+		block = dst[4:]
+		size = uint32(len(block)) | blockUncompressed
+	}
+	binary.LittleEndian.PutUint32(dst, size)
+	if zw.opts.BlockChecksum {
+		dst = binary.LittleEndian.AppendUint32(dst, xxh32Checksum(block))
+	}
+	return dst
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package lz4
+
+// the race detector randomly drops pooled objects, so pooled code allocates
+const raceEnabled = true
@@ -28,7 +28,6 @@ package lz4
 import (
 	"encoding/binary"
 	"errors"
-	"io"
 )
 
 var (
@@ -43,172 +42,143 @@ const (
 	runMask = (1 << runBits) - 1
 )
 
-type decoder struct {
-	src  []byte
-	dst  []byte
-	spos uint32
-	dpos uint32
-	ref  uint32
-}
-
-func (d *decoder) readByte() (uint8, error) {
-	if int(d.spos) == len(d.src) {
-		return 0, io.EOF
-	}
-	b := d.src[d.spos]
-	d.spos++
-	return b, nil
-}
-
-func (d *decoder) getLen() (uint32, error) {
-
-	length := uint32(0)
-	ln, err := d.readByte()
-	if err != nil {
-		return 0, ErrCorrupt
-	}
-	for ln == 255 {
-		length += 255
-		ln, err = d.readByte()
-		if err != nil {
-			return 0, ErrCorrupt
-		}
-	}
-	length += uint32(ln)
-
-	return length, nil
-}
-
-func (d *decoder) cp(length, decr uint32) {
-
-	if int(d.ref+length) < int(d.dpos) {
-		copy(d.dst[d.dpos:], d.dst[d.ref:d.ref+length])
-	} else {
-		for ii := uint32(0); ii < length; ii++ {
-			d.dst[d.dpos+ii] = d.dst[d.ref+ii]
-		}
-	}
-	d.dpos += length
-	d.ref += length - decr
-}
-
-func (d *decoder) finish(err error) error {
-	if err == io.EOF {
-		return nil
-	}
-
-	return err
-}
+// maxRatio bounds the decoded size of a block: every input byte expands to
+// at most 255 output bytes, through a match length extension byte.
+const maxRatio = 255
 
 // Decode returns the decoded form of src.  The returned slice may be a
 // subslice of dst if it was large enough to hold the entire decoded block.
+//
+// The size stored in src is only trusted up to what the block could
+// possibly decode to; use DecodeWithMaxSize to also cap it for untrusted
+// input.
 func Decode(dst, src []byte, headSizeFirst bool) ([]byte, error) {
+	return DecodeWithMaxSize(dst, src, headSizeFirst, MaxInputSize)
+}
+
+// DecodeWithMaxSize is Decode for untrusted input: it returns ErrTooLarge
+// before allocating anything if the stored uncompressed size exceeds
+// maxSize, and ErrCorrupt if src does not decode to exactly that size.
+func DecodeWithMaxSize(dst, src []byte, headSizeFirst bool, maxSize int) ([]byte, error) {
 	size := len(src)
 	if size < 4 {
 		return nil, ErrCorrupt
 	}
 
 	var uncompressedLen uint32
+	var block []byte
 	if !headSizeFirst {
 		uncompressedLen = binary.LittleEndian.Uint32(src[size-4 : size])
+		block = src[:size-4]
 	} else {
 		uncompressedLen = binary.LittleEndian.Uint32(src)
+		block = src[4:]
 	}
 
 	if uncompressedLen == 0 {
 		return nil, nil
 	}
 
-	if uncompressedLen > MaxInputSize {
+	if uncompressedLen > MaxInputSize || int64(uncompressedLen) > int64(maxSize) {
 		return nil, ErrTooLarge
 	}
+	if uint64(uncompressedLen) > uint64(len(block))*maxRatio {
+		return nil, ErrCorrupt
+	}
 
-	if dst == nil || len(dst) < int(uncompressedLen) {
+	if len(dst) < int(uncompressedLen) {
 		dst = make([]byte, uncompressedLen)
 	}
+	dst = dst[:uncompressedLen]
 
-	var d decoder
-	if headSizeFirst {
-		d = decoder{src: src, dst: dst[:uncompressedLen], spos: 4}
-	} else {
-		d = decoder{src: src, dst: dst[:uncompressedLen], spos: 0}
+	n, err := decodeBlock(dst, 0, block)
+	if err != nil {
+		return nil, err
 	}
+	if n != len(dst) {
+		return nil, ErrCorrupt
+	}
+	return dst, nil
+}
 
-	decr := []uint32{0, 3, 2, 3}
-
-	for {
-		code, err := d.readByte()
-		if err != nil {
-			return d.dst, d.finish(err)
-		}
-
-		length := uint32(code >> mlBits)
-		if length == runMask {
-			ln, err := d.getLen()
-			if err != nil {
-				return nil, ErrCorrupt
+// decodeBlock decodes the raw LZ4 block src into dst[start:] and returns the
+// end position of the decoded data. dst[:start] is treated as history that
+// matches may refer back to (the previous block or a dictionary). Every read
+// and write is bounds checked, so corrupt input yields ErrCorrupt rather than
+// a panic.
+func decodeBlock(dst []byte, start int, src []byte) (int, error) {
+	si, di := 0, start
+	for si < len(src) {
+		token := src[si]
+		si++
+
+		// literals
+		lit := int(token >> mlBits)
+		if lit == runMask {
+			for {
+				if si >= len(src) {
+					return 0, ErrCorrupt
+				}
+				b := src[si]
+				si++
+				lit += int(b)
+				if b != 255 {
+					break
+				}
 			}
-			length += ln
 		}
-
-		if int(d.spos+length) > len(d.src) || int(d.dpos+length) > len(d.dst) {
-			return nil, ErrCorrupt
-		}
-
-		for ii := uint32(0); ii < length; ii++ {
-			d.dst[d.dpos+ii] = d.src[d.spos+ii]
+		if lit > len(src)-si || lit > len(dst)-di {
+			return 0, ErrCorrupt
 		}
+		copy(dst[di:], src[si:si+lit])
+		si += lit
+		di += lit
 
-		d.spos += length
-		d.dpos += length
-		if !headSizeFirst {
-			if int(d.spos) == len(d.src)-4 {
-				return d.dst, nil
-			}
-		} else {
-			if int(d.spos) == len(d.src) {
-				return d.dst, nil
-			}
+		// the last sequence has no match part
+		if si == len(src) {
+			return di, nil
 		}
 
-		if int(d.spos+2) >= len(d.src) {
-			return nil, ErrCorrupt
+		// match
+		if len(src)-si < 2 {
+			return 0, ErrCorrupt
 		}
-
-		back := uint32(d.src[d.spos]) | uint32(d.src[d.spos+1])<<8
-
-		if back > d.dpos {
-			return nil, ErrCorrupt
+		offset := int(src[si]) | int(src[si+1])<<8
+		si += 2
+		if offset == 0 || offset > di {
+			return 0, ErrCorrupt
 		}
 
-		d.spos += 2
-		d.ref = d.dpos - back
-
-		length = uint32(code & mlMask)
-		if length == mlMask {
-			ln, err := d.getLen()
-			if err != nil {
-				return nil, ErrCorrupt
+		ml := int(token & mlMask)
+		if ml == mlMask {
+			for {
+				if si >= len(src) {
+					return 0, ErrCorrupt
+				}
+				b := src[si]
+				si++
+				ml += int(b)
+				if b != 255 {
+					break
+				}
 			}
-			length += ln
 		}
-
-		literal := d.dpos - d.ref
-
-		if literal < 4 {
-			if int(d.dpos+4) > len(d.dst) {
-				return nil, ErrCorrupt
-			}
-
-			d.cp(4, decr[literal])
-		} else {
-			length += 4
+		ml += minMatch
+		if ml > len(dst)-di {
+			return 0, ErrCorrupt
 		}
 
-		if d.dpos+length > uncompressedLen {
-			return nil, ErrCorrupt
+		ref := di - offset
+		if offset >= ml {
+			copy(dst[di:di+ml], dst[ref:ref+ml])
+		} else {
+			// overlapping match: repeat the pattern of length offset
+			for i := 0; i < ml; i++ {
+				dst[di+i] = dst[ref+i]
+			}
 		}
-
-		d.cp(length, 0)
+		di += ml
 	}
+	// valid blocks end with a literal-only sequence
+	return 0, ErrCorrupt
 }
@@ -0,0 +1,124 @@
+package lz4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestDecode_Untrusted(t *testing.T) {
+	data := testData(10000)
+	encoded, _ := Encode(nil, data, true)
+
+	// a tiny packet claiming a huge size fails without allocating
+	huge := []byte{0xff, 0xff, 0xff, 0x7d, 0x00}
+	allocs := testing.AllocsPerRun(10, func() {
+		if _, err := Decode(nil, huge, true); !errors.Is(err, ErrCorrupt) {
+			t.Fatalf("err = %v, want ErrCorrupt", err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("%v allocs for a huge claimed size", allocs)
+	}
+
+	if _, err := DecodeWithMaxSize(nil, encoded, true, len(data)-1); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("err = %v, want ErrTooLarge", err)
+	}
+	got, err := DecodeWithMaxSize(nil, encoded, true, len(data))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("within max size: %v", err)
+	}
+
+	// the stored size must match the decoded size exactly
+	for _, size := range []uint32{uint32(len(data)) - 1, uint32(len(data)) + 1} {
+		bad := append([]byte{}, encoded...)
+		binary.LittleEndian.PutUint32(bad, size)
+		if _, err := Decode(nil, bad, true); !errors.Is(err, ErrCorrupt) {
+			t.Fatalf("size %d: err = %v, want ErrCorrupt", size, err)
+		}
+	}
+
+	for name, src := range map[string][]byte{
+		// match offset 0
+		"offset 0": {8, 0, 0, 0, 0x10, 'a', 0, 0, 0x00},
+		// match before the start of the output
+		"offset past start": {8, 0, 0, 0, 0x10, 'a', 2, 0, 0x00},
+		// match running past the output
+		"long match": {8, 0, 0, 0, 0x1f, 'a', 1, 0, 0xff, 0x00},
+		// literals running past the input
+		"long literals": {8, 0, 0, 0, 0x80, 'a', 'b'},
+		// ends with a match
+		"no last literals": {5, 0, 0, 0, 0x10, 'a', 1, 0},
+	} {
+		if _, err := Decode(nil, src, true); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: err = %v, want ErrCorrupt", name, err)
+		}
+	}
+}
+
+// TestDecode_Mutations decodes randomly corrupted blocks, which must fail
+// cleanly or decode to the stored size, never panic.
+func TestDecode_Mutations(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var seeds [][]byte
+	for _, n := range []int{0, 20, 300, 5000} {
+		fast, _ := Encode(nil, testData(n), true)
+		hc, _ := EncodeHC(nil, testData(n), false, MaxLevelHC)
+		seeds = append(seeds, fast, hc)
+	}
+	dst := make([]byte, 8192)
+	for i := 0; i < 20000; i++ {
+		src := append([]byte{}, seeds[rng.Intn(len(seeds))]...)
+		for m := rng.Intn(4) + 1; m > 0 && len(src) > 0; m-- {
+			switch rng.Intn(3) {
+			case 0:
+				src[rng.Intn(len(src))] ^= byte(1 << rng.Intn(8))
+			case 1:
+				src[rng.Intn(len(src))] = byte(rng.Intn(256))
+			case 2:
+				src = src[:rng.Intn(len(src))]
+			}
+		}
+		headSizeFirst := rng.Intn(2) == 0
+		checkDecode(t, dst, src, headSizeFirst)
+	}
+}
+
+func checkDecode(t *testing.T, dst, src []byte, headSizeFirst bool) {
+	t.Helper()
+	out, err := DecodeWithMaxSize(dst, src, headSizeFirst, 1<<20)
+	if err != nil {
+		if !errors.Is(err, ErrCorrupt) && !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return
+	}
+	if len(src) >= 4 {
+		stored := binary.LittleEndian.Uint32(src)
+		if !headSizeFirst {
+			stored = binary.LittleEndian.Uint32(src[len(src)-4:])
+		}
+		if uint32(len(out)) != stored {
+			t.Fatalf("decoded %d bytes, stored size %d", len(out), stored)
+		}
+	}
+}
+
+// FuzzDecode runs its seed corpus with go test; run it with -fuzz=FuzzDecode
+// to search for inputs that crash the decoder.
+func FuzzDecode(f *testing.F) {
+	for _, n := range []int{0, 1, 13, 100, 4000} {
+		fast, _ := Encode(nil, testData(n), true)
+		hc, _ := EncodeHC(nil, testData(n), false, 0)
+		f.Add(fast, true)
+		f.Add(hc, false)
+	}
+	f.Add([]byte{0xff, 0xff, 0xff, 0x7d, 0x00}, true)
+	f.Add([]byte{8, 0, 0, 0, 0x1f, 'a', 1, 0, 0xff, 0x00}, true)
+	f.Add([]byte{0x10, 'a', 1, 0, 0x00, 5, 0, 0, 0}, false)
+	f.Fuzz(func(t *testing.T, src []byte, headSizeFirst bool) {
+		checkDecode(t, nil, src, headSizeFirst)
+	})
+}
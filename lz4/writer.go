@@ -26,7 +26,6 @@
 package lz4
 
 import (
-	"encoding/binary"
 	"errors"
 )
 
@@ -54,6 +53,9 @@ type encoder struct {
 	pos       uint32
 	anchor    uint32
 	dpos      uint32
+	accel     uint32
+	// hash table entries are positions minus off
+	off uint32
 }
 
 // CompressBound returns the maximum length of a lz4 block, given it's uncompressed length
@@ -100,47 +102,115 @@ func (e *encoder) writeLiterals(length, mlLen, pos uint32) {
 	e.dpos += length
 }
 
+// writeSequence writes length literals from pos followed by a match of
+// mlLen+minMatch bytes at distance back.
+func (e *encoder) writeSequence(length, mlLen, pos, back uint32) {
+	e.writeLiterals(length, mlLen, pos)
+	e.dst[e.dpos] = uint8(back)
+	e.dst[e.dpos+1] = uint8(back >> 8)
+	e.dpos += 2
+
+	if mlLen > mlMask-1 {
+		mlLen -= mlMask
+		for mlLen > 254 {
+			mlLen -= 255
+
+			e.dst[e.dpos] = 255
+			e.dpos++
+		}
+
+		e.dst[e.dpos] = byte(mlLen)
+		e.dpos++
+	}
+}
+
 // Encode returns the encoded form of src.  The returned array may be a
 // sub-slice of dst if it was large enough to hold the entire output.
 func Encode(dst, src []byte, headSizeFirst bool) ([]byte, error) {
+	return EncodeFast(dst, src, headSizeFirst, 1)
+}
 
-	if len(src) >= MaxInputSize {
-		return nil, ErrTooLarge
-	}
+// EncodeFast is Encode with an acceleration factor. An acceleration of 1 is
+// the same as Encode; larger values skip through incompressible data faster
+// and stop retrying missed matches, trading compression ratio for speed.
+// Values below 1 are treated as 1.
+func EncodeFast(dst, src []byte, headSizeFirst bool, acceleration int) ([]byte, error) {
+	c := Compressor{accel: acceleration}
+	return c.encode(dst, src, headSizeFirst)
+}
 
-	if n := CompressBound(len(src)); len(dst) < n {
-		dst = make([]byte, n)
+// EncodeHC is Encode using the high compression encoder, which searches hash
+// chains for the longest match. level selects the search depth, from
+// MinLevelHC to MaxLevelHC; 0 uses DefaultLevelHC. The output is decoded by
+// Decode like any other block.
+func EncodeHC(dst, src []byte, headSizeFirst bool, level int) ([]byte, error) {
+	if level <= 0 {
+		level = DefaultLevelHC
 	}
+	c := Compressor{level: max(level, MinLevelHC)}
+	return c.encode(dst, src, headSizeFirst)
+}
 
-	e := encoder{src: src, dst: dst, hashTable: make([]uint32, hashTableSize)}
+// fastTable is the hash table of the fast encoder. Entries are stored
+// relative to an offset that moves on for every block, so entries left by
+// earlier blocks look farther away than the maximum match distance and the
+// table can be reused without clearing it.
+type fastTable struct {
+	entries [hashTableSize]uint32
+	// distance the offset has moved since the table was last cleared
+	used uint64
+}
+
+// prepare returns the entry offset for compressing n bytes.
+func (t *fastTable) prepare(n int) uint32 {
+	// keep every stale entry and the zero value out of match distance
+	if t.used+uint64(n) >= 1<<31 {
+		clear(t.entries[:])
+		t.used = 0
+	}
+	off := uninitHash - uint32(t.used)
+	t.used += uint64(n) + windowSize
+	return off
+}
 
-	if headSizeFirst {
-		binary.LittleEndian.PutUint32(dst, uint32(len(src)))
-		e.dpos = 4
+// compressBlock compresses src[start:] into dst as a raw LZ4 block (no size
+// header) and returns the number of bytes written. src[:start] is history
+// that matches may refer to, such as a dictionary. dst must hold at least
+// CompressBound(len(src)-start) bytes.
+func compressBlock(dst, src []byte, start int, t *fastTable, acceleration int) int {
+	off := t.prepare(len(src))
+	// index the history so the first positions can match it
+	for p := 0; p < start && p+minMatch <= len(src); p++ {
+		sequence := uint32(src[p+3])<<24 | uint32(src[p+2])<<16 | uint32(src[p+1])<<8 | uint32(src[p+0])
+		t.entries[(sequence*2654435761)>>hashShift] = uint32(p) - off
 	}
+	if acceleration < 1 {
+		acceleration = 1
+	}
+	e := encoder{src: src, dst: dst, hashTable: t.entries[:], accel: uint32(acceleration), off: off}
+	e.pos = uint32(start)
+	e.anchor = e.pos
+	e.compress()
+	return int(e.dpos)
+}
 
+// compress writes the LZ4 sequences for e.src starting at e.dst[e.dpos].
+func (e *encoder) compress() {
 	var (
-		step  uint32 = 1
-		limit        = incompressible
+		step  = e.accel
+		limit = incompressible
 	)
 
 	for {
 		if int(e.pos)+12 >= len(e.src) {
 			e.writeLiterals(uint32(len(e.src))-e.anchor, 0, e.anchor)
-			if !headSizeFirst {
-				tmp := make([]byte, 4)
-				binary.LittleEndian.PutUint32(tmp, uint32(len(src)))
-				e.dst = append(e.dst[:e.dpos], tmp...)
-				e.dpos += 4
-			}
-			return e.dst[:e.dpos], nil
+			return
 		}
-
 		sequence := uint32(e.src[e.pos+3])<<24 | uint32(e.src[e.pos+2])<<16 | uint32(e.src[e.pos+1])<<8 | uint32(e.src[e.pos+0])
 
 		hash := (sequence * 2654435761) >> hashShift
-		ref := e.hashTable[hash] + uninitHash
-		e.hashTable[hash] = e.pos - uninitHash
+		ref := e.hashTable[hash] + e.off
+		e.hashTable[hash] = e.pos - e.off
 
 		if ((e.pos-ref)>>16) != 0 || uint32(e.src[ref+3])<<24|uint32(e.src[ref+2])<<16|uint32(e.src[ref+1])<<8|uint32(e.src[ref+0]) != sequence {
 			if e.pos-e.anchor > limit {
@@ -151,12 +221,15 @@ func Encode(dst, src []byte, headSizeFirst bool) ([]byte, error) {
 			continue
 		}
 
-		if step > 1 {
-			e.hashTable[hash] = ref - uninitHash
+		// back up to find the earliest match skipped over; accelerated
+		// encoding keeps the match found
+		if step > 1 && e.accel == 1 {
+			e.hashTable[hash] = ref - e.off
 			e.pos -= step - 1
 			step = 1
 			continue
 		}
+		step = e.accel
 		limit = incompressible
 
 		ln := e.pos - e.anchor
@@ -175,23 +248,7 @@ func Encode(dst, src []byte, headSizeFirst bool) ([]byte, error) {
 
 		mlLen := e.pos - e.anchor
 
-		e.writeLiterals(ln, mlLen, anchor)
-		e.dst[e.dpos] = uint8(back)
-		e.dst[e.dpos+1] = uint8(back >> 8)
-		e.dpos += 2
-
-		if mlLen > mlMask-1 {
-			mlLen -= mlMask
-			for mlLen > 254 {
-				mlLen -= 255
-
-				e.dst[e.dpos] = 255
-				e.dpos++
-			}
-
-			e.dst[e.dpos] = byte(mlLen)
-			e.dpos++
-		}
+		e.writeSequence(ln, mlLen, anchor, back)
 
 		e.anchor = e.pos
 
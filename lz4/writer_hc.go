@@ -0,0 +1,147 @@
+package lz4
+
+import "encoding/binary"
+
+// Compression levels of the high compression encoder. Each level doubles the
+// number of candidates searched per position, as in the reference LZ4HC.
+const (
+	MinLevelHC     = 3
+	DefaultLevelHC = 9
+	MaxLevelHC     = 12
+)
+
+const (
+	hcHashLog  = 15
+	hcHashSize = 1 << hcHashLog
+	hcMaxDist  = 1<<16 - 1
+
+	// the last match must start this many bytes before the end of the block
+	mfLimit = 12
+	// the last literals of a block, never covered by a match
+	lastLiterals = 5
+)
+
+// hcTables holds the hash chains of the high compression encoder.
+type hcTables struct {
+	// head[h] is 1 + the last position with hash h, 0 if none
+	head [hcHashSize]int32
+	// chain[p&0xFFFF] is the distance from p to the previous position with
+	// the same hash, 0 at the end of the chain
+	chain [hcMaxDist + 1]uint16
+}
+
+func newHCTables() *hcTables {
+	return &hcTables{}
+}
+
+func (t *hcTables) reset() {
+	t.head = [hcHashSize]int32{}
+}
+
+func hcHash(v uint32) uint32 {
+	return (v * 2654435761) >> (32 - hcHashLog)
+}
+
+// hcLevelDepth returns the maximum number of chain entries searched per
+// position for level.
+func hcLevelDepth(level int) int {
+	switch {
+	case level <= 0:
+		level = DefaultLevelHC
+	case level < MinLevelHC:
+		level = MinLevelHC
+	case level > MaxLevelHC:
+		level = MaxLevelHC
+	}
+	return 1 << uint(level-1)
+}
+
+// hcMatcher finds the longest matches in src using hash chains.
+type hcMatcher struct {
+	src   []byte
+	t     *hcTables
+	next  int // first position not yet inserted into the chains
+	depth int
+}
+
+// insert adds the positions before end to the hash chains.
+func (m *hcMatcher) insert(end int) {
+	for p := m.next; p < end; p++ {
+		h := hcHash(binary.LittleEndian.Uint32(m.src[p:]))
+		prev := int(m.t.head[h]) - 1
+		delta := 0
+		if prev >= 0 && p-prev <= hcMaxDist {
+			delta = p - prev
+		}
+		m.t.chain[p&hcMaxDist] = uint16(delta)
+		m.t.head[h] = int32(p + 1)
+	}
+	if end > m.next {
+		m.next = end
+	}
+}
+
+// find returns the position and length of the longest match for pos whose
+// end does not pass limit, or a length of 0 if there is none.
+func (m *hcMatcher) find(pos, limit int) (ref, length int) {
+	m.insert(pos)
+	src := m.src
+	seq := binary.LittleEndian.Uint32(src[pos:])
+	cand := int(m.t.head[hcHash(seq)]) - 1
+	for attempts := m.depth; cand >= 0 && pos-cand <= hcMaxDist && attempts > 0; attempts-- {
+		// a longer match must also match at the current best length
+		if binary.LittleEndian.Uint32(src[cand:]) == seq && (length == 0 || src[cand+length] == src[pos+length]) {
+			n := minMatch
+			for pos+n < limit && src[cand+n] == src[pos+n] {
+				n++
+			}
+			if n > length {
+				ref, length = cand, n
+				if pos+n == limit {
+					break
+				}
+			}
+		}
+		delta := int(m.t.chain[cand&hcMaxDist])
+		if delta == 0 {
+			break
+		}
+		cand -= delta
+	}
+	return ref, length
+}
+
+// compressBlockHC compresses src[start:] into dst as a raw LZ4 block with the
+// high compression encoder and returns the number of bytes written.
+// src[:start] is history that matches may refer to. dst must hold at least
+// CompressBound(len(src)-start) bytes.
+func compressBlockHC(dst, src []byte, start int, t *hcTables, level int) int {
+	t.reset()
+	m := hcMatcher{src: src, t: t, depth: hcLevelDepth(level)}
+	e := encoder{src: src, dst: dst}
+
+	mflimit := len(src) - mfLimit
+	limit := len(src) - lastLiterals
+	anchor, pos := start, start
+	for pos < mflimit {
+		ref, length := m.find(pos, limit)
+		if length == 0 {
+			pos++
+			continue
+		}
+		// lazy matching: prefer a longer match starting at the next position
+		for pos+1 < mflimit {
+			ref2, length2 := m.find(pos+1, limit)
+			if length2 <= length {
+				break
+			}
+			pos, ref, length = pos+1, ref2, length2
+		}
+
+		e.writeSequence(uint32(pos-anchor), uint32(length-minMatch), uint32(anchor), uint32(pos-ref))
+		pos += length
+		anchor = pos
+	}
+	e.writeLiterals(uint32(len(src)-anchor), 0, uint32(anchor))
+	return int(e.dpos)
+}
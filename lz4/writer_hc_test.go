@@ -0,0 +1,65 @@
+package lz4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeLevels(t *testing.T) {
+	data := testData(1 << 20)
+	fast, err := Encode(nil, data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := map[string]int{"fast": len(fast)}
+	encoders := map[string]func() ([]byte, error){
+		"accel 1":  func() ([]byte, error) { return EncodeFast(nil, data, true, 1) },
+		"accel 8":  func() ([]byte, error) { return EncodeFast(nil, data, true, 8) },
+		"hc min":   func() ([]byte, error) { return EncodeHC(nil, data, true, MinLevelHC) },
+		"hc":       func() ([]byte, error) { return EncodeHC(nil, data, true, 0) },
+		"hc max":   func() ([]byte, error) { return EncodeHC(nil, data, false, MaxLevelHC) },
+		"hc small": func() ([]byte, error) { return EncodeHC(nil, data[:100], true, 0) },
+	}
+	for name, encode := range encoders {
+		encoded, err := encode()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want := data
+		if name == "hc small" {
+			want = data[:100]
+		}
+		decoded, err := Decode(nil, encoded, name != "hc max")
+		if err != nil {
+			t.Fatalf("%s: Decode: %v", name, err)
+		}
+		if !bytes.Equal(decoded, want) {
+			t.Fatalf("%s: round trip mismatch", name)
+		}
+		sizes[name] = len(encoded)
+	}
+
+	if sizes["accel 1"] != sizes["fast"] {
+		t.Errorf("acceleration 1 differs from Encode: %v", sizes)
+	}
+	if !(sizes["hc max"] <= sizes["hc"] && sizes["hc"] <= sizes["hc min"] && sizes["hc min"] < sizes["fast"]) {
+		t.Errorf("higher levels should compress better: %v", sizes)
+	}
+	if sizes["accel 8"] < sizes["fast"] {
+		t.Errorf("acceleration should not compress better: %v", sizes)
+	}
+}
+
+func TestFrame_Level(t *testing.T) {
+	data := testData(300000)
+	fast := compressFrame(t, data, nil)
+	hc := compressFrame(t, data, &FrameOptions{BlockSize: BlockSize4M, Level: DefaultLevelHC})
+	if len(hc) >= len(fast) {
+		t.Errorf("hc frame %d bytes, fast %d bytes", len(hc), len(fast))
+	}
+	got, err := decompressFrame(hc)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("round trip: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package lz4
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// xxHash32 as used by the LZ4 frame format for header, block and content checksums.
+// See https://github.com/Cyan4973/xxHash/blob/dev/doc/xxhash_spec.md
+
+const (
+	prime32x1 uint32 = 2654435761
+	prime32x2 uint32 = 2246822519
+	prime32x3 uint32 = 3266489917
+	prime32x4 uint32 = 668265263
+	prime32x5 uint32 = 374761393
+)
+
+// xxh32 is a streaming xxHash32 digest.
+type xxh32 struct {
+	seed  uint32
+	v     [4]uint32
+	total uint64
+	buf   [16]byte
+	n     int
+}
+
+func newXXH32(seed uint32) *xxh32 {
+	h := &xxh32{}
+	h.reset(seed)
+	return h
+}
+
+func (h *xxh32) reset(seed uint32) {
+	h.seed = seed
+	h.v = [4]uint32{seed + prime32x1 + prime32x2, seed + prime32x2, seed, seed - prime32x1}
+	h.total = 0
+	h.n = 0
+}
+
+func xxh32Round(acc, input uint32) uint32 {
+	acc += input * prime32x2
+	acc = bits.RotateLeft32(acc, 13)
+	return acc * prime32x1
+}
+
+// Write adds p to the running hash. It never returns an error.
+func (h *xxh32) Write(p []byte) (int, error) {
+	n := len(p)
+	h.total += uint64(n)
+
+	if h.n > 0 {
+		c := copy(h.buf[h.n:], p)
+		h.n += c
+		p = p[c:]
+		if h.n < len(h.buf) {
+			return n, nil
+		}
+		h.stripes(h.buf[:])
+		h.n = 0
+	}
+
+	if full := len(p) &^ 15; full > 0 {
+		h.stripes(p[:full])
+		p = p[full:]
+	}
+	h.n = copy(h.buf[:], p)
+	return n, nil
+}
+
+// stripes consumes p, whose length must be a multiple of 16.
+func (h *xxh32) stripes(p []byte) {
+	v0, v1, v2, v3 := h.v[0], h.v[1], h.v[2], h.v[3]
+	for ; len(p) >= 16; p = p[16:] {
+		v0 = xxh32Round(v0, binary.LittleEndian.Uint32(p[0:]))
+		v1 = xxh32Round(v1, binary.LittleEndian.Uint32(p[4:]))
+		v2 = xxh32Round(v2, binary.LittleEndian.Uint32(p[8:]))
+		v3 = xxh32Round(v3, binary.LittleEndian.Uint32(p[12:]))
+	}
+	h.v = [4]uint32{v0, v1, v2, v3}
+}
+
+// Sum32 returns the hash of all data written so far.
+func (h *xxh32) Sum32() uint32 {
+	var acc uint32
+	if h.total >= 16 {
+		acc = bits.RotateLeft32(h.v[0], 1) + bits.RotateLeft32(h.v[1], 7) +
+			bits.RotateLeft32(h.v[2], 12) + bits.RotateLeft32(h.v[3], 18)
+	} else {
+		acc = h.seed + prime32x5
+	}
+	acc += uint32(h.total)
+
+	p := h.buf[:h.n]
+	for ; len(p) >= 4; p = p[4:] {
+		acc += binary.LittleEndian.Uint32(p) * prime32x3
+		acc = bits.RotateLeft32(acc, 17) * prime32x4
+	}
+	for _, b := range p {
+		acc += uint32(b) * prime32x5
+		acc = bits.RotateLeft32(acc, 11) * prime32x1
+	}
+
+	acc ^= acc >> 15
+	acc *= prime32x2
+	acc ^= acc >> 13
+	acc *= prime32x3
+	acc ^= acc >> 16
+	return acc
+}
+
+// xxh32Checksum returns the xxHash32 of p with seed 0.
+func xxh32Checksum(p []byte) uint32 {
+	var h xxh32
+	h.reset(0)
+	_, _ = h.Write(p)
+	return h.Sum32()
+}
@@ -11,8 +11,10 @@ import (
 	"strconv"
 	"sync"
 
+	"github.com/bobwong89757/gnbutils/log"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
 // ShardingConfig 分库分表配置
@@ -56,6 +58,8 @@ type ShardingManager struct {
 	databases     []*gorm.DB
 	databasesLock sync.RWMutex
 	initialized   bool
+	// GORM 日志，为空时使用 GORM 默认日志
+	logger gormlogger.Interface
 }
 
 // GetConfig 获取配置（用于外部访问）
@@ -72,6 +76,15 @@ func (sm *ShardingManager) IsInitialized() bool {
 	return sm.initialized
 }
 
+// SetLogger 设置 GORM 日志，需在 Init 之前调用
+// 如果是 *log.GormLogger，每个分库会自动附加 db_index 和 database 字段
+// 未设置时使用 log.Lookup("sharding") 找到的 logger（见 log.Register / log.SetDefault）
+func (sm *ShardingManager) SetLogger(l gormlogger.Interface) {
+	sm.databasesLock.Lock()
+	defer sm.databasesLock.Unlock()
+	sm.logger = l
+}
+
 var (
 	globalManager *ShardingManager
 	once          sync.Once
@@ -134,7 +147,8 @@ func (sm *ShardingManager) initDatabase(dbIndex int) (*gorm.DB, error) {
 	)
 
 	// 打开数据库连接
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	gormConfig := &gorm.Config{Logger: sm.gormLogger(dbIndex, dbName)}
+	db, err := gorm.Open(mysql.Open(dsn), gormConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect database %d: %w", dbIndex, err)
 	}
@@ -170,6 +184,21 @@ func (sm *ShardingManager) initDatabase(dbIndex int) (*gorm.DB, error) {
 	return db, nil
 }
 
+// gormLogger 返回分库使用的 GORM 日志，都未设置时返回 nil（使用 GORM 默认日志）
+func (sm *ShardingManager) gormLogger(dbIndex int, dbName string) gormlogger.Interface {
+	if gl, ok := sm.logger.(*log.GormLogger); ok {
+		return gl.WithFields("db_index", dbIndex, "database", dbName)
+	}
+	if sm.logger != nil {
+		return sm.logger
+	}
+	if l, ok := log.Lookup("sharding"); ok {
+		// 未设置日志时使用按名称登记的 "sharding" logger
+		return log.NewGormLogger(l, log.GormLoggerConfig{}).WithFields("db_index", dbIndex, "database", dbName)
+	}
+	return nil
+}
+
 // GetDB 根据分片键获取对应的数据库连接
 // 注意：由于每个表可能有不同的算法，这个方法无法确定使用哪个算法
 // 建议使用 GetDBForTable 方法，明确指定表名
@@ -0,0 +1,164 @@
+package sharding
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bobwong89757/gnbutils/log"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newTestManager 返回已初始化的管理器，分库用互不相同的空 *gorm.DB 代替真实连接
+func newTestManager() *ShardingManager {
+	config := &ShardingConfig{
+		DatabaseCount:    2,
+		DatabaseTemplate: DatabaseConfig{Database: "game_{db_index}"},
+		TableConfigs: map[string]*TableShardingConfig{
+			"game_player": {TableName: "game_player", ShardingKey: "player_id", Algorithm: NewLongShardingAlgorithm(), TableCount: 4},
+			"relate_user": {TableName: "relate_user", ShardingKey: "open_id", Algorithm: NewStringShardingAlgorithm(), TableCount: 8},
+		},
+	}
+	return &ShardingManager{
+		config:      config,
+		databases:   []*gorm.DB{{}, {}},
+		initialized: true,
+	}
+}
+
+func TestShardingManager_GetDBForTable(t *testing.T) {
+	sm := newTestManager()
+
+	tests := []struct {
+		table   string
+		value   interface{}
+		dbIndex int
+		wantErr string
+	}{
+		{"game_player", int64(10), 0, ""},
+		{"game_player", 7, 1, ""},
+		{"game_player", "13", 1, ""},
+		{"game_player", -3, 1, ""},
+		{"game_player", "abc", 0, "cannot convert"},
+		{"game_player", 1.5, 0, "unsupported type"},
+		{"relate_user", "test1013", stringShard("test1013", 2), ""},
+		{"unknown", 1, 0, "table config not found"},
+	}
+	for _, tt := range tests {
+		db, err := sm.GetDBForTable(tt.table, tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s/%v: want error containing %q, got %v", tt.table, tt.value, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s/%v: %v", tt.table, tt.value, err)
+			continue
+		}
+		if db != sm.databases[tt.dbIndex] {
+			t.Errorf("%s/%v: routed to the wrong database, want index %d", tt.table, tt.value, tt.dbIndex)
+		}
+	}
+
+	// GetDB 始终使用 Long 算法
+	if db, err := sm.GetDB(int64(3)); err != nil || db != sm.databases[1] {
+		t.Fatalf("GetDB: got %p, %v", db, err)
+	}
+	if _, err := sm.GetDBByIndex(2); err == nil {
+		t.Fatal("GetDBByIndex out of range: want error")
+	}
+	if _, err := new(ShardingManager).GetDBForTable("game_player", 1); err == nil {
+		t.Fatal("GetDBForTable before Init: want error")
+	}
+}
+
+// stringShard 按 Java hashCode 规则计算期望的分片索引
+func stringShard(s string, count int) int {
+	index, _ := NewStringShardingAlgorithm().CalculateShardIndex(s, count)
+	return index
+}
+
+func TestCalculateShardForTable(t *testing.T) {
+	manager := GetManager()
+	config, databases, initialized := manager.config, manager.databases, manager.initialized
+	defer func() {
+		manager.config, manager.databases, manager.initialized = config, databases, initialized
+	}()
+	test := newTestManager()
+	manager.config, manager.databases, manager.initialized = test.config, test.databases, true
+
+	info, err := CalculateShardForTable("game_player", int64(7))
+	if err != nil {
+		t.Fatalf("CalculateShardForTable: %v", err)
+	}
+	if info.DatabaseIndex != 1 || info.TableIndex != 3 || info.DatabaseName != "game_1" || info.TableName != "game_player_3" {
+		t.Fatalf("got %+v", info)
+	}
+
+	info, err = CalculateShardForTable("relate_user", "test1013")
+	if err != nil {
+		t.Fatalf("CalculateShardForTable: %v", err)
+	}
+	if want := "relate_user_" + strconv.Itoa(stringShard("test1013", 8)); info.TableName != want {
+		t.Fatalf("table name: got %s, want %s", info.TableName, want)
+	}
+	// 库和表使用同一算法，路由结果与 GetDBForTable 一致
+	db, _ := manager.GetDBForTable("relate_user", "test1013")
+	if db != manager.databases[info.DatabaseIndex] {
+		t.Fatal("CalculateShardForTable and GetDBForTable disagree")
+	}
+
+	if _, err := CalculateShardForTable("unknown", 1); err == nil {
+		t.Fatal("CalculateShardForTable unknown table: want error")
+	}
+}
+
+func TestReplacePlaceholder(t *testing.T) {
+	tests := []struct{ template, want string }{
+		{"game_{db_index}", "game_3"},
+		{"{db_index}_game_{db_index}", "3_game_3"},
+		{"game", "game"},
+		{"game_{db}", "game_{db}"},
+	}
+	for _, tt := range tests {
+		if got := replacePlaceholder(tt.template, "db_index", "3"); got != tt.want {
+			t.Errorf("replacePlaceholder(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestShardingManager_GormLogger(t *testing.T) {
+	sm := &ShardingManager{}
+	if l := sm.gormLogger(0, "game_0"); l != nil {
+		t.Fatalf("no logger configured: got %T", l)
+	}
+
+	// 登记的 "sharding" logger
+	var logger log.Log
+	config := log.NewLogConfig()
+	config.Type = log.TypeConsole
+	if err := logger.InitLogWithConfig(config, "sharding_test"); err != nil {
+		t.Fatalf("InitLogWithConfig: %v", err)
+	}
+	log.Register("sharding", &logger)
+	defer log.Register("sharding", nil)
+	if _, ok := sm.gormLogger(0, "game_0").(*log.GormLogger); !ok {
+		t.Fatal("registered logger: want *log.GormLogger")
+	}
+
+	// SetLogger 设置的 GormLogger 为每个分库附加字段，返回新的实例
+	gl := log.NewGormLogger(&logger, log.GormLoggerConfig{})
+	sm.SetLogger(gl)
+	if l, ok := sm.gormLogger(1, "game_1").(*log.GormLogger); !ok || l == gl {
+		t.Fatal("GormLogger should be copied with shard fields")
+	}
+
+	// 其他实现原样使用
+	other := gormlogger.Discard
+	sm.SetLogger(other)
+	if l := sm.gormLogger(1, "game_1"); l != other {
+		t.Fatalf("custom logger: got %T", l)
+	}
+}
@@ -0,0 +1,365 @@
+package static
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// DataPoolRegistry 多数据源连接池注册表
+// 按名称管理多个 MySQL / Redis 连接池，首次获取时才建立连接
+type DataPoolRegistry struct {
+	mu           sync.Mutex
+	mysqlConfigs map[string]*MysqlConfig
+	redisConfigs map[string]*RedisConfig
+	mysqlPools   map[string]*poolConn[*MysqlDataPool]
+	redisPools   map[string]*poolConn[*RedisDataPool]
+
+	// 建立连接的函数，测试中替换为假实现
+	openMysql func(*MysqlConfig) (*MysqlDataPool, error)
+	openRedis func(*RedisConfig) (*RedisDataPool, error)
+}
+
+// poolConn 单个数据源的连接，同名数据源的并发获取共享同一次连接
+// done 关闭后 pool 和 err 才可读
+type poolConn[T any] struct {
+	done chan struct{}
+	pool T
+	err  error
+}
+
+var (
+	globalRegistry     *DataPoolRegistry
+	globalRegistryOnce sync.Once
+)
+
+// NewDataPoolRegistry
+//
+//	@Description: 创建空的连接池注册表
+//	@return *DataPoolRegistry
+func NewDataPoolRegistry() *DataPoolRegistry {
+	return &DataPoolRegistry{
+		mysqlConfigs: make(map[string]*MysqlConfig),
+		redisConfigs: make(map[string]*RedisConfig),
+		mysqlPools:   make(map[string]*poolConn[*MysqlDataPool]),
+		redisPools:   make(map[string]*poolConn[*RedisDataPool]),
+		openMysql:    NewMysqlDataPool,
+		openRedis:    NewRedisDataPool,
+	}
+}
+
+// GetRegistry
+//
+//	@Description: 获取全局连接池注册表
+//	@return *DataPoolRegistry
+func GetRegistry() *DataPoolRegistry {
+	globalRegistryOnce.Do(func() {
+		globalRegistry = NewDataPoolRegistry()
+	})
+	return globalRegistry
+}
+
+// LoadFromViper
+//
+//	@Description: 从 Viper 配置加载命名数据源（只登记配置，不建立连接）
+//	@receiver r
+//	@param v Viper 实例
+//	@param configKey 配置键名，如 "datasources"
+//	@return error
+//
+// 配置示例:
+//
+//	datasources:
+//	  mysql:
+//	    game:
+//	      host: 127.0.0.1
+//	      port: 3306
+//	      username: root
+//	      password: password
+//	      database: game
+//	    log:
+//	      host: 127.0.0.1
+//	      database: game_log
+//	      maxOpenConns: 20
+//	  redis:
+//	    cache:
+//	      host: 127.0.0.1
+//	      port: 6379
+//	      db: 0
+//	    session:
+//	      mode: cluster
+//	      addrs: 10.0.0.1:7000,10.0.0.2:7000,10.0.0.3:7000
+func (r *DataPoolRegistry) LoadFromViper(v *viper.Viper, configKey string) error {
+	if v == nil {
+		return fmt.Errorf("viper is nil")
+	}
+	if !v.IsSet(configKey) {
+		return fmt.Errorf("datasource config not found at key: %s", configKey)
+	}
+
+	mysqlKey := fmt.Sprintf("%s.mysql", configKey)
+	for name := range v.GetStringMap(mysqlKey) {
+		config, err := MysqlConfigFromMap(v.GetStringMapString(fmt.Sprintf("%s.%s", mysqlKey, name)))
+		if err != nil {
+			return fmt.Errorf("invalid mysql datasource %s: %w", name, err)
+		}
+		if err := r.RegisterMysql(name, config); err != nil {
+			return err
+		}
+	}
+
+	redisKey := fmt.Sprintf("%s.redis", configKey)
+	for name := range v.GetStringMap(redisKey) {
+		config, err := RedisConfigFromMap(v.GetStringMapString(fmt.Sprintf("%s.%s", redisKey, name)))
+		if err != nil {
+			return fmt.Errorf("invalid redis datasource %s: %w", name, err)
+		}
+		if err := r.RegisterRedis(name, config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RegisterMysql
+//
+//	@Description: 登记 MySQL 数据源，名称在 MySQL 和 Redis 之间必须唯一
+//	@receiver r
+//	@param name 数据源名称
+//	@param config
+//	@return error
+func (r *DataPoolRegistry) RegisterMysql(name string, config *MysqlConfig) error {
+	if config == nil {
+		return fmt.Errorf("mysql config is nil for datasource %s", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.exists(name) {
+		return fmt.Errorf("datasource %s already registered", name)
+	}
+	r.mysqlConfigs[name] = config
+	return nil
+}
+
+// RegisterRedis
+//
+//	@Description: 登记 Redis 数据源，名称在 MySQL 和 Redis 之间必须唯一
+//	@receiver r
+//	@param name 数据源名称
+//	@param config
+//	@return error
+func (r *DataPoolRegistry) RegisterRedis(name string, config *RedisConfig) error {
+	if config == nil {
+		return fmt.Errorf("redis config is nil for datasource %s", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.exists(name) {
+		return fmt.Errorf("datasource %s already registered", name)
+	}
+	r.redisConfigs[name] = config
+	return nil
+}
+
+// exists 检查名称是否已登记（调用方需持有锁）
+func (r *DataPoolRegistry) exists(name string) bool {
+	_, isMysql := r.mysqlConfigs[name]
+	_, isRedis := r.redisConfigs[name]
+	return isMysql || isRedis
+}
+
+// Get
+//
+//	@Description: 按名称获取连接池，返回 *MysqlDataPool 或 *RedisDataPool
+//	@receiver r
+//	@param name 数据源名称
+//	@return interface{}
+//	@return error
+func (r *DataPoolRegistry) Get(name string) (interface{}, error) {
+	r.mu.Lock()
+	_, isMysql := r.mysqlConfigs[name]
+	_, isRedis := r.redisConfigs[name]
+	r.mu.Unlock()
+
+	switch {
+	case isMysql:
+		return r.GetMysql(name)
+	case isRedis:
+		return r.GetRedis(name)
+	default:
+		return nil, fmt.Errorf("datasource %s not registered", name)
+	}
+}
+
+// GetMysql
+//
+//	@Description: 按名称获取 MySQL 连接池，首次调用时建立连接
+//	@receiver r
+//	@param name 数据源名称
+//	@return *MysqlDataPool
+//	@return error
+func (r *DataPoolRegistry) GetMysql(name string) (*MysqlDataPool, error) {
+	return getPool(r, "mysql", name, r.mysqlConfigs, r.mysqlPools, r.openMysql)
+}
+
+// GetRedis
+//
+//	@Description: 按名称获取 Redis 连接池，首次调用时建立连接
+//	@receiver r
+//	@param name 数据源名称
+//	@return *RedisDataPool
+//	@return error
+func (r *DataPoolRegistry) GetRedis(name string) (*RedisDataPool, error) {
+	return getPool(r, "redis", name, r.redisConfigs, r.redisPools, r.openRedis)
+}
+
+// getPool
+//
+//	@Description: 获取或建立连接池。连接在锁外建立，慢的或不可达的数据源只阻塞获取同名数据源的调用；
+//	              连接失败不缓存，下次获取重新连接
+//	@param r
+//	@param kind 数据源类型，用于错误信息
+//	@param name 数据源名称
+//	@param configs 已登记的配置
+//	@param pools 已建立或正在建立的连接
+//	@param open 建立连接的函数
+//	@return T
+//	@return error
+func getPool[C any, T interface{ Close() error }](r *DataPoolRegistry, kind, name string,
+	configs map[string]C, pools map[string]*poolConn[T], open func(C) (T, error)) (T, error) {
+	var zero T
+
+	r.mu.Lock()
+	conn, ok := pools[name]
+	config, registered := configs[name]
+	if !ok {
+		if !registered {
+			r.mu.Unlock()
+			return zero, fmt.Errorf("%s datasource %s not registered", kind, name)
+		}
+		conn = &poolConn[T]{done: make(chan struct{})}
+		pools[name] = conn
+	}
+	r.mu.Unlock()
+
+	if ok {
+		<-conn.done
+	} else {
+		conn.pool, conn.err = open(config)
+		finishConn(r, kind, name, pools, conn)
+	}
+	if conn.err != nil {
+		return zero, conn.err
+	}
+	return conn.pool, nil
+}
+
+// finishConn
+//
+//	@Description: 发布连接结果并唤醒等待的调用
+//	@param r
+//	@param kind 数据源类型
+//	@param name 数据源名称
+//	@param pools
+//	@param conn 刚建立完成的连接
+func finishConn[T interface{ Close() error }](r *DataPoolRegistry, kind, name string, pools map[string]*poolConn[T], conn *poolConn[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// 在锁内唤醒，Close 看到的连接要么已完成，要么会在这里被发现已移除
+	defer close(conn.done)
+
+	current := pools[name] == conn
+	switch {
+	case conn.err != nil:
+		conn.err = fmt.Errorf("failed to open %s datasource %s: %w", kind, name, conn.err)
+		if current {
+			delete(pools, name)
+		}
+	case !current:
+		// 连接期间注册表已 Close，丢弃这个连接
+		_ = conn.pool.Close()
+		conn.err = fmt.Errorf("%s datasource %s closed while connecting", kind, name)
+	}
+}
+
+// GetDB
+//
+//	@Description: 按名称获取 GORM 连接，失败时返回 nil
+//	@receiver r
+//	@param name 数据源名称
+//	@return *gorm.DB
+func (r *DataPoolRegistry) GetDB(name string) *gorm.DB {
+	pool, err := r.GetMysql(name)
+	if err != nil {
+		fmt.Printf("Warning: could not get mysql datasource %s: %v\n", name, err)
+		return nil
+	}
+	return pool.GetDB()
+}
+
+// Names
+//
+//	@Description: 返回所有已登记的数据源名称（已排序）
+//	@receiver r
+//	@return []string
+func (r *DataPoolRegistry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.mysqlConfigs)+len(r.redisConfigs))
+	for name := range r.mysqlConfigs {
+		names = append(names, name)
+	}
+	for name := range r.redisConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close
+//
+//	@Description: 关闭所有已建立的连接，配置保留，之后再次获取会重新连接
+//	@receiver r
+//	@return error 所有关闭错误的合并
+func (r *DataPoolRegistry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for name, conn := range r.mysqlPools {
+		if err := closeConn(conn); err != nil {
+			errs = append(errs, fmt.Errorf("close mysql datasource %s: %w", name, err))
+		}
+		delete(r.mysqlPools, name)
+	}
+	for name, conn := range r.redisPools {
+		if err := closeConn(conn); err != nil {
+			errs = append(errs, fmt.Errorf("close redis datasource %s: %w", name, err))
+		}
+		delete(r.redisPools, name)
+	}
+	return errors.Join(errs...)
+}
+
+// closeConn
+//
+//	@Description: 关闭已建立的连接；正在建立的连接由 finishConn 发现已移除后自行关闭
+//	@param conn
+//	@return error
+func closeConn[T interface{ Close() error }](conn *poolConn[T]) error {
+	select {
+	case <-conn.done:
+		if conn.err != nil {
+			return nil
+		}
+		return conn.pool.Close()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,258 @@
+package static
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const registryYAML = `
+datasources:
+  mysql:
+    game:
+      host: 127.0.0.1
+      database: game
+      maxOpenConns: 20
+    log:
+      host: 127.0.0.1
+      database: game_log
+  redis:
+    cache:
+      host: 127.0.0.1
+      port: 6379
+`
+
+func newTestViper(t *testing.T, config string) *viper.Viper {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewBufferString(config)); err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	return v
+}
+
+// newFakeRegistry 返回不建立真实连接的注册表，并统计每个数据源的连接次数
+func newFakeRegistry() (*DataPoolRegistry, *sync.Map) {
+	r := NewDataPoolRegistry()
+	opened := new(sync.Map)
+	count := func(name string) {
+		n, _ := opened.LoadOrStore(name, new(int32))
+		atomic.AddInt32(n.(*int32), 1)
+	}
+	r.openMysql = func(c *MysqlConfig) (*MysqlDataPool, error) {
+		count(c.Database)
+		return &MysqlDataPool{}, nil
+	}
+	r.openRedis = func(c *RedisConfig) (*RedisDataPool, error) {
+		count(c.Host)
+		return &RedisDataPool{}, nil
+	}
+	return r, opened
+}
+
+func openCount(opened *sync.Map, name string) int32 {
+	n, ok := opened.Load(name)
+	if !ok {
+		return 0
+	}
+	return atomic.LoadInt32(n.(*int32))
+}
+
+func TestDataPoolRegistry_LoadFromViper(t *testing.T) {
+	r, opened := newFakeRegistry()
+	if err := r.LoadFromViper(newTestViper(t, registryYAML), "datasources"); err != nil {
+		t.Fatalf("LoadFromViper: %v", err)
+	}
+	if got := strings.Join(r.Names(), ","); got != "cache,game,log" {
+		t.Fatalf("Names: got %s", got)
+	}
+	if r.mysqlConfigs["game"].MaxOpenConns != 20 {
+		t.Fatalf("maxOpenConns not loaded: %+v", r.mysqlConfigs["game"])
+	}
+	// 只登记配置，不建立连接
+	if openCount(opened, "game") != 0 {
+		t.Fatal("LoadFromViper should not connect")
+	}
+
+	if err := r.LoadFromViper(newTestViper(t, registryYAML), "datasources"); err == nil {
+		t.Fatal("LoadFromViper twice: want duplicate name error")
+	}
+	if err := NewDataPoolRegistry().LoadFromViper(newTestViper(t, registryYAML), "missing"); err == nil {
+		t.Fatal("LoadFromViper: want error for missing key")
+	}
+	bad := "datasources:\n  mysql:\n    game:\n      host: db\n      port: abc\n"
+	if err := NewDataPoolRegistry().LoadFromViper(newTestViper(t, bad), "datasources"); err == nil {
+		t.Fatal("LoadFromViper: want error for invalid port")
+	}
+}
+
+func TestDataPoolRegistry_Get(t *testing.T) {
+	r, opened := newFakeRegistry()
+	if err := r.LoadFromViper(newTestViper(t, registryYAML), "datasources"); err != nil {
+		t.Fatalf("LoadFromViper: %v", err)
+	}
+
+	pool, err := r.Get("game")
+	if err != nil {
+		t.Fatalf("Get game: %v", err)
+	}
+	if _, ok := pool.(*MysqlDataPool); !ok {
+		t.Fatalf("Get game: got %T", pool)
+	}
+	pool, err = r.Get("cache")
+	if err != nil {
+		t.Fatalf("Get cache: %v", err)
+	}
+	if _, ok := pool.(*RedisDataPool); !ok {
+		t.Fatalf("Get cache: got %T", pool)
+	}
+	if _, err := r.Get("unknown"); err == nil {
+		t.Fatal("Get unknown: want error")
+	}
+	if _, err := r.GetRedis("game"); err == nil {
+		t.Fatal("GetRedis on a mysql datasource: want error")
+	}
+
+	// 并发获取只连接一次，且返回同一个连接池
+	var wg sync.WaitGroup
+	pools := make([]*MysqlDataPool, 10)
+	for i := range pools {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pools[i], _ = r.GetMysql("log")
+		}(i)
+	}
+	wg.Wait()
+	for _, p := range pools {
+		if p == nil || p != pools[0] {
+			t.Fatal("concurrent GetMysql returned different pools")
+		}
+	}
+	if n := openCount(opened, "game_log"); n != 1 {
+		t.Fatalf("log opened %d times, want 1", n)
+	}
+}
+
+func TestDataPoolRegistry_SlowBackendDoesNotBlockOthers(t *testing.T) {
+	r, _ := newFakeRegistry()
+	_ = r.RegisterMysql("slow", &MysqlConfig{Host: "slow"})
+	_ = r.RegisterMysql("fast", &MysqlConfig{Host: "fast"})
+
+	release := make(chan struct{})
+	r.openMysql = func(c *MysqlConfig) (*MysqlDataPool, error) {
+		if c.Host == "slow" {
+			<-release
+		}
+		return &MysqlDataPool{}, nil
+	}
+
+	slowDone := make(chan error)
+	go func() {
+		_, err := r.GetMysql("slow")
+		slowDone <- err
+	}()
+
+	fastDone := make(chan error)
+	go func() {
+		_, err := r.GetMysql("fast")
+		fastDone <- err
+	}()
+	select {
+	case err := <-fastDone:
+		if err != nil {
+			t.Fatalf("GetMysql fast: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("GetMysql fast blocked by a slow datasource")
+	}
+	if names := r.Names(); len(names) != 2 {
+		t.Fatalf("Names: got %v", names)
+	}
+
+	close(release)
+	if err := <-slowDone; err != nil {
+		t.Fatalf("GetMysql slow: %v", err)
+	}
+}
+
+func TestDataPoolRegistry_RetryAfterFailure(t *testing.T) {
+	r, _ := newFakeRegistry()
+	_ = r.RegisterRedis("cache", &RedisConfig{Host: "cache"})
+
+	fail := true
+	r.openRedis = func(c *RedisConfig) (*RedisDataPool, error) {
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		return &RedisDataPool{}, nil
+	}
+
+	if _, err := r.GetRedis("cache"); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("GetRedis: want connection error, got %v", err)
+	}
+	// 失败不缓存
+	fail = false
+	if _, err := r.GetRedis("cache"); err != nil {
+		t.Fatalf("GetRedis after recovery: %v", err)
+	}
+}
+
+func TestDataPoolRegistry_Close(t *testing.T) {
+	r, opened := newFakeRegistry()
+	if err := r.LoadFromViper(newTestViper(t, registryYAML), "datasources"); err != nil {
+		t.Fatalf("LoadFromViper: %v", err)
+	}
+	first, _ := r.GetMysql("game")
+	if _, err := r.GetRedis("cache"); err != nil {
+		t.Fatalf("GetRedis: %v", err)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if len(r.mysqlPools) != 0 || len(r.redisPools) != 0 {
+		t.Fatal("Close should drop all pools")
+	}
+	// 配置保留，再次获取重新连接
+	second, err := r.GetMysql("game")
+	if err != nil {
+		t.Fatalf("GetMysql after Close: %v", err)
+	}
+	if second == first || openCount(opened, "game") != 2 {
+		t.Fatal("GetMysql after Close should reconnect")
+	}
+}
+
+func TestDataPoolRegistry_CloseWhileConnecting(t *testing.T) {
+	r, _ := newFakeRegistry()
+	_ = r.RegisterMysql("game", &MysqlConfig{Host: "db"})
+
+	started, release := make(chan struct{}), make(chan struct{})
+	r.openMysql = func(c *MysqlConfig) (*MysqlDataPool, error) {
+		close(started)
+		<-release
+		return &MysqlDataPool{}, nil
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := r.GetMysql("game")
+		done <- err
+	}()
+	<-started
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	close(release)
+	if err := <-done; err == nil {
+		t.Fatal("GetMysql: want error when closed while connecting")
+	}
+}
@@ -0,0 +1,244 @@
+package static
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// redactedPassword 日志中替代密码的占位符
+const redactedPassword = "******"
+
+// MysqlConfig MySQL 连接配置
+type MysqlConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Database string
+
+	// 字符集，默认 utf8mb4
+	Charset string
+	// 排序规则，为空时使用驱动默认值
+	Collation string
+	// 时区，如 "Local"、"UTC"、"Asia/Shanghai"，为空时使用驱动默认值（UTC）
+	Loc string
+	// 是否将 DATE/DATETIME 解析为 time.Time
+	ParseTime bool
+
+	// TLS 模式: ""/"false"（不启用）、"true"、"skip-verify"、"preferred" 或通过 mysql.RegisterTLSConfig 注册的名称
+	TLS string
+	// 自定义 TLS 配置，优先级高于 TLS
+	TLSConfig *tls.Config
+
+	// 建立连接超时
+	Timeout time.Duration
+	// 读超时
+	ReadTimeout time.Duration
+	// 写超时
+	WriteTimeout time.Duration
+
+	// 最大打开连接数，<=0 表示不限制
+	MaxOpenConns int
+	// 最大空闲连接数，<=0 时使用 database/sql 默认值
+	MaxIdleConns int
+	// 连接最大存活时间，<=0 表示不限制
+	ConnMaxLifetime time.Duration
+	// 连接最大空闲时间，<=0 表示不限制
+	ConnMaxIdleTime time.Duration
+
+	// 额外的 DSN 参数
+	Params map[string]string
+
+	// GORM 配置，为空时使用 &gorm.Config{}
+	GormConfig *gorm.Config
+	// GORM 日志，设置后覆盖 GormConfig.Logger，可使用 log.NewGormLogger 输出到 zap
+	// 都未设置时使用 log.Lookup("static") 找到的 logger
+	Logger gormlogger.Interface
+}
+
+// NewMysqlConfig
+//
+//	@Description: 创建带默认值的 MySQL 配置（与 InitMysql 旧行为一致：utf8mb4 + parseTime）
+//	@return *MysqlConfig
+func NewMysqlConfig() *MysqlConfig {
+	return &MysqlConfig{
+		Port:      3306,
+		Charset:   "utf8mb4",
+		ParseTime: true,
+	}
+}
+
+// MysqlConfigFromMap
+//
+//	@Description: 从 map 配置构建 MySQL 配置，数值和时长解析失败时返回错误
+//	@param config 支持 host、port、username、password、database、charset、collation、loc、parseTime、
+//	       tls、timeout、readTimeout、writeTimeout、maxOpenConns、maxIdleConns、connMaxLifetime、connMaxIdleTime
+//	@return *MysqlConfig
+//	@return error
+func MysqlConfigFromMap(config map[string]string) (*MysqlConfig, error) {
+	// Viper 会把键名转为小写，这里统一按小写查找
+	get := lowerKeyGetter(config)
+
+	c := NewMysqlConfig()
+	c.Host = get("host")
+	c.Username = get("username")
+	c.Password = get("password")
+	c.Database = get("database")
+	c.Collation = get("collation")
+	c.Loc = get("loc")
+	c.TLS = get("tls")
+	if v := get("charset"); v != "" {
+		c.Charset = v
+	}
+
+	var err error
+	if v := get("port"); v != "" {
+		if c.Port, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid mysql port %q: %w", v, err)
+		}
+	}
+	if v := get("parseTime"); v != "" {
+		if c.ParseTime, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid mysql parseTime %q: %w", v, err)
+		}
+	}
+
+	durations := []struct {
+		key string
+		dst *time.Duration
+	}{
+		{"timeout", &c.Timeout},
+		{"readTimeout", &c.ReadTimeout},
+		{"writeTimeout", &c.WriteTimeout},
+		{"connMaxLifetime", &c.ConnMaxLifetime},
+		{"connMaxIdleTime", &c.ConnMaxIdleTime},
+	}
+	for _, d := range durations {
+		if v := get(d.key); v != "" {
+			if *d.dst, err = time.ParseDuration(v); err != nil {
+				return nil, fmt.Errorf("invalid mysql %s %q: %w", d.key, v, err)
+			}
+		}
+	}
+
+	ints := []struct {
+		key string
+		dst *int
+	}{
+		{"maxOpenConns", &c.MaxOpenConns},
+		{"maxIdleConns", &c.MaxIdleConns},
+	}
+	for _, n := range ints {
+		if v := get(n.key); v != "" {
+			if *n.dst, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("invalid mysql %s %q: %w", n.key, v, err)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// lowerKeyGetter
+//
+//	@Description: 返回忽略键名大小写的 map 取值函数
+//	@param config
+//	@return func(key string) string
+func lowerKeyGetter(config map[string]string) func(key string) string {
+	lower := make(map[string]string, len(config))
+	for k, v := range config {
+		lower[strings.ToLower(k)] = v
+	}
+	return func(key string) string {
+		return lower[strings.ToLower(key)]
+	}
+}
+
+// driverConfig
+//
+//	@Description: 转换为 go-sql-driver 的配置
+//	@receiver c
+//	@return *mysqldriver.Config
+//	@return error
+func (c *MysqlConfig) driverConfig() (*mysqldriver.Config, error) {
+	if c.Host == "" {
+		return nil, fmt.Errorf("mysql host is required")
+	}
+	port := c.Port
+	if port == 0 {
+		port = 3306
+	}
+
+	cfg := mysqldriver.NewConfig()
+	cfg.User = c.Username
+	cfg.Passwd = c.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(c.Host, strconv.Itoa(port))
+	cfg.DBName = c.Database
+	cfg.ParseTime = c.ParseTime
+	cfg.Timeout = c.Timeout
+	cfg.ReadTimeout = c.ReadTimeout
+	cfg.WriteTimeout = c.WriteTimeout
+	cfg.TLSConfig = c.TLS
+	cfg.TLS = c.TLSConfig
+	if c.Collation != "" {
+		cfg.Collation = c.Collation
+	}
+	if c.Loc != "" {
+		loc, err := time.LoadLocation(c.Loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mysql loc %q: %w", c.Loc, err)
+		}
+		cfg.Loc = loc
+	}
+
+	cfg.Params = make(map[string]string, len(c.Params)+1)
+	if c.Charset != "" {
+		cfg.Params["charset"] = c.Charset
+	}
+	for k, v := range c.Params {
+		cfg.Params[k] = v
+	}
+	return cfg, nil
+}
+
+// DSN
+//
+//	@Description: 生成完整的 DSN（包含密码，不要直接打印）
+//	@receiver c
+//	@return string
+//	@return error
+func (c *MysqlConfig) DSN() (string, error) {
+	cfg, err := c.driverConfig()
+	if err != nil {
+		return "", err
+	}
+	return cfg.FormatDSN(), nil
+}
+
+// RedactedDSN
+//
+//	@Description: 生成隐藏密码后的 DSN，用于日志输出
+//	@receiver c
+//	@return string
+func (c *MysqlConfig) RedactedDSN() string {
+	cfg, err := c.driverConfig()
+	if err != nil {
+		return fmt.Sprintf("<invalid mysql config: %v>", err)
+	}
+	if cfg.Passwd != "" {
+		cfg.Passwd = redactedPassword
+	}
+	if cfg.TLS != nil && cfg.TLSConfig == "" {
+		cfg.TLSConfig = "custom"
+	}
+	return cfg.FormatDSN()
+}
@@ -0,0 +1,89 @@
+package static
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMysqlConfigFromMap_Defaults(t *testing.T) {
+	c, err := MysqlConfigFromMap(map[string]string{"host": "db", "database": "app"})
+	if err != nil {
+		t.Fatalf("MysqlConfigFromMap: %v", err)
+	}
+	if c.Port != 3306 || c.Charset != "utf8mb4" || !c.ParseTime {
+		t.Fatalf("defaults: got port %d charset %q parseTime %v", c.Port, c.Charset, c.ParseTime)
+	}
+	if c.Timeout != 0 || c.MaxOpenConns != 0 || c.TLS != "" {
+		t.Fatalf("unset fields should stay zero: %+v", c)
+	}
+}
+
+func TestMysqlConfigFromMap_Values(t *testing.T) {
+	// Viper 读出的键名是小写的
+	c, err := MysqlConfigFromMap(map[string]string{
+		"host":            "db",
+		"port":            "3307",
+		"charset":         "utf8",
+		"parsetime":       "false",
+		"tls":             "skip-verify",
+		"readTimeout":     "3s",
+		"connmaxlifetime": "1h",
+		"maxopenconns":    "20",
+		"maxIdleConns":    "5",
+	})
+	if err != nil {
+		t.Fatalf("MysqlConfigFromMap: %v", err)
+	}
+	if c.Port != 3307 || c.Charset != "utf8" || c.ParseTime || c.TLS != "skip-verify" {
+		t.Fatalf("got %+v", c)
+	}
+	if c.ReadTimeout != 3*time.Second || c.ConnMaxLifetime != time.Hour {
+		t.Fatalf("durations: got read %v lifetime %v", c.ReadTimeout, c.ConnMaxLifetime)
+	}
+	if c.MaxOpenConns != 20 || c.MaxIdleConns != 5 {
+		t.Fatalf("pool: got open %d idle %d", c.MaxOpenConns, c.MaxIdleConns)
+	}
+}
+
+func TestMysqlConfigFromMap_Invalid(t *testing.T) {
+	for _, key := range []string{"port", "parseTime", "timeout", "maxOpenConns"} {
+		if _, err := MysqlConfigFromMap(map[string]string{"host": "db", key: "bad"}); err == nil {
+			t.Errorf("%s: want error for invalid value", key)
+		}
+	}
+}
+
+func TestMysqlConfig_RedactedDSN(t *testing.T) {
+	c := NewMysqlConfig()
+	c.Host = "db"
+	c.Username = "root"
+	c.Password = "s3cret"
+	c.Database = "app"
+
+	dsn, err := c.DSN()
+	if err != nil {
+		t.Fatalf("DSN: %v", err)
+	}
+	if !strings.Contains(dsn, "root:s3cret@tcp(db:3306)/app") {
+		t.Fatalf("DSN: got %q", dsn)
+	}
+
+	redacted := c.RedactedDSN()
+	if strings.Contains(redacted, "s3cret") {
+		t.Fatalf("RedactedDSN leaks the password: %q", redacted)
+	}
+	if !strings.Contains(redacted, "root:"+redactedPassword+"@tcp(db:3306)/app") {
+		t.Fatalf("RedactedDSN: got %q", redacted)
+	}
+
+	c.Password = ""
+	if redacted := c.RedactedDSN(); strings.Contains(redacted, redactedPassword) {
+		t.Fatalf("RedactedDSN without password: got %q", redacted)
+	}
+
+	c.Host = ""
+	if redacted := c.RedactedDSN(); !strings.HasPrefix(redacted, "<invalid mysql config") {
+		t.Fatalf("RedactedDSN without host: got %q", redacted)
+	}
+}
@@ -1,7 +1,11 @@
 package static
 
 import (
+	"database/sql"
 	"fmt"
+
+	"github.com/bobwong89757/gnbutils/log"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -10,6 +14,20 @@ type MysqlDataPool struct {
 	db *gorm.DB
 }
 
+// NewMysqlDataPool
+//
+//	@Description: 通过配置创建数据库连接池，失败时返回错误
+//	@param config
+//	@return *MysqlDataPool
+//	@return error
+func NewMysqlDataPool(config *MysqlConfig) (*MysqlDataPool, error) {
+	d := &MysqlDataPool{}
+	if err := d.InitMysqlWithOptions(config); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
 // InitMysql
 //
 //	@Description: 通过参数初始化数据库
@@ -20,10 +38,16 @@ type MysqlDataPool struct {
 //	@param password
 //	@param dbName
 func (d *MysqlDataPool) InitMysql(host string, port string, username string, password string, dbName string) {
-	var err error
-	url := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True", username, password, host, port, dbName)
-	fmt.Println("init mysql with " + url)
-	d.db, err = gorm.Open(mysql.Open(url), &gorm.Config{})
+	config, err := MysqlConfigFromMap(map[string]string{
+		"host":     host,
+		"port":     port,
+		"username": username,
+		"password": password,
+		"database": dbName,
+	})
+	if err == nil {
+		err = d.InitMysqlWithOptions(config)
+	}
 	if err != nil {
 		fmt.Println("could not init db " + err.Error())
 		panic("db error")
@@ -34,18 +58,74 @@ func (d *MysqlDataPool) InitMysql(host string, port string, username string, pas
 //
 //	@Description: 通过配置初始化数据库
 //	@receiver d
-//	@param config
+//	@param config 配置项见 MysqlConfigFromMap
 func (d *MysqlDataPool) InitMysqlWithConfig(config map[string]string) {
-	var err error
-	url := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True", config["username"], config["password"], config["host"], config["port"], config["database"])
-	fmt.Println("init mysql with " + url)
-	d.db, err = gorm.Open(mysql.Open(url), &gorm.Config{})
+	options, err := MysqlConfigFromMap(config)
+	if err == nil {
+		err = d.InitMysqlWithOptions(options)
+	}
 	if err != nil {
 		fmt.Println("could not init db " + err.Error())
 		panic("db error")
 	}
 }
 
+// InitMysqlWithOptions
+//
+//	@Description: 通过 MysqlConfig 初始化数据库，失败时返回错误
+//	@receiver d
+//	@param config
+//	@return error
+func (d *MysqlDataPool) InitMysqlWithOptions(config *MysqlConfig) error {
+	if config == nil {
+		return fmt.Errorf("mysql config is nil")
+	}
+	dsnConfig, err := config.driverConfig()
+	if err != nil {
+		return err
+	}
+	fmt.Println("init mysql with " + config.RedactedDSN())
+
+	connector, err := mysqldriver.NewConnector(dsnConfig)
+	if err != nil {
+		return fmt.Errorf("invalid mysql config: %w", err)
+	}
+	sqlDB := sql.OpenDB(connector)
+	if config.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
+
+	gormConfig := &gorm.Config{}
+	if config.GormConfig != nil {
+		copied := *config.GormConfig
+		gormConfig = &copied
+	}
+	if config.Logger != nil {
+		gormConfig.Logger = config.Logger
+	} else if gormConfig.Logger == nil {
+		// 未设置日志时使用按名称登记的 "static" logger
+		if l, ok := log.Lookup("static"); ok {
+			gormConfig.Logger = log.NewGormLogger(l, log.GormLoggerConfig{})
+		}
+	}
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, DSNConfig: dsnConfig}), gormConfig)
+	if err != nil {
+		_ = sqlDB.Close()
+		return fmt.Errorf("failed to connect mysql %s: %w", config.RedactedDSN(), err)
+	}
+	d.db = db
+	return nil
+}
+
 // GetDB
 //
 //	@Description: 获取数据库连接
@@ -54,3 +134,20 @@ func (d *MysqlDataPool) InitMysqlWithConfig(config map[string]string) {
 func (d *MysqlDataPool) GetDB() *gorm.DB {
 	return d.db
 }
+
+// Close
+//
+//	@Description: 关闭数据库连接
+//	@receiver d
+//	@return error
+func (d *MysqlDataPool) Close() error {
+	if d.db == nil {
+		return nil
+	}
+	sqlDB, err := d.db.DB()
+	if err != nil {
+		return err
+	}
+	d.db = nil
+	return sqlDB.Close()
+}
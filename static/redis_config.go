@@ -0,0 +1,250 @@
+package static
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 部署模式
+const (
+	RedisModeSingle   = "single"
+	RedisModeSentinel = "sentinel"
+	RedisModeCluster  = "cluster"
+)
+
+// defaultRedisPingTimeout 启动时 PING 的默认超时
+const defaultRedisPingTimeout = 5 * time.Second
+
+// RedisConfig Redis 连接配置
+type RedisConfig struct {
+	// 部署模式: single（默认）、sentinel、cluster
+	Mode string
+
+	// 单节点地址
+	Host string
+	Port int
+	// 集群模式的种子节点地址列表（host:port）
+	Addrs []string
+
+	// 哨兵模式的主节点名称
+	MasterName string
+	// 哨兵节点地址列表（host:port）
+	SentinelAddrs []string
+	// 哨兵节点的认证信息
+	SentinelUsername string
+	SentinelPassword string
+
+	Username string
+	Password string
+	// 数据库编号，集群模式下无效
+	DB int
+
+	// 是否启用 TLS
+	TLS bool
+	// 跳过证书校验（仅用于测试环境）
+	TLSSkipVerify bool
+	// 自定义 TLS 配置，设置后忽略 TLS / TLSSkipVerify
+	TLSConfig *tls.Config
+
+	// 建立连接超时
+	DialTimeout time.Duration
+	// 读超时
+	ReadTimeout time.Duration
+	// 写超时
+	WriteTimeout time.Duration
+	// 启动时 PING 超时，默认 5s
+	PingTimeout time.Duration
+
+	// 连接池大小（每个节点），<=0 时使用 go-redis 默认值
+	PoolSize int
+	// 最小空闲连接数
+	MinIdleConns int
+	// 最大空闲连接数
+	MaxIdleConns int
+}
+
+// RedisConfigFromMap
+//
+//	@Description: 从 map 配置构建 Redis 配置，数值和时长解析失败时返回错误
+//	@param config 支持 mode、host、port、addrs、masterName、sentinelAddrs、sentinelUsername、sentinelPassword、
+//	       username、pwd/password、db、tls、tlsSkipVerify、dialTimeout、readTimeout、writeTimeout、pingTimeout、
+//	       poolSize、minIdleConns、maxIdle/maxIdleConns，地址列表用逗号分隔
+//	@return *RedisConfig
+//	@return error
+func RedisConfigFromMap(config map[string]string) (*RedisConfig, error) {
+	// Viper 会把键名转为小写，这里统一按小写查找
+	get := lowerKeyGetter(config)
+
+	c := &RedisConfig{
+		Mode:             strings.ToLower(strings.TrimSpace(get("mode"))),
+		Host:             get("host"),
+		Addrs:            splitAddrs(get("addrs")),
+		MasterName:       get("masterName"),
+		SentinelAddrs:    splitAddrs(get("sentinelAddrs")),
+		SentinelUsername: get("sentinelUsername"),
+		SentinelPassword: get("sentinelPassword"),
+		Username:         get("username"),
+		Password:         get("password"),
+	}
+	if c.Password == "" {
+		c.Password = get("pwd")
+	}
+
+	var err error
+	bools := []struct {
+		key string
+		dst *bool
+	}{
+		{"tls", &c.TLS},
+		{"tlsSkipVerify", &c.TLSSkipVerify},
+	}
+	for _, b := range bools {
+		if v := get(b.key); v != "" {
+			if *b.dst, err = strconv.ParseBool(v); err != nil {
+				return nil, fmt.Errorf("invalid redis %s %q: %w", b.key, v, err)
+			}
+		}
+	}
+
+	ints := []struct {
+		key string
+		dst *int
+	}{
+		{"port", &c.Port},
+		{"db", &c.DB},
+		{"poolSize", &c.PoolSize},
+		{"minIdleConns", &c.MinIdleConns},
+		{"maxIdleConns", &c.MaxIdleConns},
+		{"maxIdle", &c.MaxIdleConns},
+	}
+	for _, n := range ints {
+		if v := get(n.key); v != "" {
+			if *n.dst, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("invalid redis %s %q: %w", n.key, v, err)
+			}
+		}
+	}
+
+	durations := []struct {
+		key string
+		dst *time.Duration
+	}{
+		{"dialTimeout", &c.DialTimeout},
+		{"readTimeout", &c.ReadTimeout},
+		{"writeTimeout", &c.WriteTimeout},
+		{"pingTimeout", &c.PingTimeout},
+	}
+	for _, d := range durations {
+		if v := get(d.key); v != "" {
+			if *d.dst, err = time.ParseDuration(v); err != nil {
+				return nil, fmt.Errorf("invalid redis %s %q: %w", d.key, v, err)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// splitAddrs 解析逗号分隔的地址列表
+func splitAddrs(s string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(s, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// universalOptions
+//
+//	@Description: 校验配置并转换为 go-redis 的通用配置
+//	@receiver c
+//	@return *redis.UniversalOptions
+//	@return error
+func (c *RedisConfig) universalOptions() (*redis.UniversalOptions, error) {
+	opts := &redis.UniversalOptions{
+		DB:               c.DB,
+		Username:         c.Username,
+		Password:         c.Password,
+		SentinelUsername: c.SentinelUsername,
+		SentinelPassword: c.SentinelPassword,
+		DialTimeout:      c.DialTimeout,
+		ReadTimeout:      c.ReadTimeout,
+		WriteTimeout:     c.WriteTimeout,
+		PoolSize:         c.PoolSize,
+		MinIdleConns:     c.MinIdleConns,
+		MaxIdleConns:     c.MaxIdleConns,
+		TLSConfig:        c.TLSConfig,
+	}
+	if opts.TLSConfig == nil && (c.TLS || c.TLSSkipVerify) {
+		opts.TLSConfig = &tls.Config{InsecureSkipVerify: c.TLSSkipVerify}
+	}
+
+	switch c.mode() {
+	case RedisModeSingle:
+		if c.Host == "" {
+			return nil, fmt.Errorf("redis host is required")
+		}
+		port := c.Port
+		if port == 0 {
+			port = 6379
+		}
+		opts.Addrs = []string{net.JoinHostPort(c.Host, strconv.Itoa(port))}
+	case RedisModeSentinel:
+		if c.MasterName == "" {
+			return nil, fmt.Errorf("redis masterName is required in sentinel mode")
+		}
+		if len(c.SentinelAddrs) == 0 {
+			return nil, fmt.Errorf("redis sentinelAddrs is required in sentinel mode")
+		}
+		opts.MasterName = c.MasterName
+		opts.Addrs = c.SentinelAddrs
+	case RedisModeCluster:
+		if len(c.Addrs) == 0 {
+			return nil, fmt.Errorf("redis addrs is required in cluster mode")
+		}
+		if c.DB != 0 {
+			return nil, fmt.Errorf("redis db must be 0 in cluster mode")
+		}
+		opts.Addrs = c.Addrs
+	default:
+		return nil, fmt.Errorf("unknown redis mode: %s", c.Mode)
+	}
+	return opts, nil
+}
+
+// mode 返回规范化后的部署模式
+func (c *RedisConfig) mode() string {
+	if c.Mode == "" {
+		return RedisModeSingle
+	}
+	return strings.ToLower(c.Mode)
+}
+
+// newClient
+//
+//	@Description: 按部署模式创建客户端（不建立连接）
+//	@receiver c
+//	@return redis.UniversalClient
+//	@return error
+func (c *RedisConfig) newClient() (redis.UniversalClient, error) {
+	opts, err := c.universalOptions()
+	if err != nil {
+		return nil, err
+	}
+	switch c.mode() {
+	case RedisModeSentinel:
+		return redis.NewFailoverClient(opts.Failover()), nil
+	case RedisModeCluster:
+		return redis.NewClusterClient(opts.Cluster()), nil
+	default:
+		return redis.NewClient(opts.Simple()), nil
+	}
+}
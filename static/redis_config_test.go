@@ -0,0 +1,93 @@
+package static
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedisConfigFromMap(t *testing.T) {
+	// Viper 读出的键名是小写的
+	c, err := RedisConfigFromMap(map[string]string{
+		"mode":          " Sentinel ",
+		"mastername":    "mymaster",
+		"sentinelAddrs": "10.0.0.1:26379, 10.0.0.2:26379,",
+		"pwd":           "secret",
+		"db":            "2",
+		"tls":           "true",
+		"dialtimeout":   "2s",
+		"maxIdle":       "8",
+	})
+	if err != nil {
+		t.Fatalf("RedisConfigFromMap: %v", err)
+	}
+	if c.Mode != RedisModeSentinel || c.MasterName != "mymaster" || c.Password != "secret" || c.DB != 2 {
+		t.Fatalf("got %+v", c)
+	}
+	if strings.Join(c.SentinelAddrs, ",") != "10.0.0.1:26379,10.0.0.2:26379" {
+		t.Fatalf("sentinelAddrs: got %q", c.SentinelAddrs)
+	}
+	if !c.TLS || c.DialTimeout != 2*time.Second || c.MaxIdleConns != 8 {
+		t.Fatalf("got tls %v dialTimeout %v maxIdleConns %d", c.TLS, c.DialTimeout, c.MaxIdleConns)
+	}
+
+	// password 优先于 pwd
+	c, _ = RedisConfigFromMap(map[string]string{"host": "cache", "password": "a", "pwd": "b"})
+	if c.Password != "a" {
+		t.Fatalf("password: got %q", c.Password)
+	}
+
+	for _, key := range []string{"port", "db", "tls", "readTimeout", "poolSize"} {
+		if _, err := RedisConfigFromMap(map[string]string{"host": "cache", key: "bad"}); err == nil {
+			t.Errorf("%s: want error for invalid value", key)
+		}
+	}
+}
+
+func TestRedisConfig_UniversalOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RedisConfig
+		addrs   string
+		wantErr string
+	}{
+		{"single default port", RedisConfig{Host: "cache"}, "cache:6379", ""},
+		{"single", RedisConfig{Mode: "SINGLE", Host: "cache", Port: 6380}, "cache:6380", ""},
+		{"single without host", RedisConfig{}, "", "host is required"},
+		{"sentinel", RedisConfig{Mode: RedisModeSentinel, MasterName: "m", SentinelAddrs: []string{"s1:26379"}}, "s1:26379", ""},
+		{"sentinel without master name", RedisConfig{Mode: RedisModeSentinel, SentinelAddrs: []string{"s1:26379"}}, "", "masterName is required"},
+		{"sentinel without addrs", RedisConfig{Mode: RedisModeSentinel, MasterName: "m"}, "", "sentinelAddrs is required"},
+		{"cluster", RedisConfig{Mode: RedisModeCluster, Addrs: []string{"n1:7000", "n2:7000"}}, "n1:7000,n2:7000", ""},
+		{"cluster without addrs", RedisConfig{Mode: RedisModeCluster, Host: "cache"}, "", "addrs is required"},
+		{"cluster with db", RedisConfig{Mode: RedisModeCluster, Addrs: []string{"n1:7000"}, DB: 1}, "", "db must be 0"},
+		{"unknown mode", RedisConfig{Mode: "ring", Host: "cache"}, "", "unknown redis mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := tt.config.universalOptions()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("want error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("universalOptions: %v", err)
+			}
+			if got := strings.Join(opts.Addrs, ","); got != tt.addrs {
+				t.Fatalf("addrs: got %s, want %s", got, tt.addrs)
+			}
+		})
+	}
+}
+
+func TestRedisConfig_TLS(t *testing.T) {
+	opts, _ := (&RedisConfig{Host: "cache"}).universalOptions()
+	if opts.TLSConfig != nil {
+		t.Fatal("TLS should be off by default")
+	}
+	opts, _ = (&RedisConfig{Host: "cache", TLSSkipVerify: true}).universalOptions()
+	if opts.TLSConfig == nil || !opts.TLSConfig.InsecureSkipVerify {
+		t.Fatal("TLSSkipVerify should enable TLS without verification")
+	}
+}
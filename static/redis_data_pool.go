@@ -1,33 +1,79 @@
 package static
 
 import (
+	"context"
 	"fmt"
+
 	"github.com/redis/go-redis/v9"
-	"strconv"
 )
 
 type RedisDataPool struct {
-	db *redis.Client
+	client redis.UniversalClient
+}
+
+// 通过配置创建redis连接池，失败时返回错误
+func NewRedisDataPool(config *RedisConfig) (*RedisDataPool, error) {
+	d := &RedisDataPool{}
+	if err := d.InitRedisWithOptions(config); err != nil {
+		return nil, err
+	}
+	return d, nil
 }
 
-// 初始化redis
+// 初始化redis，配置项见 RedisConfigFromMap
 func (d *RedisDataPool) InitRedisWithConfig(config map[string]string) (bool, error) {
-	address := fmt.Sprintf("%s:%s", config["host"], config["port"])
-	db, _ := strconv.Atoi(config["db"])
-	pwd := config["pwd"]
-	maxIdle, _ := strconv.Atoi(config["maxIdle"])
-
-	rdb := redis.NewClient(&redis.Options{
-		Network:      "tcp",
-		Addr:         address,
-		Password:     pwd,
-		DB:           db,
-		MaxIdleConns: maxIdle,
-	})
-	d.db = rdb
+	options, err := RedisConfigFromMap(config)
+	if err != nil {
+		return false, err
+	}
+	if err := d.InitRedisWithOptions(options); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
+// 通过 RedisConfig 初始化redis（单节点、哨兵或集群），启动时 PING 校验连接
+func (d *RedisDataPool) InitRedisWithOptions(config *RedisConfig) error {
+	if config == nil {
+		return fmt.Errorf("redis config is nil")
+	}
+	client, err := config.newClient()
+	if err != nil {
+		return err
+	}
+
+	pingTimeout := config.PingTimeout
+	if pingTimeout <= 0 {
+		pingTimeout = defaultRedisPingTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return fmt.Errorf("failed to ping redis (%s mode): %w", config.mode(), err)
+	}
+
+	d.client = client
+	return nil
+}
+
+// 获取单节点或哨兵模式的客户端，集群模式下返回 nil，请使用 GetClient
 func (d *RedisDataPool) GetDB() *redis.Client {
-	return d.db
+	client, _ := d.client.(*redis.Client)
+	return client
+}
+
+// 获取通用客户端，适用于所有部署模式
+func (d *RedisDataPool) GetClient() redis.UniversalClient {
+	return d.client
+}
+
+// 关闭redis连接
+func (d *RedisDataPool) Close() error {
+	if d.client == nil {
+		return nil
+	}
+	err := d.client.Close()
+	d.client = nil
+	return err
 }
@@ -0,0 +1,338 @@
+package static
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrLockNotAcquired 锁已被其他持有者占用
+	ErrLockNotAcquired = errors.New("redis lock not acquired")
+	// ErrLockNotHeld 当前实例未持有锁（未加锁、已释放或租约已过期）
+	ErrLockNotHeld = errors.New("redis lock not held")
+)
+
+// 加锁：SET NX PX 成功后递增 fencing 计数器，返回新的 fencing token；失败返回 0
+var lockAcquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+// 释放：仅当 token 匹配时删除
+var lockReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// 续期：仅当 token 匹配时延长过期时间
+var lockRenewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// RedisLockOptions 分布式锁配置
+type RedisLockOptions struct {
+	// 租约时长，默认 30s
+	TTL time.Duration
+	// 获取失败后的首次重试间隔，默认 50ms，之后指数退避
+	RetryInterval time.Duration
+	// 最大重试间隔，默认 1s
+	MaxRetryInterval time.Duration
+	// 续期间隔，默认 TTL/3
+	RenewInterval time.Duration
+	// 禁用自动续期
+	DisableRenew bool
+}
+
+// withDefaults 返回填充默认值后的配置副本
+func (o *RedisLockOptions) withDefaults() RedisLockOptions {
+	opts := RedisLockOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 30 * time.Second
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 50 * time.Millisecond
+	}
+	if opts.MaxRetryInterval < opts.RetryInterval {
+		opts.MaxRetryInterval = time.Second
+		if opts.MaxRetryInterval < opts.RetryInterval {
+			opts.MaxRetryInterval = opts.RetryInterval
+		}
+	}
+	if opts.RenewInterval <= 0 || opts.RenewInterval >= opts.TTL {
+		opts.RenewInterval = opts.TTL / 3
+	}
+	return opts
+}
+
+// RedisLock 基于 Redis 的分布式锁
+//
+// 每次成功加锁都会得到一个单调递增的 fencing token，写入下游存储时携带该值，
+// 下游拒绝比已见过的 token 更小的请求，即可避免锁过期后旧持有者的延迟写入。
+//
+// 集群模式下锁键和计数器键使用相同的 hash tag，保证落在同一个 slot。
+type RedisLock struct {
+	client   redis.Scripter
+	key      string
+	fenceKey string
+	opts     RedisLockOptions
+
+	mu        sync.Mutex
+	token     string
+	fence     int64
+	held      bool
+	lost      chan struct{}
+	stopRenew chan struct{}
+	renewDone chan struct{}
+}
+
+// NewRedisLock
+//
+//	@Description: 创建分布式锁（不会立即加锁）
+//	@param client Redis 客户端，支持 *redis.Client、*redis.ClusterClient 等
+//	@param name 锁名称
+//	@param opts 锁配置，为 nil 时使用默认值
+//	@return *RedisLock
+func NewRedisLock(client redis.Scripter, name string, opts *RedisLockOptions) *RedisLock {
+	return &RedisLock{
+		client:   client,
+		key:      fmt.Sprintf("lock:{%s}", name),
+		fenceKey: fmt.Sprintf("lock:{%s}:fence", name),
+		opts:     opts.withDefaults(),
+	}
+}
+
+// NewLock
+//
+//	@Description: 基于当前连接池创建分布式锁
+//	@receiver d
+//	@param name 锁名称
+//	@param opts 锁配置，为 nil 时使用默认值
+//	@return *RedisLock
+func (d *RedisDataPool) NewLock(name string, opts *RedisLockOptions) *RedisLock {
+	return NewRedisLock(d.client, name, opts)
+}
+
+// TryLock
+//
+//	@Description: 尝试加锁一次，不重试
+//	@receiver l
+//	@param ctx
+//	@return error 被占用时返回 ErrLockNotAcquired
+func (l *RedisLock) TryLock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held {
+		return fmt.Errorf("redis lock %s already held by this instance", l.key)
+	}
+
+	token, err := newLockToken()
+	if err != nil {
+		return err
+	}
+	fence, err := lockAcquireScript.Run(ctx, l.client, []string{l.key, l.fenceKey},
+		token, l.opts.TTL.Milliseconds()).Int64()
+	if err != nil {
+		return fmt.Errorf("acquire redis lock %s: %w", l.key, err)
+	}
+	if fence == 0 {
+		return ErrLockNotAcquired
+	}
+
+	l.token = token
+	l.fence = fence
+	l.held = true
+	l.lost = make(chan struct{})
+	if !l.opts.DisableRenew {
+		l.stopRenew = make(chan struct{})
+		l.renewDone = make(chan struct{})
+		go l.renewLoop(token, l.lost, l.stopRenew, l.renewDone)
+	}
+	return nil
+}
+
+// Lock
+//
+//	@Description: 加锁，被占用时按指数退避重试，直到成功或 ctx 结束
+//	@receiver l
+//	@param ctx
+//	@return error
+func (l *RedisLock) Lock(ctx context.Context) error {
+	interval := l.opts.RetryInterval
+	for {
+		err := l.TryLock(ctx)
+		if err == nil || !errors.Is(err, ErrLockNotAcquired) {
+			return err
+		}
+
+		// 加入最多 50% 的随机抖动，避免多个节点同时重试
+		wait := interval + time.Duration(mrand.Int63n(int64(interval)/2+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > l.opts.MaxRetryInterval {
+			interval = l.opts.MaxRetryInterval
+		}
+	}
+}
+
+// Unlock
+//
+//	@Description: 释放锁，仅删除自己持有的锁
+//	@receiver l
+//	@param ctx
+//	@return error 锁已过期或被他人持有时返回 ErrLockNotHeld
+func (l *RedisLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.held {
+		return ErrLockNotHeld
+	}
+	l.stopRenewLoop()
+	l.held = false
+
+	n, err := lockReleaseScript.Run(ctx, l.client, []string{l.key}, l.token).Int64()
+	if err != nil {
+		return fmt.Errorf("release redis lock %s: %w", l.key, err)
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Refresh
+//
+//	@Description: 手动续期（禁用自动续期时使用）
+//	@receiver l
+//	@param ctx
+//	@return error 锁已过期或被他人持有时返回 ErrLockNotHeld
+func (l *RedisLock) Refresh(ctx context.Context) error {
+	l.mu.Lock()
+	token, held := l.token, l.held
+	l.mu.Unlock()
+
+	if !held {
+		return ErrLockNotHeld
+	}
+	ok, err := l.renew(ctx, token)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// FencingToken
+//
+//	@Description: 获取本次加锁的 fencing token，未持有锁时返回 0
+//	@receiver l
+//	@return int64
+func (l *RedisLock) FencingToken() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.held {
+		return 0
+	}
+	return l.fence
+}
+
+// Lost
+//
+//	@Description: 返回锁丢失通知，自动续期失败（锁已过期或被他人持有）时关闭
+//	@receiver l
+//	@return <-chan struct{} 未持有锁时返回 nil
+func (l *RedisLock) Lost() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.held {
+		return nil
+	}
+	return l.lost
+}
+
+// renew 执行一次续期，返回锁是否仍由 token 持有
+func (l *RedisLock) renew(ctx context.Context, token string) (bool, error) {
+	n, err := lockRenewScript.Run(ctx, l.client, []string{l.key}, token, l.opts.TTL.Milliseconds()).Int64()
+	if err != nil {
+		return false, fmt.Errorf("renew redis lock %s: %w", l.key, err)
+	}
+	return n == 1, nil
+}
+
+// renewLoop 定期续期，直到被停止或确认锁已丢失
+func (l *RedisLock) renewLoop(token string, lost, stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(l.opts.RenewInterval)
+	defer ticker.Stop()
+
+	// 续期请求出错时，只要租约尚未到期就继续重试
+	expireAt := time.Now().Add(l.opts.TTL)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.opts.RenewInterval)
+		ok, err := l.renew(ctx, token)
+		cancel()
+
+		switch {
+		case err == nil && ok:
+			expireAt = time.Now().Add(l.opts.TTL)
+		case err == nil && !ok, time.Now().After(expireAt):
+			close(lost)
+			return
+		}
+	}
+}
+
+// stopRenewLoop 停止续期协程（调用方需持有锁）
+func (l *RedisLock) stopRenewLoop() {
+	if l.stopRenew == nil {
+		return
+	}
+	close(l.stopRenew)
+	<-l.renewDone
+	l.stopRenew = nil
+	l.renewDone = nil
+}
+
+// newLockToken 生成随机的锁持有者标识
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
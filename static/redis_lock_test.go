@@ -0,0 +1,188 @@
+package static
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeScripter 进程内的 Redis 替身，只实现锁脚本用到的语义
+type fakeScripter struct {
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+	counter map[string]int64
+}
+
+func newFakeScripter() *fakeScripter {
+	return &fakeScripter{
+		values:  make(map[string]string),
+		expires: make(map[string]time.Time),
+		counter: make(map[string]int64),
+	}
+}
+
+func (f *fakeScripter) get(key string) (string, bool) {
+	if exp, ok := f.expires[key]; ok && time.Now().After(exp) {
+		delete(f.values, key)
+		delete(f.expires, key)
+	}
+	v, ok := f.values[key]
+	return v, ok
+}
+
+func (f *fakeScripter) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ttl := func(i int) time.Duration {
+		return time.Duration(args[i].(int64)) * time.Millisecond
+	}
+
+	switch sha1 {
+	case lockAcquireScript.Hash():
+		if _, ok := f.get(keys[0]); ok {
+			return redis.NewCmdResult(int64(0), nil)
+		}
+		f.values[keys[0]] = args[0].(string)
+		f.expires[keys[0]] = time.Now().Add(ttl(1))
+		f.counter[keys[1]]++
+		return redis.NewCmdResult(f.counter[keys[1]], nil)
+	case lockReleaseScript.Hash():
+		if v, ok := f.get(keys[0]); ok && v == args[0].(string) {
+			delete(f.values, keys[0])
+			delete(f.expires, keys[0])
+			return redis.NewCmdResult(int64(1), nil)
+		}
+		return redis.NewCmdResult(int64(0), nil)
+	case lockRenewScript.Hash():
+		if v, ok := f.get(keys[0]); ok && v == args[0].(string) {
+			f.expires[keys[0]] = time.Now().Add(ttl(1))
+			return redis.NewCmdResult(int64(1), nil)
+		}
+		return redis.NewCmdResult(int64(0), nil)
+	}
+	return redis.NewCmdResult(nil, errors.New("unknown script"))
+}
+
+func (f *fakeScripter) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.EvalSha(ctx, redis.NewScript(script).Hash(), keys, args...)
+}
+
+func (f *fakeScripter) EvalRO(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.Eval(ctx, script, keys, args...)
+}
+
+func (f *fakeScripter) EvalShaRO(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.EvalSha(ctx, sha1, keys, args...)
+}
+
+func (f *fakeScripter) ScriptExists(ctx context.Context, hashes ...string) *redis.BoolSliceCmd {
+	return redis.NewBoolSliceResult(make([]bool, len(hashes)), nil)
+}
+
+func (f *fakeScripter) ScriptLoad(ctx context.Context, script string) *redis.StringCmd {
+	return redis.NewStringResult(redis.NewScript(script).Hash(), nil)
+}
+
+func TestRedisLock_FencingAndRelease(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeScripter()
+
+	first := NewRedisLock(client, "job", nil)
+	second := NewRedisLock(client, "job", nil)
+
+	if err := first.TryLock(ctx); err != nil {
+		t.Fatalf("first TryLock: %v", err)
+	}
+	if err := second.TryLock(ctx); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("second TryLock: want ErrLockNotAcquired, got %v", err)
+	}
+	fence := first.FencingToken()
+
+	if err := first.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if err := first.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("second Unlock: want ErrLockNotHeld, got %v", err)
+	}
+
+	if err := second.TryLock(ctx); err != nil {
+		t.Fatalf("second TryLock after release: %v", err)
+	}
+	defer second.Unlock(ctx)
+	if second.FencingToken() <= fence {
+		t.Fatalf("fencing token not increasing: %d <= %d", second.FencingToken(), fence)
+	}
+}
+
+func TestRedisLock_LockWaitsAndRespectsContext(t *testing.T) {
+	client := newFakeScripter()
+	opts := &RedisLockOptions{TTL: time.Second, RetryInterval: 5 * time.Millisecond, MaxRetryInterval: 20 * time.Millisecond}
+
+	holder := NewRedisLock(client, "cron", opts)
+	if err := holder.TryLock(context.Background()); err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	waiter := NewRedisLock(client, "cron", opts)
+	if err := waiter.Lock(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Lock: want DeadlineExceeded, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		_ = holder.Unlock(context.Background())
+	}()
+	if err := waiter.Lock(context.Background()); err != nil {
+		t.Fatalf("Lock after release: %v", err)
+	}
+	_ = waiter.Unlock(context.Background())
+}
+
+func TestRedisLock_AutoRenew(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeScripter()
+
+	lock := NewRedisLock(client, "renew", &RedisLockOptions{TTL: 60 * time.Millisecond})
+	if err := lock.TryLock(ctx); err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	select {
+	case <-lock.Lost():
+		t.Fatal("lock lost despite renewal")
+	default:
+	}
+	if err := NewRedisLock(client, "renew", nil).TryLock(ctx); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("want ErrLockNotAcquired while renewed, got %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+}
+
+func TestRedisLock_LostAfterExpiry(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeScripter()
+
+	lock := NewRedisLock(client, "expire", &RedisLockOptions{TTL: 30 * time.Millisecond, DisableRenew: true})
+	if err := lock.TryLock(ctx); err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if err := lock.Refresh(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Refresh: want ErrLockNotHeld, got %v", err)
+	}
+	if err := lock.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Unlock: want ErrLockNotHeld, got %v", err)
+	}
+}
@@ -3,9 +3,12 @@ package static
 import (
 	"fmt"
 
+	"github.com/bobwong89757/gnbutils/log"
 	"github.com/bobwong89757/gnbutils/sharding"
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
 // ShardingDataPool 分库分表数据库连接池
@@ -13,6 +16,20 @@ type ShardingDataPool struct {
 	manager *sharding.ShardingManager
 }
 
+// staticLogger 返回按名称登记的 "static" logger，未配置日志时不输出
+func staticLogger() *zap.SugaredLogger {
+	return log.Get("static").GetLog().With("component", "sharding")
+}
+
+// SetGormLogger
+//
+//	@Description: 设置分库连接使用的 GORM 日志，需在初始化之前调用
+//	@receiver d
+//	@param l 如 log.NewGormLogger(logger, log.GormLoggerConfig{})
+func (d *ShardingDataPool) SetGormLogger(l gormlogger.Interface) {
+	sharding.GetManager().SetLogger(l)
+}
+
 // InitShardingWithAutoConfig
 //
 //	@Description: 自动从配置初始化分库分表（自动合并 mysql 和 sharding 配置）
@@ -62,8 +79,8 @@ func (d *ShardingDataPool) InitShardingWithAutoConfig(v *viper.Viper) error {
 func (d *ShardingDataPool) InitShardingWithViper(v *viper.Viper, configKey string) {
 	err := sharding.InitFromViper(v, configKey)
 	if err != nil {
-		fmt.Println("could not init sharding: " + err.Error())
-		panic("sharding init error")
+		staticLogger().Errorw("could not init sharding", "error", err)
+		panic("sharding init error: " + err.Error())
 	}
 	d.manager = sharding.GetManager()
 }
@@ -80,60 +97,59 @@ func (d *ShardingDataPool) InitShardingWithViper(v *viper.Viper, configKey strin
 func (d *ShardingDataPool) InitShardingWithConfig(v *viper.Viper) {
 	// 检查 sharding 是否存在
 	if !v.IsSet("sharding") {
-		fmt.Println("could not init sharding: sharding config not found")
-		panic("sharding init error")
+		staticLogger().Error("could not init sharding: sharding config not found")
+		panic("sharding init error: sharding config not found")
 	}
 
 	var config *sharding.ShardingConfig
 	var err error
 
 	// 检测配置格式并打印日志
+	logger := staticLogger()
 	hasDatabaseTemplate := v.IsSet("sharding.database_template.host")
 	hasMysqlConfig := v.IsSet("mysql")
 
-	fmt.Printf("[Sharding Init] Has database_template: %v, Has mysql config: %v\n",
-		hasDatabaseTemplate, hasMysqlConfig)
+	logger.Debugw("detecting sharding config",
+		"has_database_template", hasDatabaseTemplate, "has_mysql_config", hasMysqlConfig)
 
 	// 智能检测配置格式
 	if hasDatabaseTemplate {
 		// 情况1: sharding 配置中包含完整的 database_template
-		fmt.Println("[Sharding Init] Using sharding.database_template config")
+		logger.Info("init sharding with sharding.database_template config")
 		config, err = sharding.LoadConfigFromViper(v, "sharding")
 	} else if hasMysqlConfig {
 		// 情况2: sharding 配置依赖 mysql 配置
-		fmt.Println("[Sharding Init] Using mysql config")
+		logger.Info("init sharding with mysql config")
 		config, err = sharding.LoadConfigFromViperWithMysql(v, "sharding", "mysql")
 	} else {
 		err = fmt.Errorf("neither sharding.database_template nor mysql config found")
 	}
 
 	if err != nil {
-		fmt.Printf("[Sharding Init] Failed to load config: %v\n", err)
-		fmt.Println("could not init sharding: " + err.Error())
-		panic("sharding init error")
+		logger.Errorw("could not load sharding config", "error", err)
+		panic("sharding init error: " + err.Error())
 	}
 
 	if config == nil {
-		fmt.Println("[Sharding Init] Config is nil but no error returned")
+		logger.Error("sharding config is nil but no error returned")
 		panic("sharding init error: config is nil")
 	}
 
 	// 打印配置信息用于调试
-	fmt.Printf("[Sharding Init] Config loaded - DB count: %d, Tables: %d\n",
-		config.DatabaseCount, len(config.TableConfigs))
-	fmt.Printf("[Sharding Init] DB Template - Host: %s, Port: %d, Database: %s\n",
-		config.DatabaseTemplate.Host, config.DatabaseTemplate.Port, config.DatabaseTemplate.Database)
+	logger.Infow("sharding config loaded",
+		"db_count", config.DatabaseCount, "tables", len(config.TableConfigs),
+		"host", config.DatabaseTemplate.Host, "port", config.DatabaseTemplate.Port,
+		"database", config.DatabaseTemplate.Database)
 
 	// 初始化管理器
 	manager := sharding.GetManager()
 	if err := manager.Init(config); err != nil {
-		fmt.Printf("[Sharding Init] Failed to init manager: %v\n", err)
-		fmt.Println("could not init sharding manager: " + err.Error())
-		panic("sharding init error")
+		logger.Errorw("could not init sharding manager", "error", err)
+		panic("sharding init error: " + err.Error())
 	}
 
 	d.manager = manager
-	fmt.Println("[Sharding Init] Initialization successful")
+	logger.Info("sharding initialized")
 }
 
 // InitShardingFromYAML
@@ -148,8 +164,8 @@ func (d *ShardingDataPool) InitShardingFromYAML(configPath string, configKey str
 	}
 	err := sharding.InitFromYAML(configPath, configKey)
 	if err != nil {
-		fmt.Println("could not init sharding: " + err.Error())
-		panic("sharding init error")
+		staticLogger().Errorw("could not init sharding", "path", configPath, "error", err)
+		panic("sharding init error: " + err.Error())
 	}
 	d.manager = sharding.GetManager()
 }
@@ -163,7 +179,7 @@ func (d *ShardingDataPool) InitShardingFromYAML(configPath string, configKey str
 func (d *ShardingDataPool) GetDB(shardingValue interface{}) *gorm.DB {
 	db, err := d.manager.GetDB(shardingValue)
 	if err != nil {
-		fmt.Printf("Warning: could not get sharding DB: %v, trying default DB\n", err)
+		staticLogger().Warnw("could not get sharding DB, trying default DB", "error", err)
 		db, _ = d.manager.GetDBByIndex(0)
 	}
 	return db
@@ -179,7 +195,7 @@ func (d *ShardingDataPool) GetDB(shardingValue interface{}) *gorm.DB {
 func (d *ShardingDataPool) GetDBForTable(tableName string, shardingValue interface{}) *gorm.DB {
 	db, err := d.manager.GetDBForTable(tableName, shardingValue)
 	if err != nil {
-		fmt.Printf("Warning: could not get sharding DB for table %s: %v, trying default DB\n", tableName, err)
+		staticLogger().Warnw("could not get sharding DB, trying default DB", "table", tableName, "error", err)
 		db, _ = d.manager.GetDBByIndex(0)
 	}
 	return db
@@ -194,7 +210,7 @@ func (d *ShardingDataPool) GetDBForTable(tableName string, shardingValue interfa
 func (d *ShardingDataPool) GetDBByIndex(dbIndex int) *gorm.DB {
 	db, err := d.manager.GetDBByIndex(dbIndex)
 	if err != nil {
-		fmt.Printf("Warning: could not get DB by index %d: %v\n", dbIndex, err)
+		staticLogger().Warnw("could not get DB by index", "index", dbIndex, "error", err)
 		return nil
 	}
 	return db
@@ -229,7 +245,7 @@ func (d *ShardingDataPool) GetAllDBs() []*gorm.DB {
 func (d *ShardingDataPool) GetShardedDB(tableName string, shardingValue interface{}) (*gorm.DB, string) {
 	db, tableFullName, err := sharding.GetShardedDB(tableName, shardingValue)
 	if err != nil {
-		fmt.Printf("Warning: could not get sharded DB for table %s: %v, using default DB\n", tableName, err)
+		staticLogger().Warnw("could not get sharded DB, using default DB", "table", tableName, "error", err)
 		defaultDB := d.GetDefaultDB()
 		if defaultDB != nil {
 			return defaultDB.Table(tableName), tableName
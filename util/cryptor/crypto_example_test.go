@@ -1,6 +1,10 @@
 package cryptor
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
 
 func ExampleAesEcbEncrypt() {
 	data := "hello"
@@ -254,7 +258,11 @@ func ExampleDesOfbDecrypt() {
 
 func ExampleGenerateRsaKey() {
 	// Create ras private and public pem file
-	err := GenerateRsaKey(4096, "rsa_private.pem", "rsa_public.pem")
+	dir, _ := os.MkdirTemp("", "rsa")
+	defer os.RemoveAll(dir)
+	priKeyFile := filepath.Join(dir, "rsa_private.pem")
+	pubKeyFile := filepath.Join(dir, "rsa_public.pem")
+	err := GenerateRsaKey(4096, priKeyFile, pubKeyFile)
 	if err != nil {
 		return
 	}
@@ -267,14 +275,18 @@ func ExampleGenerateRsaKey() {
 
 func ExampleRsaEncrypt() {
 	// Create ras private and public pem file
-	err := GenerateRsaKey(4096, "rsa_private.pem", "rsa_public.pem")
+	dir, _ := os.MkdirTemp("", "rsa")
+	defer os.RemoveAll(dir)
+	priKeyFile := filepath.Join(dir, "rsa_private.pem")
+	pubKeyFile := filepath.Join(dir, "rsa_public.pem")
+	err := GenerateRsaKey(4096, priKeyFile, pubKeyFile)
 	if err != nil {
 		return
 	}
 
 	data := []byte("hello")
-	encrypted := RsaEncrypt(data, "rsa_public.pem")
-	decrypted := RsaDecrypt(encrypted, "rsa_private.pem")
+	encrypted := RsaEncrypt(data, pubKeyFile)
+	decrypted := RsaDecrypt(encrypted, priKeyFile)
 
 	fmt.Println(string(decrypted))
 
@@ -284,14 +296,18 @@ func ExampleRsaEncrypt() {
 
 func ExampleRsaDecrypt() {
 	// Create ras private and public pem file
-	err := GenerateRsaKey(4096, "rsa_private.pem", "rsa_public.pem")
+	dir, _ := os.MkdirTemp("", "rsa")
+	defer os.RemoveAll(dir)
+	priKeyFile := filepath.Join(dir, "rsa_private.pem")
+	pubKeyFile := filepath.Join(dir, "rsa_public.pem")
+	err := GenerateRsaKey(4096, priKeyFile, pubKeyFile)
 	if err != nil {
 		return
 	}
 
 	data := []byte("hello")
-	encrypted := RsaEncrypt(data, "rsa_public.pem")
-	decrypted := RsaDecrypt(encrypted, "rsa_private.pem")
+	encrypted := RsaEncrypt(data, pubKeyFile)
+	decrypted := RsaDecrypt(encrypted, priKeyFile)
 
 	fmt.Println(string(decrypted))
 
@@ -1,6 +1,7 @@
 package cryptor
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/bobwong89757/gnbutils/util/internal"
@@ -117,13 +118,15 @@ func TestDesOfbEncrypt(t *testing.T) {
 }
 
 func TestRsaEncrypt(t *testing.T) {
-	err := GenerateRsaKey(4096, "rsa_private.pem", "rsa_public.pem")
+	priKeyFile := filepath.Join(t.TempDir(), "rsa_private.pem")
+	pubKeyFile := filepath.Join(t.TempDir(), "rsa_public.pem")
+	err := GenerateRsaKey(4096, priKeyFile, pubKeyFile)
 	if err != nil {
 		t.FailNow()
 	}
 	data := []byte("hello world")
-	encrypted := RsaEncrypt(data, "rsa_public.pem")
-	decrypted := RsaDecrypt(encrypted, "rsa_private.pem")
+	encrypted := RsaEncrypt(data, pubKeyFile)
+	decrypted := RsaDecrypt(encrypted, priKeyFile)
 
 	assert := internal.NewAssert(t, "TestRsaEncrypt")
 	assert.Equal(string(data), string(decrypted))